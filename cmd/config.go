@@ -59,6 +59,32 @@ func init() {
 	configCmd.Flags().IntVar(&conf.Config.TCPServer.Port, "tcpPort", 7078, "Node TCP port")
 	viper.BindPFlag("TCPServer.Host", configCmd.Flags().Lookup("tcpHost"))
 	viper.BindPFlag("TCPServer.Port", configCmd.Flags().Lookup("tcpPort"))
+	configCmd.Flags().BoolVar(&conf.Config.TCPServer.Secure, "tcpSecure", false, "Encrypt and authenticate connections to other nodes with the node key")
+	configCmd.Flags().BoolVar(&conf.Config.TCPServer.Strict, "tcpStrict", false, "Accept privileged requests only from authenticated full nodes")
+	viper.BindPFlag("TCPServer.Secure", configCmd.Flags().Lookup("tcpSecure"))
+	viper.BindPFlag("TCPServer.Strict", configCmd.Flags().Lookup("tcpStrict"))
+	configCmd.Flags().BoolVar(&conf.Config.TCPServer.Announce, "tcpAnnounce", false, "Share the node address with other nodes to serve blocks to them")
+	viper.BindPFlag("TCPServer.Announce", configCmd.Flags().Lookup("tcpAnnounce"))
+
+	// Snapshots
+	configCmd.Flags().Int64Var(&conf.Config.Snapshot.Interval, "snapshotInterval", 0, "Create state snapshot every N blocks rounded up to a multiple of 1000, 0 disables snapshots")
+	configCmd.Flags().IntVar(&conf.Config.Snapshot.Keep, "snapshotKeep", 2, "Count of stored state snapshots")
+	configCmd.Flags().BoolVar(&conf.Config.Snapshot.FastSync, "fastSync", false, "Load the state from the snapshot of other nodes")
+	viper.BindPFlag("Snapshot.Interval", configCmd.Flags().Lookup("snapshotInterval"))
+	viper.BindPFlag("Snapshot.Keep", configCmd.Flags().Lookup("snapshotKeep"))
+	viper.BindPFlag("Snapshot.FastSync", configCmd.Flags().Lookup("fastSync"))
+
+	// Replica
+	configCmd.Flags().StringSliceVar(&conf.Config.Replica.Validators, "replicaValidators", []string{}, "TCP addresses of the validators receiving transactions of the replica (default all full nodes)")
+	configCmd.Flags().BoolVar(&conf.Config.Replica.KeepHistory, "keepHistory", false, "Never prune the rollback records on the replica")
+	viper.BindPFlag("Replica.Validators", configCmd.Flags().Lookup("replicaValidators"))
+	viper.BindPFlag("Replica.KeepHistory", configCmd.Flags().Lookup("keepHistory"))
+
+	// Mempool
+	configCmd.Flags().IntVar(&conf.Config.Mempool.MaxSize, "mempoolSize", 10000, "Maximal count of the pending transactions (0 is unlimited)")
+	configCmd.Flags().IntVar(&conf.Config.Mempool.MaxPerKey, "mempoolPerKey", 100, "Maximal count of the pending transactions of one key_id (0 is unlimited)")
+	viper.BindPFlag("Mempool.MaxSize", configCmd.Flags().Lookup("mempoolSize"))
+	viper.BindPFlag("Mempool.MaxPerKey", configCmd.Flags().Lookup("mempoolPerKey"))
 
 	// HTTP Server
 	configCmd.Flags().StringVar(&conf.Config.HTTP.Host, "httpHost", "127.0.0.1", "Node HTTP host")
@@ -122,6 +148,14 @@ func init() {
 	viper.BindPFlag("TokenMovement.From", configCmd.Flags().Lookup("tmovFrom"))
 	viper.BindPFlag("TokenMovement.Subject", configCmd.Flags().Lookup("tmovSubj"))
 
+	// JWT
+	configCmd.Flags().StringVar(&conf.Config.JWT.Algorithm, "jwtAlg", "HS256", "Algorithm of api tokens signing, could be HS256|ES256")
+	configCmd.Flags().StringVar(&conf.Config.JWT.SigningKey, "jwtSigningKey", "", "ID of the key for signing api tokens (default the last key)")
+	configCmd.Flags().StringVar(&conf.Config.JWT.KeysFile, "jwtKeysFile", "", "Filepath to the json list of api token keys")
+	viper.BindPFlag("JWT.Algorithm", configCmd.Flags().Lookup("jwtAlg"))
+	viper.BindPFlag("JWT.SigningKey", configCmd.Flags().Lookup("jwtSigningKey"))
+	viper.BindPFlag("JWT.KeysFile", configCmd.Flags().Lookup("jwtKeysFile"))
+
 	// Etc
 	configCmd.Flags().StringVar(&conf.Config.PidFilePath, "pid", "",
 		fmt.Sprintf("Apla pid file name (default dataDir/%s)", consts.DefaultPidFilename),
@@ -137,9 +171,11 @@ func init() {
 	configCmd.Flags().StringVar(&conf.Config.TLSCert, "tls-cert", "", "Filepath to the fullchain of certificates")
 	configCmd.Flags().StringVar(&conf.Config.TLSKey, "tls-key", "", "Filepath to the private key")
 	configCmd.Flags().Int64Var(&conf.Config.MaxPageGenerationTime, "mpgt", 1000, "Max page generation time in ms")
+	configCmd.Flags().IntVar(&conf.Config.PageCacheSize, "pageCacheSize", 1000, "Count of the cached pages (0 disables the cache)")
+	configCmd.Flags().BoolVar(&conf.Config.Debugger, "debugger", false, "Allow the trace and the debugger of contracts for the local requests")
 	configCmd.Flags().Int64Var(&conf.Config.HTTPServerMaxBodySize, "mbs", 1<<20, "Max server body size in byte")
 	configCmd.Flags().StringSliceVar(&conf.Config.NodesAddr, "nodesAddr", []string{}, "List of addresses for downloading blockchain")
-	configCmd.Flags().StringVar(&conf.Config.OBSMode, "obsMode", consts.NoneVDE, "OBS running mode")
+	configCmd.Flags().StringVar(&conf.Config.OBSMode, "obsMode", consts.NoneVDE, "Running mode: OBS, OBSMaster or Replica")
 
 	viper.BindPFlag("PidFilePath", configCmd.Flags().Lookup("pid"))
 	viper.BindPFlag("LockFilePath", configCmd.Flags().Lookup("lock"))
@@ -150,6 +186,8 @@ func init() {
 	viper.BindPFlag("TLSCert", configCmd.Flags().Lookup("tls-cert"))
 	viper.BindPFlag("TLSKey", configCmd.Flags().Lookup("tls-key"))
 	viper.BindPFlag("MaxPageGenerationTime", configCmd.Flags().Lookup("mpgt"))
+	viper.BindPFlag("PageCacheSize", configCmd.Flags().Lookup("pageCacheSize"))
+	viper.BindPFlag("Debugger", configCmd.Flags().Lookup("debugger"))
 	viper.BindPFlag("HTTPServerMaxBodySize", configCmd.Flags().Lookup("mbs"))
 	viper.BindPFlag("TempDir", configCmd.Flags().Lookup("tempDir"))
 	viper.BindPFlag("NodesAddr", configCmd.Flags().Lookup("nodesAddr"))
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/AplaProject/go-apla/packages/script"
+	"github.com/AplaProject/go-apla/packages/smart"
+
+	"github.com/spf13/cobra"
+)
+
+var lintEcosystem int64
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [files]",
+	Short: "Check the source code of contracts",
+	Long: `Check the source code of contracts for the errors which the compiler accepts but the execution fails with.
+The contracts of the blockchain aren't loaded, so the calls of them are reported as warnings`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		smart.InitVM()
+		var failed bool
+		for _, file := range args {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+				continue
+			}
+			messages := smart.VMLint(smart.GetVM(), string(data), &script.OwnerInfo{StateID: uint32(lintEcosystem)})
+			for _, msg := range messages {
+				fmt.Printf("%s:%s\n", file, msg)
+			}
+			failed = failed || script.LintHasErrors(messages)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().Int64Var(&lintEcosystem, "ecosystem", 1, "ecosystem of contracts")
+}
@@ -21,6 +21,7 @@ func init() {
 		generateFirstBlockCmd,
 		generateKeysCmd,
 		initDatabaseCmd,
+		lintCmd,
 		rollbackCmd,
 		startCmd,
 		configCmd,
@@ -29,23 +29,49 @@
 package api
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/converter"
 	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/service"
 	"github.com/AplaProject/go-apla/packages/types"
+	"github.com/AplaProject/go-apla/packages/utils"
 
 	"github.com/dgrijalva/jwt-go"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	jwtAlgHS256      = "HS256"
+	jwtAlgES256      = "ES256"
+	jwtKeyIDHeader   = "kid"
+	jwtNodeKeyPrefix = "node-"
+	jwtRefreshType   = "refresh"
 )
 
 var (
-	jwtSecret = []byte(crypto.RandSeq(15))
+	jwtKeys   = newRandomJWTKeyring()
 	jwtPrefix = "Bearer "
 	jwtExpire = 36000 // By default, seconds
 
 	errJWTAuthValue      = errors.New("wrong authorization value")
+	errJWTUnknownKey     = errors.New("unknown key of token")
+	errJWTAlgorithm      = errors.New("unknown algorithm of token signing")
+	errJWTEmptyKey       = errors.New("key of token is empty")
 	errEcosystemNotFound = errors.New("ecosystem not found")
 )
 
@@ -56,12 +82,239 @@ type JWTClaims struct {
 	KeyID       string `json:"key_id,omitempty"`
 	RoleID      string `json:"role_id,omitempty"`
 	IsMobile    bool   `json:"is_mobile,omitempty"`
+	Type        string `json:"type,omitempty"`
+	IssuedAtNs  int64  `json:"iat_ns,omitempty"`
 	jwt.StandardClaims
 }
 
+// IsRefresh returns true if the claims belong to refresh token
+func (c *JWTClaims) IsRefresh() bool {
+	return c.Type == jwtRefreshType
+}
+
+// IsRevoked returns true if the session of the token has been revoked
+func (c *JWTClaims) IsRevoked() bool {
+	issuedAt := c.IssuedAtNs
+	if issuedAt == 0 {
+		// the tokens without iat_ns are revoked if they have been issued in the second of revocation
+		issuedAt = c.IssuedAt * int64(time.Second)
+	}
+	return service.GetSessionRevocationService().IsRevoked(c.Id, converter.StrToInt64(c.KeyID), issuedAt)
+}
+
+// jwtKeyring keeps the key for signing new tokens and all keys accepted for checking,
+// tokens refer to the key by kid header, so the keys can be rotated without logging out users
+type jwtKeyring struct {
+	method    jwt.SigningMethod
+	signingID string
+	signing   interface{}
+	secrets   map[string][]byte
+
+	nodeKeyID     int64
+	nodePublicKey *ecdsa.PublicKey
+}
+
+func newRandomJWTKeyring() *jwtKeyring {
+	secret := []byte(crypto.RandSeq(15))
+	return &jwtKeyring{
+		method:  jwt.SigningMethodHS256,
+		signing: secret,
+		secrets: map[string][]byte{"": secret},
+	}
+}
+
+func newJWTKeyring(cfg conf.JWTConfig, nodeKeyID int64, nodePrivateKey []byte) (*jwtKeyring, error) {
+	kr := &jwtKeyring{
+		secrets: make(map[string][]byte),
+	}
+
+	for _, key := range cfg.Keys {
+		if len(key.ID) == 0 || len(key.Secret) == 0 {
+			return nil, errJWTEmptyKey
+		}
+		kr.secrets[key.ID] = []byte(key.Secret)
+	}
+
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "", jwtAlgHS256:
+		if len(cfg.Keys) == 0 {
+			return newRandomJWTKeyring(), nil
+		}
+		kr.method = jwt.SigningMethodHS256
+		kr.signingID = cfg.SigningKey
+		if len(kr.signingID) == 0 {
+			kr.signingID = cfg.Keys[len(cfg.Keys)-1].ID
+		}
+		secret, ok := kr.secrets[kr.signingID]
+		if !ok {
+			return nil, fmt.Errorf("signing key %s is not found", kr.signingID)
+		}
+		kr.signing = secret
+
+	case jwtAlgES256:
+		if len(nodePrivateKey) == 0 {
+			return nil, errJWTEmptyKey
+		}
+		curve := elliptic.P256()
+		priv := new(ecdsa.PrivateKey)
+		priv.Curve = curve
+		priv.D = new(big.Int).SetBytes(nodePrivateKey)
+		priv.X, priv.Y = curve.ScalarBaseMult(nodePrivateKey)
+
+		kr.method = jwt.SigningMethodES256
+		kr.signingID = jwtNodeKeyPrefix + converter.Int64ToStr(nodeKeyID)
+		kr.signing = priv
+		kr.nodeKeyID = nodeKeyID
+		kr.nodePublicKey = &priv.PublicKey
+
+	default:
+		return nil, errJWTAlgorithm
+	}
+
+	return kr, nil
+}
+
+func (kr *jwtKeyring) sign(claims JWTClaims) (string, error) {
+	token := jwt.NewWithClaims(kr.method, claims)
+	if len(kr.signingID) > 0 {
+		token.Header[jwtKeyIDHeader] = kr.signingID
+	}
+	return token.SignedString(kr.signing)
+}
+
+func (kr *jwtKeyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header[jwtKeyIDHeader].(string)
+
+	// only the algorithm configured for the node is accepted, otherwise HS256 nodes
+	// would accept the tokens signed by the keys of other nodes
+	if token.Method.Alg() != kr.method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(kid) == 0 {
+			// tokens without kid have been issued before the keys were configured
+			if secret, ok := kr.signing.([]byte); ok {
+				return secret, nil
+			}
+			return nil, errJWTUnknownKey
+		}
+		if secret, ok := kr.secrets[kid]; ok {
+			return secret, nil
+		}
+		return nil, errJWTUnknownKey
+
+	case *jwt.SigningMethodECDSA:
+		if !strings.HasPrefix(kid, jwtNodeKeyPrefix) {
+			return nil, errJWTUnknownKey
+		}
+		return kr.nodeKey(converter.StrToInt64(kid[len(jwtNodeKeyPrefix):]))
+	}
+
+	return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+}
+
+// nodeKey returns the public key of the node which has signed the token,
+// tokens of other nodes are checked with the keys of full_nodes
+func (kr *jwtKeyring) nodeKey(keyID int64) (*ecdsa.PublicKey, error) {
+	if kr.nodePublicKey != nil && keyID == kr.nodeKeyID {
+		return kr.nodePublicKey, nil
+	}
+
+	node := syspar.GetNode(keyID)
+	if node == nil || len(node.PublicKey) != consts.PubkeySizeLength {
+		return nil, errJWTUnknownKey
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(node.PublicKey[:consts.PrivkeyLength]),
+		Y:     new(big.Int).SetBytes(node.PublicKey[consts.PrivkeyLength:]),
+	}, nil
+}
+
+func loadJWTKeys(path string) ([]conf.JWTKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": path}).Error("reading jwt keys file")
+		return nil, err
+	}
+
+	var keys []conf.JWTKey
+	if err = json.Unmarshal(data, &keys); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err, "path": path}).Error("unmarshalling jwt keys")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// InitJWT loads the keys of api tokens from config
+func InitJWT(cfg conf.JWTConfig) error {
+	if len(cfg.KeysFile) > 0 {
+		path := cfg.KeysFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(conf.Config.KeysDir, path)
+		}
+		keys, err := loadJWTKeys(path)
+		if err != nil {
+			return err
+		}
+		cfg.Keys = append(cfg.Keys, keys...)
+	}
+
+	var nodePrivateKey []byte
+	if strings.ToUpper(cfg.Algorithm) == jwtAlgES256 {
+		var err error
+		if nodePrivateKey, err = utils.GetNodePrivateKey(); err != nil {
+			return err
+		}
+	}
+
+	kr, err := newJWTKeyring(cfg, conf.Config.KeyID, nodePrivateKey)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JWTError, "error": err}).Error("loading jwt keys")
+		return err
+	}
+	if len(kr.signingID) == 0 {
+		log.WithFields(log.Fields{"type": consts.JWTError}).Warning("jwt keys are not configured, tokens will be invalid after restart")
+	}
+
+	jwtKeys = kr
+	return nil
+}
+
 func generateJWTToken(claims JWTClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return jwtKeys.sign(claims)
+}
+
+// generateSessionTokens starts the new session and returns the access token which expires
+// in expire seconds and the refresh token for prolonging the session
+func generateSessionTokens(claims JWTClaims, expire int64) (token, refresh string, err error) {
+	if claims.Id, err = newSessionID(); err != nil {
+		return
+	}
+
+	now := time.Now()
+	claims.IssuedAt = now.Unix()
+	claims.IssuedAtNs = now.UnixNano()
+	claims.ExpiresAt = now.Add(time.Second * time.Duration(expire)).Unix()
+	if token, err = generateJWTToken(claims); err != nil {
+		return
+	}
+
+	claims.Type = jwtRefreshType
+	claims.ExpiresAt = now.Add(service.SessionLifetime).Unix()
+	refresh, err = generateJWTToken(claims)
+	return
+}
+
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
 func parseJWTToken(header string) (*jwt.Token, error) {
@@ -75,12 +328,7 @@ func parseJWTToken(header string) (*jwt.Token, error) {
 		return nil, errJWTAuthValue
 	}
 
-	return jwt.ParseWithClaims(header, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtSecret), nil
-	})
+	return jwt.ParseWithClaims(header, &JWTClaims{}, jwtKeys.keyFunc)
 }
 
 func getClientFromToken(token *jwt.Token, ecosysNameService types.EcosystemNameGetter) (*Client, error) {
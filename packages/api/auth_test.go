@@ -0,0 +1,168 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/service"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseWithKeyring(kr *jwtKeyring, token string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(token, &JWTClaims{}, kr.keyFunc)
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	claims := JWTClaims{KeyID: "123", EcosystemID: "1"}
+
+	oldKeys, err := newJWTKeyring(conf.JWTConfig{
+		Keys: []conf.JWTKey{{ID: "k1", Secret: "first"}},
+	}, 0, nil)
+	require.NoError(t, err)
+	oldToken, err := oldKeys.sign(claims)
+	require.NoError(t, err)
+
+	newKeys, err := newJWTKeyring(conf.JWTConfig{
+		Keys: []conf.JWTKey{{ID: "k1", Secret: "first"}, {ID: "k2", Secret: "second"}},
+	}, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", newKeys.signingID)
+
+	token, err := parseWithKeyring(newKeys, oldToken)
+	require.NoError(t, err)
+	assert.True(t, token.Valid)
+	assert.Equal(t, "123", token.Claims.(*JWTClaims).KeyID)
+
+	newToken, err := newKeys.sign(claims)
+	require.NoError(t, err)
+	_, err = parseWithKeyring(oldKeys, newToken)
+	assert.Error(t, err)
+
+	_, err = newJWTKeyring(conf.JWTConfig{
+		SigningKey: "k3",
+		Keys:       []conf.JWTKey{{ID: "k1", Secret: "first"}},
+	}, 0, nil)
+	assert.Error(t, err)
+}
+
+func TestJWTWithoutKeys(t *testing.T) {
+	kr, err := newJWTKeyring(conf.JWTConfig{}, 0, nil)
+	require.NoError(t, err)
+	assert.Empty(t, kr.signingID)
+
+	tokenString, err := kr.sign(JWTClaims{KeyID: "1"})
+	require.NoError(t, err)
+	token, err := parseWithKeyring(kr, tokenString)
+	require.NoError(t, err)
+	assert.True(t, token.Valid)
+}
+
+func TestJWTNodeKey(t *testing.T) {
+	priv, _, err := crypto.GenHexKeys()
+	require.NoError(t, err)
+	privateKey, err := hex.DecodeString(priv)
+	require.NoError(t, err)
+
+	kr, err := newJWTKeyring(conf.JWTConfig{Algorithm: "ES256"}, 42, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, "node-42", kr.signingID)
+
+	tokenString, err := kr.sign(JWTClaims{KeyID: "7"})
+	require.NoError(t, err)
+	token, err := parseWithKeyring(kr, tokenString)
+	require.NoError(t, err)
+	assert.True(t, token.Valid)
+	assert.Equal(t, "ES256", token.Method.Alg())
+
+	hmacKeys, err := newJWTKeyring(conf.JWTConfig{}, 0, nil)
+	require.NoError(t, err)
+	_, err = parseWithKeyring(hmacKeys, tokenString)
+	assert.Error(t, err)
+
+	// HS256 node must not accept ES256 tokens even if it knows the key of the node
+	hmacKeys.nodeKeyID, hmacKeys.nodePublicKey = kr.nodeKeyID, kr.nodePublicKey
+	_, err = parseWithKeyring(hmacKeys, tokenString)
+	assert.Error(t, err)
+
+	esKeys, err := newJWTKeyring(conf.JWTConfig{Algorithm: "ES256",
+		Keys: []conf.JWTKey{{ID: "k1", Secret: "first"}}}, 42, privateKey)
+	require.NoError(t, err)
+	hsKeys, err := newJWTKeyring(conf.JWTConfig{Keys: []conf.JWTKey{{ID: "k1", Secret: "first"}}}, 0, nil)
+	require.NoError(t, err)
+	hsToken, err := hsKeys.sign(JWTClaims{KeyID: "7"})
+	require.NoError(t, err)
+	_, err = parseWithKeyring(esKeys, hsToken)
+	assert.Error(t, err)
+}
+
+func TestSessionTokens(t *testing.T) {
+	token, refresh, err := generateSessionTokens(JWTClaims{KeyID: "-100", EcosystemID: "1"}, 60)
+	require.NoError(t, err)
+
+	access, err := parseWithKeyring(jwtKeys, token)
+	require.NoError(t, err)
+	accessClaims := access.Claims.(*JWTClaims)
+	assert.False(t, accessClaims.IsRefresh())
+	assert.NotEmpty(t, accessClaims.Id)
+
+	rt, err := parseWithKeyring(jwtKeys, refresh)
+	require.NoError(t, err)
+	refreshClaims := rt.Claims.(*JWTClaims)
+	assert.True(t, refreshClaims.IsRefresh())
+	assert.Equal(t, accessClaims.Id, refreshClaims.Id)
+	assert.False(t, refreshClaims.IsRevoked())
+
+	revocation := service.GetSessionRevocationService()
+	require.NoError(t, revocation.RevokeSession(accessClaims.Id, -100, time.Now().Add(time.Minute)))
+	assert.True(t, accessClaims.IsRevoked())
+	assert.True(t, refreshClaims.IsRevoked())
+
+	_, other, err := generateSessionTokens(JWTClaims{KeyID: "-100", EcosystemID: "1"}, 60)
+	require.NoError(t, err)
+	rt, err = parseWithKeyring(jwtKeys, other)
+	require.NoError(t, err)
+	assert.False(t, rt.Claims.(*JWTClaims).IsRevoked())
+
+	require.NoError(t, revocation.RevokeKey(-100))
+	assert.True(t, rt.Claims.(*JWTClaims).IsRevoked())
+
+	// the session started right after the revocation in the same second is valid
+	token, _, err = generateSessionTokens(JWTClaims{KeyID: "-100", EcosystemID: "1"}, 60)
+	require.NoError(t, err)
+	access, err = parseWithKeyring(jwtKeys, token)
+	require.NoError(t, err)
+	assert.False(t, access.Claims.(*JWTClaims).IsRevoked())
+}
@@ -197,6 +197,9 @@ type BlockHeaderInfo struct {
 	NodePosition int64  `json:"node_position"`
 	Sign         []byte `json:"-"`
 	Hash         []byte `json:"-"`
+	StateRoot    []byte `json:"state_root"`
+	SnapshotHash []byte `json:"snapshot_hash,omitempty"`
+	Commit       []byte `json:"commit,omitempty"`
 	Version      int    `json:"version"`
 }
 
@@ -274,6 +277,9 @@ func getBlocksDetailedInfoHandler(w http.ResponseWriter, r *http.Request) {
 			NodePosition: blck.Header.NodePosition,
 			Sign:         blck.Header.Sign,
 			Hash:         blck.Header.Hash,
+			StateRoot:    blck.Header.StateRoot,
+			SnapshotHash: blck.Header.SnapshotHash,
+			Commit:       blck.Header.Commit,
 			Version:      blck.Header.Version,
 		}
 
@@ -32,7 +32,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"html"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -42,6 +46,7 @@ import (
 	"github.com/AplaProject/go-apla/packages/converter"
 	"github.com/AplaProject/go-apla/packages/crypto"
 	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/pagecache"
 	"github.com/AplaProject/go-apla/packages/template"
 
 	"github.com/gorilla/mux"
@@ -161,21 +166,84 @@ func pageValue(r *http.Request) (*model.Page, string, error) {
 	return page, ecosystem, nil
 }
 
-func getPage(r *http.Request) (result *contentResult, err error) {
+type pageRender func(input string, timeout *bool, vars *map[string]string, deps *pagecache.Deps) []byte
+
+// sourceKind is the prefix of the kind of the rendered source of data, the menu isn't rendered for it
+const sourceKind = `source/`
+
+func getPage(r *http.Request) (result *contentResult, hash string, err error) {
+	return renderPage(r, `json`, template.Template2JSONDeps)
+}
+
+// pageCacheKey returns the key of the rendered page in the page cache. The variables of the page
+// include the key, the role and the language of the client so they are the part of the key
+func pageCacheKey(kind string, page *model.Page, vars *map[string]string) string {
+	keys := make([]string, 0, len(*vars))
+	for key := range *vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := []string{kind, converter.Int64ToStr(page.ID)}
+	for _, key := range keys {
+		list = append(list, key+`=`+(*vars)[key])
+	}
+	return strings.Join(list, "\x00")
+}
+
+// pageHash returns the hash of the rendered page or its part which is used as ETag
+func pageHash(r *http.Request, result *contentResult) (string, error) {
+	logger := getLogger(r)
+	out, err := json.Marshal(result)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("getting string for hash")
+		return ``, errServer
+	}
+	ret, err := crypto.Hash(out)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("calculating hash of the page")
+		return ``, errServer
+	}
+	return hex.EncodeToString(ret), nil
+}
+
+// renderPage renders the page and its menu with the limit of the generation time. The result is
+// taken from the page cache if no block has changed the data which the page has read
+func renderPage(r *http.Request, kind string, render pageRender) (result *contentResult, hash string, err error) {
 	page, _, err := pageValue(r)
 	if err != nil {
-		return nil, err
+		return nil, ``, err
 	}
 
 	logger := getLogger(r)
 
 	client := getClient(r)
+	vars := initVars(r)
+	(*vars)["app_id"] = converter.Int64ToStr(page.AppID)
+
+	// the data of OBS is changed without blocks so the pages can't be invalidated
+	useCache := conf.Config.PageCacheSize > 0 && !conf.Config.IsSupportingOBS()
+	var cacheKey string
+	if useCache {
+		cacheKey = pageCacheKey(kind, page, vars)
+		if entry, ok := pagecache.Get(cacheKey); ok {
+			return entry.Value.(*contentResult), entry.Hash, nil
+		}
+	}
+	generation := pagecache.Generation()
+	deps := pagecache.NewDeps()
+	deps.AddRow(page.TableName(), page.ID)
+
 	menu := &model.Menu{}
 	menu.SetTablePrefix(client.Prefix())
-	_, err = menu.Get(page.Menu)
+	found, err := menu.Get(page.Menu)
 	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting page menu")
-		return nil, errServer
+		return nil, ``, errServer
+	}
+	if found {
+		deps.AddRow(menu.TableName(), menu.ID)
+	} else {
+		deps.AddTable(menu.TableName())
 	}
 	var wg sync.WaitGroup
 	var timeout bool
@@ -184,16 +252,15 @@ func getPage(r *http.Request) (result *contentResult, err error) {
 	go func() {
 		defer wg.Done()
 
-		vars := initVars(r)
-		(*vars)["app_id"] = converter.Int64ToStr(page.AppID)
-
-		ret := template.Template2JSON(page.Value, &timeout, vars)
+		ret := render(page.Value, &timeout, vars, deps)
 		if timeout {
 			return
 		}
-		retmenu := template.Template2JSON(menu.Value, &timeout, vars)
-		if timeout {
-			return
+		var retmenu []byte
+		if !strings.HasPrefix(kind, sourceKind) {
+			if retmenu = render(menu.Value, &timeout, vars, deps); timeout {
+				return
+			}
 		}
 		result = &contentResult{
 			Tree:       ret,
@@ -219,50 +286,143 @@ func getPage(r *http.Request) (result *contentResult, err error) {
 
 	if timeout {
 		logger.WithFields(log.Fields{"type": consts.InvalidObject}).Error(page.Name + " is a heavy page")
-		return nil, errHeavyPage
+		return nil, ``, errHeavyPage
 	}
 
-	return result, nil
+	if hash, err = pageHash(r, result); err != nil {
+		return nil, ``, err
+	}
+	if useCache {
+		pagecache.Set(cacheKey, pagecache.Entry{Value: result, Hash: hash}, deps, generation)
+	}
+	return result, hash, nil
+}
+
+// notModified sets ETag of the page and returns true if the client has the same version of the page
+func notModified(w http.ResponseWriter, r *http.Request, hash string) bool {
+	etag := `"` + hash + `"`
+	w.Header().Set("ETag", etag)
+	for _, item := range strings.Split(r.Header.Get("If-None-Match"), `,`) {
+		item = strings.TrimPrefix(strings.TrimSpace(item), `W/`)
+		if item == etag || item == `*` {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
 }
 
 func getPageHandler(w http.ResponseWriter, r *http.Request) {
-	result, err := getPage(r)
+	result, hash, err := getPage(r)
 	if err != nil {
 		errorResponse(w, err)
 		return
 	}
+	if notModified(w, r, hash) {
+		return
+	}
 
 	jsonResponse(w, result)
 }
 
-func getPageHashHandler(w http.ResponseWriter, r *http.Request) {
+// getPageHTMLHandler returns the page rendered to HTML document. The form value classes
+// chooses the mapping of the classes of the templates to CSS classes, the links to other
+// pages lead to this handler so the pages can be browsed without JavaScript
+func getPageHTMLHandler(w http.ResponseWriter, r *http.Request) {
 	logger := getLogger(r)
-	params := mux.Vars(r)
 
-	if ecosystem := r.FormValue("ecosystem"); len(ecosystem) > 0 &&
-		!strings.HasPrefix(params["name"], "@") {
+	classes := template.GetClassMapper(template.ClassesDefault)
+	if name := r.FormValue("classes"); len(name) > 0 {
+		if classes = template.GetClassMapper(name); classes == nil {
+			logger.WithFields(log.Fields{"type": consts.NotFound, "value": name}).Error("class mapping not found")
+			errorResponse(w, errParamNotFound.Errorf(name))
+			return
+		}
+	}
+	params := mux.Vars(r)
+	ecosystem := r.FormValue("ecosystem")
+	if len(ecosystem) > 0 && !strings.HasPrefix(params["name"], "@") {
 		params["name"] = "@" + ecosystem + params["name"]
 	}
-	result, err := getPage(r)
+	options := template.HTMLOptions{
+		Classes: classes,
+		PageLink: func(page string, query url.Values) string {
+			if len(ecosystem) > 0 && !strings.HasPrefix(page, "@") {
+				page = "@" + ecosystem + page
+			}
+			link := "/api/v2/content/html/" + url.PathEscape(page)
+			if len(query) > 0 {
+				link += "?" + query.Encode()
+			}
+			return link
+		},
+	}
+	result, hash, err := renderPage(r, `html`, func(input string, timeout *bool,
+		vars *map[string]string, deps *pagecache.Deps) []byte {
+		htmlOptions := options
+		htmlOptions.Deps = deps
+		return template.Template2HTML(input, timeout, vars, htmlOptions)
+	})
 	if err != nil {
 		errorResponse(w, err)
 		return
 	}
+	if notModified(w, r, hash) {
+		return
+	}
 
-	out, err := json.Marshal(result)
+	title := html.EscapeString(params["name"])
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title></head><body>", title)
+	if len(result.MenuTree) > 0 {
+		fmt.Fprintf(w, "<nav>%s</nav>", result.MenuTree)
+	}
+	fmt.Fprintf(w, "<main>%s</main></body></html>\n", result.Tree)
+}
+
+// getDataSourceHandler returns the node of DBFind or Data which defines the source on the page.
+// The front-end uses it for the buttons with Refresh so only the node of the source is updated.
+// The page is processed only till the node of the source
+func getDataSourceHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	logger := getLogger(r)
+
+	source := params["source"]
+	result, hash, err := renderPage(r, sourceKind+source, func(input string, timeout *bool,
+		vars *map[string]string, deps *pagecache.Deps) []byte {
+		return template.Template2Source(input, timeout, vars, deps, source)
+	})
 	if err != nil {
-		logger.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("getting string for hash")
-		errorResponse(w, errServer)
+		errorResponse(w, err)
 		return
 	}
-	ret, err := crypto.Hash(out)
+	if len(result.Tree) == 0 {
+		logger.WithFields(log.Fields{"type": consts.NotFound, "value": source}).Error("source not found")
+		errorResponse(w, errParamNotFound.Errorf(source))
+		return
+	}
+	if notModified(w, r, hash) {
+		return
+	}
+
+	jsonResponse(w, &contentResult{Tree: result.Tree})
+}
+
+func getPageHashHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	if ecosystem := r.FormValue("ecosystem"); len(ecosystem) > 0 &&
+		!strings.HasPrefix(params["name"], "@") {
+		params["name"] = "@" + ecosystem + params["name"]
+	}
+	_, hash, err := getPage(r)
 	if err != nil {
-		logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("calculating hash of the page")
-		errorResponse(w, errServer)
+		errorResponse(w, err)
 		return
 	}
 
-	jsonResponse(w, &hashResult{Hash: hex.EncodeToString(ret)})
+	jsonResponse(w, &hashResult{Hash: hash})
 }
 
 func getMenuHandler(w http.ResponseWriter, r *http.Request) {
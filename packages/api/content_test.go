@@ -29,9 +29,13 @@
 package api
 
 import (
+	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/AplaProject/go-apla/packages/consts"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -138,3 +142,79 @@ func TestContent(t *testing.T) {
 		assert.Equal(t, v.expected, string(ret.Tree))
 	}
 }
+
+func TestContentHTML(t *testing.T) {
+	assert.NoError(t, keyLogin(1))
+
+	name := randName(`page`)
+	assert.NoError(t, postTx(`NewPage`, &url.Values{
+		"ApplicationId": {`1`},
+		"Name":          {name},
+		"Value":         {`Div(myclass){Span(My <text>)}LinkPage(Body: Next, Page: next)`},
+		"Menu":          {`default_menu`},
+		"Conditions":    {"true"},
+	}))
+
+	data, err := sendRawRequest("POST", "content/html/"+name, &url.Values{"classes": {"bootstrap"}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `<main><div class="myclass"><span>My &lt;text&gt;</span></div>`+
+		`<a href="/api/v2/content/html/next">Next</a></main>`)
+
+	_, err = sendRawRequest("POST", "content/html/"+name, &url.Values{"classes": {"unknown"}})
+	assert.Error(t, err)
+}
+
+func TestContentCache(t *testing.T) {
+	assert.NoError(t, keyLogin(1))
+
+	param := randName(`param`)
+	assert.NoError(t, postTx(`NewParameter`, &url.Values{"Name": {param}, "Value": {`first`},
+		"Conditions": {`true`}}))
+	name := randName(`page`)
+	assert.NoError(t, postTx(`NewPage`, &url.Values{
+		"ApplicationId": {`1`},
+		"Name":          {name},
+		"Value":         {`Span(EcosysParam(` + param + `))`},
+		"Menu":          {`default_menu`},
+		"Conditions":    {"true"},
+	}))
+
+	getPage := func(etag string) (*http.Response, error) {
+		req, err := http.NewRequest("POST", apiAddress+consts.ApiPath+"content/page/"+name,
+			strings.NewReader(``))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", jwtPrefix+gAuth)
+		if len(etag) > 0 {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	var hash hashResult
+	assert.NoError(t, sendPost(`content/hash/`+name, &url.Values{}, &hash))
+	resp, err := getPage(``)
+	assert.NoError(t, err)
+	assert.Equal(t, `"`+hash.Hash+`"`, resp.Header.Get("ETag"))
+
+	resp, err = getPage(resp.Header.Get("ETag"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	var ret paramResult
+	assert.NoError(t, sendGet(`ecosystemparam/`+param, nil, &ret))
+	assert.NoError(t, postTx(`EditParameter`, &url.Values{"Id": {ret.ID}, "Value": {`second`},
+		"Conditions": {`true`}}))
+
+	var page contentResult
+	assert.NoError(t, sendPost(`content/page/`+name, &url.Values{}, &page))
+	assert.Equal(t, `[{"tag":"span","children":[{"tag":"text","text":"second"}]}]`, string(page.Tree))
+	resp, err = getPage(`"` + hash.Hash + `"`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
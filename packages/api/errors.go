@@ -37,6 +37,7 @@ import (
 var (
 	defaultStatus        = http.StatusBadRequest
 	ErrEcosystemNotFound = errors.New("Ecosystem not found")
+	errCursor            = errType{"E_CURSOR", "Cursor is not valid", defaultStatus}
 	errContract          = errType{"E_CONTRACT", "There is not %s contract", http.StatusNotFound}
 	errDBNil             = errType{"E_DBNIL", "DB is nil", defaultStatus}
 	errDeletedKey        = errType{"E_DELETEDKEY", "The key is deleted", http.StatusForbidden}
@@ -51,6 +52,8 @@ var (
 	errInvalidWallet     = errType{"E_INVALIDWALLET", "Wallet %s is not valid", http.StatusBadRequest}
 	errLimitForsign      = errType{"E_LIMITFORSIGN", "Length of forsign is too big (%d)", defaultStatus}
 	errLimitTxSize       = errType{"E_LIMITTXSIZE", "The size of tx is too big (%d)", defaultStatus}
+	errBatchSize         = errType{"E_BATCHSIZE", "The batch has %d transactions, it must have from 1 to %d", defaultStatus}
+	errOrder             = errType{"E_ORDER", "Order is not valid", defaultStatus}
 	errNotFound          = errType{"E_NOTFOUND", "Page not found", http.StatusNotFound}
 	errParamNotFound     = errType{"E_PARAMNOTFOUND", "Parameter %s has not been found", http.StatusNotFound}
 	errPermission        = errType{"E_PERMISSION", "Permission denied", http.StatusUnauthorized}
@@ -63,15 +66,24 @@ var (
 	errTableNotFound     = errType{"E_TABLENOTFOUND", "Table %s has not been found", http.StatusNotFound}
 	errToken             = errType{"E_TOKEN", "Token is not valid", defaultStatus}
 	errTokenExpired      = errType{"E_TOKENEXPIRED", "Token is expired by %s", http.StatusUnauthorized}
+	errTokenRevoked      = errType{"E_TOKENREVOKED", "Token is revoked", http.StatusUnauthorized}
 	errUnauthorized      = errType{"E_UNAUTHORIZED", "Unauthorized", http.StatusUnauthorized}
 	errUndefineval       = errType{"E_UNDEFINEVAL", "Value %s is undefined", defaultStatus}
+	errWhere             = errType{"E_WHERE", "Where condition is not valid", defaultStatus}
 	errUnknownUID        = errType{"E_UNKNOWNUID", "Unknown uid", defaultStatus}
 	errOBS               = errType{"E_OBS", "Virtual Dedicated Ecosystem %d doesn't exist", defaultStatus}
 	errOBSCreated        = errType{"E_OBSCREATED", "Virtual Dedicated Ecosystem is already created", http.StatusBadRequest}
 	errRequestNotFound   = errType{"E_REQUESTNOTFOUND", "Request %s doesn't exist", defaultStatus}
 	errUpdating          = errType{"E_UPDATING", "Node is updating blockchain", http.StatusServiceUnavailable}
 	errStopping          = errType{"E_STOPPING", "Network is stopping", http.StatusServiceUnavailable}
+	errStreaming         = errType{"E_STREAMING", "Streaming is not supported", http.StatusInternalServerError}
 	errNotImplemented    = errType{"E_NOTIMPLEMENTED", "Not implemented", http.StatusNotImplemented}
+	errNotContractTx     = errType{"E_NOTCONTRACTTX", "Transaction is not a contract call", http.StatusBadRequest}
+	errLocalOnly         = errType{"E_LOCALONLY", "The request is allowed only from the local host", http.StatusForbidden}
+	errDebuggerOff       = errType{"E_DEBUGGEROFF", "The debugger is turned off", http.StatusForbidden}
+	errReplayTooFar      = errType{"E_REPLAYTOOFAR", "The replay can't rewind more than %d blocks", http.StatusBadRequest}
+	errDebugSession      = errType{"E_DEBUGSESSION", "Debug session %s doesn't exist", http.StatusNotFound}
+	errBreakpoint        = errType{"E_BREAKPOINT", "Breakpoint %s is not valid", http.StatusBadRequest}
 	errDiffKey           = errType{"E_DIFKEY", "Sender's key is different from tx key", defaultStatus}
 	errBannded           = errType{"E_BANNED", "The key is banned till %s", http.StatusForbidden}
 	errCheckRole         = errType{"E_CHECKROLE", "Access denied", http.StatusForbidden}
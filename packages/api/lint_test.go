@@ -0,0 +1,61 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	require.NoError(t, keyLogin(1))
+
+	var ret lintResult
+	require.NoError(t, sendPost(`lint`, &url.Values{"code": {`contract ` + randName(`lint`) + ` {
+		conditions {
+			DBUpdate("keys", 1, {amount: 1})
+		}
+		action {
+			var a int
+			$x()
+		}
+	}`}}, &ret))
+	assert.Equal(t, 2, ret.Errors)
+	assert.Len(t, ret.Messages, 3)
+
+	require.NoError(t, sendPost(`lint`, &url.Values{"code": {`contract ` + randName(`lint`) + ` {
+		action {
+			$result = Sprintf("%d", 10)
+		}
+	}`}}, &ret))
+	assert.Equal(t, 0, ret.Errors)
+	assert.Empty(t, ret.Messages)
+}
@@ -29,12 +29,20 @@
 package api
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
 	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
 	"github.com/AplaProject/go-apla/packages/model"
 	"github.com/AplaProject/go-apla/packages/smart"
+	qb "github.com/AplaProject/go-apla/packages/smart/queryBuilder"
+	"github.com/AplaProject/go-apla/packages/types"
 	"github.com/AplaProject/go-apla/packages/utils/tx"
 
 	"github.com/gorilla/mux"
@@ -42,13 +50,18 @@ import (
 )
 
 type listResult struct {
-	Count string              `json:"count"`
-	List  []map[string]string `json:"list"`
+	Count  string              `json:"count"`
+	List   []map[string]string `json:"list"`
+	Cursor string              `json:"cursor,omitempty"`
 }
 
 type listForm struct {
 	paginatorForm
 	rowForm
+
+	Where  string `schema:"where"`
+	Order  string `schema:"order"`
+	Cursor string `schema:"cursor"`
 }
 
 func (f *listForm) Validate(r *http.Request) error {
@@ -58,8 +71,19 @@ func (f *listForm) Validate(r *http.Request) error {
 	return f.rowForm.Validate(r)
 }
 
-func checkAccess(tableName, columns string, client *Client) (table string, cols string, err error) {
-	sc := smart.SmartContract{
+// orderColumn is the column of sorting the list
+type orderColumn struct {
+	Name string
+	Desc bool
+}
+
+// listCursor points to the last row of the page, it contains values of the order columns of the row
+type listCursor struct {
+	Values []string `json:"v"`
+}
+
+func clientContract(client *Client) *smart.SmartContract {
+	return &smart.SmartContract{
 		OBS: conf.Config.IsSupportingOBS(),
 		VM:  smart.GetVM(),
 		TxSmart: tx.SmartContract{
@@ -70,10 +94,179 @@ func checkAccess(tableName, columns string, client *Client) (table string, cols
 			},
 		},
 	}
-	table, _, cols, err = sc.CheckAccess(tableName, columns, client.EcosystemID)
+}
+
+func checkAccess(tableName, columns string, client *Client) (table string, cols string, err error) {
+	table, _, cols, err = clientContract(client).CheckAccess(tableName, columns, client.EcosystemID)
 	return
 }
 
+// checkColumnsAccess returns the error if the client can't read any of the columns
+func checkColumnsAccess(table string, columns []string, client *Client) error {
+	if len(columns) == 0 || !syspar.IsPrivateBlockchain() {
+		return nil
+	}
+	checked := append([]string{}, columns...)
+	if err := clientContract(client).AccessColumns(table, &checked, false); err != nil {
+		return err
+	}
+	if len(checked) != len(columns) {
+		return errPermission
+	}
+	return nil
+}
+
+// parseListWhere converts json condition to sql and returns the list of used columns
+func parseListWhere(where string) (string, []string, error) {
+	if len(where) == 0 {
+		return ``, nil, nil
+	}
+	data, err := smart.JSONDecode(where)
+	if err != nil {
+		return ``, nil, errWhere
+	}
+	inWhere, ok := data.(*types.Map)
+	if !ok {
+		return ``, nil, errWhere
+	}
+	sql, err := qb.GetWhere(inWhere)
+	if err != nil {
+		return ``, nil, errWhere
+	}
+
+	columns := make(map[string]bool)
+	whereColumns(inWhere, columns)
+	list := make([]string, 0, len(columns))
+	for col := range columns {
+		list = append(list, col)
+	}
+	return sql, list, nil
+}
+
+func whereColumns(where *types.Map, columns map[string]bool) {
+	for _, key := range where.Keys() {
+		value, _ := where.Get(key)
+		if strings.HasPrefix(key, `$`) {
+			if list, ok := value.([]interface{}); ok {
+				for _, item := range list {
+					if cond, ok := item.(*types.Map); ok {
+						whereColumns(cond, columns)
+					}
+				}
+			}
+			continue
+		}
+		name := converter.Sanitize(strings.ToLower(key), `->`)
+		if i := strings.Index(name, `->`); i >= 0 {
+			name = name[:i]
+		}
+		if len(name) > 0 {
+			columns[name] = true
+		}
+	}
+}
+
+// parseListOrder parses the column name or json list of {"column": 1|-1},
+// id is always added as the last column so the order is unique
+func parseListOrder(order string) ([]orderColumn, error) {
+	var (
+		list   []orderColumn
+		withID bool
+	)
+	add := func(name string, value interface{}) {
+		name = converter.Sanitize(strings.ToLower(name), ``)
+		if len(name) == 0 || (withID && name == `id`) {
+			return
+		}
+		withID = withID || name == `id`
+		list = append(list, orderColumn{Name: name, Desc: fmt.Sprint(value) == `-1`})
+	}
+
+	if len(order) > 0 {
+		if strings.HasPrefix(order, `[`) || strings.HasPrefix(order, `{`) {
+			data, err := smart.JSONDecode(order)
+			if err != nil {
+				return nil, errOrder
+			}
+			items, ok := data.([]interface{})
+			if !ok {
+				items = []interface{}{data}
+			}
+			for _, item := range items {
+				switch v := item.(type) {
+				case string:
+					add(v, nil)
+				case *types.Map:
+					for _, key := range v.Keys() {
+						value, _ := v.Get(key)
+						add(key, value)
+					}
+				default:
+					return nil, errOrder
+				}
+			}
+		} else {
+			for _, name := range strings.Split(order, `,`) {
+				add(name, nil)
+			}
+		}
+	}
+	if !withID {
+		list = append(list, orderColumn{Name: `id`})
+	}
+	return list, nil
+}
+
+func orderSQL(order []orderColumn) string {
+	list := make([]string, len(order))
+	for i, col := range order {
+		list[i] = `"` + col.Name + `"`
+		if col.Desc {
+			list[i] += ` desc`
+		}
+	}
+	return strings.Join(list, `,`)
+}
+
+func encodeCursor(order []orderColumn, row map[string]string) string {
+	cursor := listCursor{Values: make([]string, len(order))}
+	for i, col := range order {
+		cursor.Values[i] = row[col.Name]
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// cursorWhere returns the condition of rows following the cursor in the order
+func cursorWhere(order []orderColumn, cursor string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ``, errCursor
+	}
+	var c listCursor
+	if err = json.Unmarshal(data, &c); err != nil || len(c.Values) != len(order) {
+		return ``, errCursor
+	}
+
+	escape := func(value string) string {
+		return `'` + strings.Replace(value, `'`, `''`, -1) + `'`
+	}
+	or := make([]string, len(order))
+	for i, col := range order {
+		and := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, fmt.Sprintf(`"%s" = %s`, order[j].Name, escape(c.Values[j])))
+		}
+		oper := `>`
+		if col.Desc {
+			oper = `<`
+		}
+		and = append(and, fmt.Sprintf(`"%s" %s %s`, col.Name, oper, escape(c.Values[i])))
+		or[i] = `(` + strings.Join(and, ` and `) + `)`
+	}
+	return `(` + strings.Join(or, ` or `) + `)`, nil
+}
+
 func getListHandler(w http.ResponseWriter, r *http.Request) {
 	form := &listForm{}
 	if err := parseForm(r, form); err != nil {
@@ -94,10 +287,37 @@ func getListHandler(w http.ResponseWriter, r *http.Request) {
 		errorResponse(w, err)
 		return
 	}
+
+	where, whereCols, err := parseListWhere(form.Where)
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+	order, err := parseListOrder(form.Order)
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+	usedCols := whereCols
+	for _, col := range order {
+		usedCols = append(usedCols, col.Name)
+	}
+	if err = checkColumnsAccess(table, usedCols, client); err != nil {
+		errorResponse(w, err)
+		return
+	}
+
 	q := model.GetTableQuery(params["name"], client.EcosystemID)
 
 	if len(form.Columns) > 0 {
-		q = q.Select("id," + form.Columns)
+		orderCols := make([]string, len(order))
+		for i, col := range order {
+			orderCols[i] = col.Name
+		}
+		q = q.Select("id," + form.Columns + "," + smart.PrepareColumns(orderCols))
+	}
+	if len(where) > 0 {
+		q = q.Where(where)
 	}
 
 	result := new(listResult)
@@ -108,7 +328,17 @@ func getListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := q.Order("id ASC").Offset(form.Offset).Limit(form.Limit).Rows()
+	if len(form.Cursor) > 0 {
+		cond, err := cursorWhere(order, form.Cursor)
+		if err != nil {
+			errorResponse(w, err)
+			return
+		}
+		q = q.Where(cond)
+		form.Offset = 0
+	}
+
+	rows, err := q.Order(orderSQL(order)).Offset(form.Offset).Limit(form.Limit).Rows()
 	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("Getting rows from table")
 		errorResponse(w, err)
@@ -120,6 +350,9 @@ func getListHandler(w http.ResponseWriter, r *http.Request) {
 		errorResponse(w, err)
 		return
 	}
+	if int64(len(result.List)) == form.Limit {
+		result.Cursor = encodeCursor(order, result.List[len(result.List)-1])
+	}
 
 	jsonResponse(w, result)
 }
@@ -30,9 +30,13 @@ package api
 
 import (
 	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/AplaProject/go-apla/packages/converter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestList(t *testing.T) {
@@ -78,3 +82,41 @@ func TestList(t *testing.T) {
 		return
 	}
 }
+
+func TestListWhereOrder(t *testing.T) {
+	where, cols, err := parseListWhere(`{"name": {"$begin": "a"}, "$or": [{"amount": {"$gt": 10}}, {"data->type": "x"}]}`)
+	require.NoError(t, err)
+	assert.NotEmpty(t, where)
+	sort.Strings(cols)
+	assert.Equal(t, []string{"amount", "data", "name"}, cols)
+
+	_, _, err = parseListWhere(`[1,2]`)
+	assert.Equal(t, errWhere, err)
+
+	order, err := parseListOrder(`[{"name": 1}, {"amount": -1}]`)
+	require.NoError(t, err)
+	assert.Equal(t, []orderColumn{{Name: "name"}, {Name: "amount", Desc: true}, {Name: "id"}}, order)
+	assert.Equal(t, `"name","amount" desc,"id"`, orderSQL(order))
+
+	order, err = parseListOrder(`id`)
+	require.NoError(t, err)
+	assert.Equal(t, []orderColumn{{Name: "id"}}, order)
+
+	order, err = parseListOrder(`{"id": -1}`)
+	require.NoError(t, err)
+	assert.Equal(t, []orderColumn{{Name: "id", Desc: true}}, order)
+}
+
+func TestListCursor(t *testing.T) {
+	order := []orderColumn{{Name: "name"}, {Name: "id", Desc: true}}
+	cursor := encodeCursor(order, map[string]string{"id": "5", "name": "it's"})
+
+	where, err := cursorWhere(order, cursor)
+	require.NoError(t, err)
+	assert.Equal(t, `(("name" > 'it''s') or ("name" = 'it''s' and "id" < '5'))`, where)
+
+	_, err = cursorWhere(order[:1], cursor)
+	assert.Equal(t, errCursor, err)
+	_, err = cursorWhere(order, `!!!`)
+	assert.Equal(t, errCursor, err)
+}
@@ -46,7 +46,6 @@ import (
 	"github.com/AplaProject/go-apla/packages/utils"
 	"github.com/AplaProject/go-apla/packages/utils/tx"
 
-	jwt "github.com/dgrijalva/jwt-go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -83,6 +82,7 @@ func (f *loginForm) Validate(r *http.Request) error {
 
 type loginResult struct {
 	Token       string        `json:"token,omitempty"`
+	Refresh     string        `json:"refresh,omitempty"`
 	EcosystemID string        `json:"ecosystem_id,omitempty"`
 	KeyID       string        `json:"key_id,omitempty"`
 	Address     string        `json:"address,omitempty"`
@@ -285,18 +285,9 @@ func (m Mode) loginHandler(w http.ResponseWriter, r *http.Request) {
 		EcosystemID: result.EcosystemID,
 		IsMobile:    form.IsMobile,
 		RoleID:      converter.Int64ToStr(form.RoleID),
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Second * time.Duration(form.Expire)).Unix(),
-		},
 	}
 
-	result.Token, err = generateJWTToken(claims)
-	if err != nil {
-		logger.WithFields(log.Fields{"type": consts.JWTError, "error": err}).Error("generating jwt token")
-		errorResponse(w, err)
-		return
-	}
-	claims.StandardClaims.ExpiresAt = time.Now().Add(time.Hour * 30 * 24).Unix()
+	result.Token, result.Refresh, err = generateSessionTokens(claims, form.Expire)
 	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.JWTError, "error": err}).Error("generating jwt token")
 		errorResponse(w, err)
@@ -0,0 +1,133 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type mempoolStats struct {
+	Count       int64  `json:"count"`
+	Size        int64  `json:"size"`
+	Keys        int64  `json:"keys"`
+	Batches     int64  `json:"batches"`
+	MinPriority string `json:"min_priority"`
+	MaxPriority string `json:"max_priority"`
+	MaxSize     int    `json:"max_size"`
+	MaxPerKey   int    `json:"max_per_key"`
+}
+
+type mempoolItem struct {
+	Hash     string `json:"hash"`
+	KeyID    string `json:"key_id"`
+	Time     int64  `json:"time"`
+	Type     int8   `json:"type"`
+	Priority string `json:"priority"`
+	MaxSum   int64  `json:"max_sum"`
+	HighRate bool   `json:"high_rate,omitempty"`
+	Batch    string `json:"batch,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+type mempoolResult struct {
+	Stats mempoolStats  `json:"stats"`
+	List  []mempoolItem `json:"list"`
+}
+
+type mempoolForm struct {
+	paginatorForm
+}
+
+func (f *mempoolForm) Validate(r *http.Request) error {
+	return f.paginatorForm.Validate(r)
+}
+
+// mempoolHandler returns the summary of the pending transactions and the list of them in the order of block selection
+func mempoolHandler(w http.ResponseWriter, r *http.Request) {
+	form := &mempoolForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	logger := getLogger(r)
+
+	stats, err := model.GetMempoolStats()
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting mempool stats")
+		errorResponse(w, err)
+		return
+	}
+
+	txs, err := model.GetAllUnusedTransactions(int(form.Limit))
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting all unused transactions")
+		errorResponse(w, err)
+		return
+	}
+
+	result := &mempoolResult{
+		Stats: mempoolStats{
+			Count:       stats.Count,
+			Size:        stats.Size,
+			Keys:        stats.Keys,
+			Batches:     stats.Batches,
+			MinPriority: stats.MinPriority.String(),
+			MaxPriority: stats.MaxPriority.String(),
+			MaxSize:     conf.Config.Mempool.MaxSize,
+			MaxPerKey:   conf.Config.Mempool.MaxPerKey,
+		},
+		List: make([]mempoolItem, 0, len(txs)),
+	}
+	for _, tx := range txs {
+		item := mempoolItem{
+			Hash:     hex.EncodeToString(tx.Hash),
+			KeyID:    converter.Int64ToStr(tx.KeyID),
+			Time:     tx.Time,
+			Type:     tx.Type,
+			Priority: tx.Priority.String(),
+			MaxSum:   tx.MaxSum,
+			HighRate: tx.HighRate > 0,
+			Verified: tx.Verified == 1,
+		}
+		if len(tx.Batch) > 0 {
+			item.Batch = hex.EncodeToString(tx.Batch)
+		}
+		result.List = append(result.List, item)
+	}
+
+	jsonResponse(w, result)
+}
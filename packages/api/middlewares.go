@@ -30,10 +30,12 @@ package api
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"runtime/debug"
 	"time"
 
+	"github.com/AplaProject/go-apla/packages/conf"
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/service"
 	"github.com/AplaProject/go-apla/packages/statsd"
@@ -57,6 +59,30 @@ func authRequire(next func(w http.ResponseWriter, r *http.Request)) func(w http.
 	}
 }
 
+// localRequire allows the requests only from the loopback addresses if the debugger is turned on
+// in the config. The address of the connection is checked, so all requests forwarded by the reverse
+// proxy on the same host are treated as local ones and the debugger must not be turned on in this case
+func localRequire(next func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !conf.Config.Debugger {
+			errorResponse(w, errDebuggerOff)
+			return
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			next(w, r)
+			return
+		}
+
+		logger := getLogger(r)
+		logger.WithFields(log.Fields{"type": consts.AccessDenied, "remote": r.RemoteAddr}).Error("request from the remote host")
+		errorResponse(w, errLocalOnly)
+	}
+}
+
 func loggerFromRequest(r *http.Request) *log.Entry {
 	return log.WithFields(log.Fields{
 		"headers":  r.Header,
@@ -128,6 +154,16 @@ func tokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 		if token != nil && token.Valid {
+			if claims, ok := token.Claims.(*JWTClaims); ok {
+				if claims.IsRefresh() {
+					errorResponse(w, errToken)
+					return
+				}
+				if claims.IsRevoked() {
+					errorResponse(w, errTokenRevoked)
+					return
+				}
+			}
 			r = setToken(r, token)
 		}
 		next.ServeHTTP(w, r)
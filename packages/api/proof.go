@@ -0,0 +1,131 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/AplaProject/go-apla/packages/block"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/proof"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// newProofHeader returns the header of the block with the hash blockHash for the proof
+func newProofHeader(header, prev *utils.BlockData, mrklRoot, blockHash []byte) proof.Header {
+	return proof.Header{
+		BlockID:           header.BlockID,
+		Time:              header.Time,
+		EcosystemID:       header.EcosystemID,
+		KeyID:             header.KeyID,
+		NodePosition:      header.NodePosition,
+		Version:           header.Version,
+		MrklRoot:          string(mrklRoot),
+		StateRoot:         hex.EncodeToString(header.StateRoot),
+		Commit:            hex.EncodeToString(header.Commit),
+		SnapshotHash:      hex.EncodeToString(header.SnapshotHash),
+		Sign:              hex.EncodeToString(header.Sign),
+		Hash:              hex.EncodeToString(blockHash),
+		PrevHash:          hex.EncodeToString(prev.Hash),
+		PrevRollbacksHash: hex.EncodeToString(prev.RollbacksHash),
+	}
+}
+
+func getTxProofHandler(w http.ResponseWriter, r *http.Request) {
+	logger := getLogger(r)
+
+	hash, err := hex.DecodeString(mux.Vars(r)["hash"])
+	if err != nil {
+		errorResponse(w, errHashWrong)
+		return
+	}
+	ltx := &model.LogTransaction{}
+	found, err := ltx.GetByHash(hash)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting log transaction by hash")
+		errorResponse(w, err)
+		return
+	}
+	if !found {
+		errorResponse(w, errHashNotFound)
+		return
+	}
+
+	blockModel := &model.Block{}
+	if found, err = blockModel.Get(ltx.Block); err != nil || !found {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": ltx.Block}).Error("getting block of transaction")
+		errorResponse(w, errNotFound)
+		return
+	}
+	blck, err := block.UnmarshallBlock(bytes.NewBuffer(blockModel.Data), blockModel.ID == 1, false)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.UnmarshallingError, "error": err, "block_id": blockModel.ID}).Error("on unmarshalling block")
+		errorResponse(w, err)
+		return
+	}
+
+	prev := &utils.BlockData{}
+	if blockModel.ID > 1 {
+		prevModel := &model.Block{}
+		if found, err = prevModel.Get(blockModel.ID - 1); err != nil || !found {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": blockModel.ID - 1}).Error("getting previous block")
+			errorResponse(w, errNotFound)
+			return
+		}
+		prev.Hash = prevModel.Hash
+		prev.RollbacksHash = prevModel.RollbacksHash
+	}
+
+	index := -1
+	txs := make([][]byte, 0, len(blck.Transactions))
+	for i, tx := range blck.Transactions {
+		if bytes.Equal(tx.TxHash, hash) {
+			index = i
+		}
+		txs = append(txs, tx.TxFullData)
+	}
+	if index < 0 {
+		errorResponse(w, errHashNotFound)
+		return
+	}
+
+	result, err := proof.NewTxProof(newProofHeader(&blck.Header, prev, blck.MrklRoot, blockModel.Hash), txs, index)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("building transaction proof")
+		errorResponse(w, err)
+		return
+	}
+
+	jsonResponse(w, result)
+}
@@ -68,6 +68,7 @@ func (m Mode) SetCommonRoutes(r Router) {
 
 	api.HandleFunc("/contract/{name}", authRequire(getContractInfoHandler)).Methods("GET")
 	api.HandleFunc("/contracts", authRequire(getContractsHandler)).Methods("GET")
+	api.HandleFunc("/lint", authRequire(lintHandler)).Methods("POST")
 	api.HandleFunc("/getuid", getUIDHandler).Methods("GET")
 	api.HandleFunc("/keyinfo/{wallet}", m.getKeyInfoHandler).Methods("GET")
 	api.HandleFunc("/list/{name}", authRequire(getListHandler)).Methods("GET")
@@ -85,11 +86,16 @@ func (m Mode) SetCommonRoutes(r Router) {
 	api.HandleFunc("/page/validators_count/{name}", getPageValidatorsCountHandler).Methods("GET")
 	api.HandleFunc("/content/source/{name}", authRequire(getSourceHandler)).Methods("POST")
 	api.HandleFunc("/content/page/{name}", authRequire(getPageHandler)).Methods("POST")
+	api.HandleFunc("/content/html/{name}", getPageHTMLHandler).Methods("GET", "POST")
 	api.HandleFunc("/content/hash/{name}", getPageHashHandler).Methods("POST")
+	api.HandleFunc("/content/data/{name}/{source}", authRequire(getDataSourceHandler)).Methods("POST")
 	api.HandleFunc("/content/menu/{name}", authRequire(getMenuHandler)).Methods("POST")
 	api.HandleFunc("/content", jsonContentHandler).Methods("POST")
 	api.HandleFunc("/login", m.loginHandler).Methods("POST")
+	api.HandleFunc("/refresh", refreshHandler).Methods("POST")
+	api.HandleFunc("/logout", authRequire(logoutHandler)).Methods("POST")
 	api.HandleFunc("/sendTx", authRequire(m.sendTxHandler)).Methods("POST")
+	api.HandleFunc("/sendTxBatch", authRequire(m.sendTxBatchHandler)).Methods("POST")
 	api.HandleFunc("/updnotificator", updateNotificatorHandler).Methods("POST")
 	api.HandleFunc("/node/{name}", nodeContractHandler).Methods("POST")
 	api.HandleFunc("/txstatus", authRequire(getTxStatusHandler)).Methods("POST")
@@ -97,13 +103,16 @@ func (m Mode) SetCommonRoutes(r Router) {
 	api.HandleFunc("/metrics/transactions", txCountHandler).Methods("GET")
 	api.HandleFunc("/metrics/ecosystems", m.ecosysCountHandler).Methods("GET")
 	api.HandleFunc("/metrics/keys", keysCountHandler).Methods("GET")
+	api.HandleFunc("/mempool", mempoolHandler).Methods("GET")
 }
 
 func (m Mode) SetBlockchainRoutes(r Router) {
 	api := r.GetAPIVersion("/api/v2")
 	api.HandleFunc("/metrics/fullnodes", fullNodesCountHandler).Methods("GET")
+	api.HandleFunc("/validators", validatorsHandler).Methods("GET")
 	api.HandleFunc("/txinfo/{hash}", authRequire(getTxInfoHandler)).Methods("GET")
 	api.HandleFunc("/txinfomultiple", authRequire(getTxInfoMultiHandler)).Methods("GET")
+	api.HandleFunc("/proof/tx/{hash}", getTxProofHandler).Methods("GET")
 	api.HandleFunc("/appparam/{appID}/{name}", authRequire(m.GetAppParamHandler)).Methods("GET")
 	api.HandleFunc("/appparams/{appID}", authRequire(m.getAppParamsHandler)).Methods("GET")
 	api.HandleFunc("/appcontent/{appID}", authRequire(m.getAppContentHandler)).Methods("GET")
@@ -113,6 +122,11 @@ func (m Mode) SetBlockchainRoutes(r Router) {
 	api.HandleFunc("/maxblockid", getMaxBlockHandler).Methods("GET")
 	api.HandleFunc("/blocks", getBlocksTxInfoHandler).Methods("GET")
 	api.HandleFunc("/detailed_blocks", getBlocksDetailedInfoHandler).Methods("GET")
+	api.HandleFunc("/stream", authRequire(streamHandler)).Methods("GET")
+	api.HandleFunc("/simulate", authRequire(simulateHandler)).Methods("POST")
+	api.HandleFunc("/trace/{hash}", localRequire(authRequire(traceHandler))).Methods("GET")
+	api.HandleFunc("/debug", localRequire(authRequire(debugHandler))).Methods("POST")
+	api.HandleFunc("/debug/{session}/{command}", localRequire(authRequire(debugCommandHandler))).Methods("POST")
 	api.HandleFunc("/ecosystemparams", authRequire(m.getEcosystemParamsHandler)).Methods("GET")
 	api.HandleFunc("/systemparams", authRequire(getSystemParamsHandler)).Methods("GET")
 	api.HandleFunc("/ecosystems", authRequire(getEcosystemsHandler)).Methods("GET")
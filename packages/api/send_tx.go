@@ -32,6 +32,8 @@ import (
 	"encoding/hex"
 	"io/ioutil"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/AplaProject/go-apla/packages/block"
 	"github.com/AplaProject/go-apla/packages/conf/syspar"
@@ -42,6 +44,7 @@ import (
 
 type sendTxResult struct {
 	Hashes map[string]string `json:"hashes"`
+	Batch  string            `json:"batch,omitempty"`
 }
 
 func getTxData(r *http.Request, key string) ([]byte, error) {
@@ -111,6 +114,103 @@ func (m Mode) sendTxHandler(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, result)
 }
 
+// sendTxBatchHandler queues the sent transactions as one batch. The transactions are ordered
+// by their form keys and either all of them are queued or none. The node keeps the transactions
+// of the batch together in one block
+func (m Mode) sendTxBatchHandler(w http.ResponseWriter, r *http.Request) {
+	client := getClient(r)
+	logger := getLogger(r)
+
+	if block.IsKeyBanned(client.KeyID) {
+		errorResponse(w, errBannded.Errorf(block.BannedTill(client.KeyID)))
+		return
+	}
+
+	err := r.ParseMultipartForm(multipartBuf)
+	if err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	txs := make(map[string][]byte)
+	for key := range r.MultipartForm.File {
+		if txs[key], err = getTxData(r, key); err != nil {
+			errorResponse(w, err)
+			return
+		}
+	}
+	for key := range r.Form {
+		if txs[key], err = hex.DecodeString(r.FormValue(key)); err != nil {
+			errorResponse(w, err)
+			return
+		}
+	}
+
+	maxCount := syspar.GetMaxTxCount()
+	if maxUser := syspar.GetMaxBlockUserTx(); maxUser > 0 && maxUser < maxCount {
+		maxCount = maxUser
+	}
+	if len(txs) == 0 || len(txs) > maxCount {
+		errorResponse(w, errBatchSize.Errorf(len(txs), maxCount))
+		return
+	}
+
+	keys := make([]string, 0, len(txs))
+	for key := range txs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return batchKeyLess(keys[i], keys[j]) })
+
+	batchData := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		txData := txs[key]
+		if int64(len(txData)) > syspar.GetMaxTxSize() {
+			logger.WithFields(log.Fields{"type": consts.ParameterExceeded, "max_size": syspar.GetMaxTxSize(), "size": len(txData)}).Error("transaction size exceeds max size")
+			block.BadTxForBan(client.KeyID)
+			errorResponse(w, errLimitTxSize.Errorf(len(txData)))
+			return
+		}
+		batchData = append(batchData, txData)
+	}
+
+	hashes, batch, err := m.ClientTxProcessor.ProcessClientTxBatch(batchData, client.KeyID, logger)
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+
+	result := &sendTxResult{Hashes: make(map[string]string), Batch: batch}
+	for i, key := range keys {
+		result.Hashes[key] = hashes[i]
+	}
+
+	jsonResponse(w, result)
+}
+
+// batchKeyLess orders the form keys of the batch by their numeric index, so tx2 precedes tx10.
+// The keys with different prefixes or without the index are ordered as strings
+func batchKeyLess(a, b string) bool {
+	split := func(key string) (string, string) {
+		i := len(key)
+		for i > 0 && key[i-1] >= '0' && key[i-1] <= '9' {
+			i--
+		}
+		return key[:i], strings.TrimLeft(key[i:], "0")
+	}
+	prefixA, indexA := split(a)
+	prefixB, indexB := split(b)
+	if prefixA != prefixB || a == prefixA || b == prefixB {
+		return a < b
+	}
+	if len(indexA) != len(indexB) {
+		return len(indexA) < len(indexB)
+	}
+	if indexA != indexB {
+		return indexA < indexB
+	}
+	return a < b
+}
+
 type contractResult struct {
 	Hash string `json:"hash"`
 	// These fields are used for OBS
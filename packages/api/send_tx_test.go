@@ -0,0 +1,112 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newContractTx(name string, params map[string]interface{}) ([]byte, error) {
+	var contract getContractResult
+	if err := sendGet("contract/"+name, nil, &contract); err != nil {
+		return nil, err
+	}
+
+	privateKey, err := hex.DecodeString(gPrivate)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := crypto.PrivateToPublic(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _, err := tx.NewTransaction(tx.SmartContract{
+		Header: tx.Header{
+			ID:          int(contract.ID),
+			Time:        time.Now().Unix(),
+			EcosystemID: 1,
+			KeyID:       crypto.Address(publicKey),
+			NetworkID:   consts.NETWORK_ID,
+		},
+		Params: params,
+	}, privateKey)
+	return data, err
+}
+
+func TestSendTxBatch(t *testing.T) {
+	require.NoError(t, keyLogin(1))
+
+	name := randName(`batch`)
+	txs := make(map[string][]byte)
+	for _, key := range []string{"tx1", "tx2"} {
+		data, err := newContractTx(`NewParameter`, map[string]interface{}{
+			"Name":       name + key,
+			"Value":      key,
+			"Conditions": `ContractConditions("MainCondition")`,
+		})
+		require.NoError(t, err)
+		txs[key] = data
+	}
+
+	var ret sendTxResult
+	require.NoError(t, sendMultipart("sendTxBatch", txs, &ret))
+	assert.NotEmpty(t, ret.Batch)
+	require.Len(t, ret.Hashes, 2)
+
+	blockID1, err := waitTx(ret.Hashes["tx1"])
+	require.NotZero(t, blockID1, err)
+	blockID2, err := waitTx(ret.Hashes["tx2"])
+	require.NotZero(t, blockID2, err)
+	assert.Equal(t, blockID1, blockID2)
+
+	// the batch with the duplicated transaction is rejected entirely
+	data, err := newContractTx(`NewParameter`, map[string]interface{}{
+		"Name":       name + "dup",
+		"Value":      "dup",
+		"Conditions": `ContractConditions("MainCondition")`,
+	})
+	require.NoError(t, err)
+	assert.Error(t, sendMultipart("sendTxBatch", map[string][]byte{"tx1": data, "tx2": data}, &ret))
+}
+
+func TestBatchKeyOrder(t *testing.T) {
+	keys := []string{"tx10", "tx2", "call", "tx1", "tx002", "a3"}
+	sort.Slice(keys, func(i, j int) bool { return batchKeyLess(keys[i], keys[j]) })
+	assert.Equal(t, []string{"a3", "call", "tx1", "tx002", "tx2", "tx10"}, keys)
+}
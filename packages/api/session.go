@@ -0,0 +1,158 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/block"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/service"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	log "github.com/sirupsen/logrus"
+)
+
+type refreshForm struct {
+	Token  string `schema:"token"`
+	Expire int64  `schema:"expire"`
+}
+
+func (f *refreshForm) Validate(r *http.Request) error {
+	if len(f.Token) == 0 {
+		return errToken
+	}
+	if f.Expire == 0 {
+		f.Expire = int64(jwtExpire)
+	}
+	return nil
+}
+
+type refreshResult struct {
+	Token   string `json:"token"`
+	Refresh string `json:"refresh"`
+}
+
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+	form := &refreshForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	logger := getLogger(r)
+
+	token, err := jwt.ParseWithClaims(form.Token, &JWTClaims{}, jwtKeys.keyFunc)
+	if err != nil || !token.Valid {
+		logger.WithFields(log.Fields{"type": consts.JWTError, "error": err}).Error("parsing refresh token")
+		errorResponse(w, errToken)
+		return
+	}
+
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !claims.IsRefresh() || len(claims.Id) == 0 {
+		logger.WithFields(log.Fields{"type": consts.JWTError}).Error("token is not refresh token")
+		errorResponse(w, errToken)
+		return
+	}
+	if claims.IsRevoked() {
+		logger.WithFields(log.Fields{"type": consts.JWTError, "key_id": claims.KeyID}).Warning("refreshing revoked session")
+		errorResponse(w, errTokenRevoked)
+		return
+	}
+
+	keyID := converter.StrToInt64(claims.KeyID)
+	if block.IsKeyBanned(keyID) {
+		errorResponse(w, errBannded.Errorf(block.BannedTill(keyID)))
+		return
+	}
+
+	// the refresh token can be used only once, the concurrent requests with the same token
+	// are rejected except the one which has revoked it
+	consumed, err := service.GetSessionRevocationService().ConsumeSession(claims.Id, keyID, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+	if !consumed {
+		logger.WithFields(log.Fields{"type": consts.JWTError, "key_id": claims.KeyID}).Warning("refresh token has already been used")
+		errorResponse(w, errTokenRevoked)
+		return
+	}
+
+	result := &refreshResult{}
+	result.Token, result.Refresh, err = generateSessionTokens(JWTClaims{
+		KeyID:       claims.KeyID,
+		EcosystemID: claims.EcosystemID,
+		IsMobile:    claims.IsMobile,
+		RoleID:      claims.RoleID,
+	}, form.Expire)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.JWTError, "error": err}).Error("generating jwt token")
+		errorResponse(w, err)
+		return
+	}
+
+	jsonResponse(w, result)
+}
+
+type logoutForm struct {
+	nopeValidator
+	All bool `schema:"all"`
+}
+
+type logoutResult struct {
+	Result bool `json:"result"`
+}
+
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	form := &logoutForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	client := getClient(r)
+	revocation := service.GetSessionRevocationService()
+
+	var err error
+	if form.All {
+		err = revocation.RevokeKey(client.KeyID)
+	} else if claims, ok := getToken(r).Claims.(*JWTClaims); ok && len(claims.Id) > 0 {
+		err = revocation.RevokeSession(claims.Id, client.KeyID, time.Unix(claims.IssuedAt, 0).Add(service.SessionLifetime))
+	}
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+
+	jsonResponse(w, &logoutResult{Result: true})
+}
@@ -0,0 +1,163 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/smart"
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	log "github.com/sirupsen/logrus"
+	msgpack "gopkg.in/vmihailenco/msgpack.v2"
+)
+
+type simulateForm struct {
+	nopeValidator
+	Data string `schema:"data"`
+	Name string `schema:"name"`
+}
+
+type extFuncFuel struct {
+	Name  string `json:"name"`
+	Calls int64  `json:"calls"`
+	Fuel  int64  `json:"fuel"`
+}
+
+type simulateTable struct {
+	Name  string `json:"name"`
+	Write bool   `json:"write"`
+}
+
+type simulateResult struct {
+	Result   string          `json:"result"`
+	Message  *txstatusError  `json:"errmsg,omitempty"`
+	Fuel     int64           `json:"fuel"`
+	ExtFuncs []extFuncFuel   `json:"ext_funcs"`
+	Tables   []simulateTable `json:"tables"`
+}
+
+// getSimulateTx returns the contract call for the simulation, it writes the error response
+// and returns nil if the call is not valid
+func getSimulateTx(w http.ResponseWriter, r *http.Request, form *simulateForm) (*tx.SmartContract, int64) {
+	client := getClient(r)
+	logger := getLogger(r)
+
+	var (
+		data []byte
+		err  error
+	)
+	if r.MultipartForm != nil && len(r.MultipartForm.File["data"]) > 0 {
+		data, err = getTxData(r, "data")
+	} else {
+		data, err = hex.DecodeString(form.Data)
+	}
+	if err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return nil, 0
+	}
+
+	smartTx := tx.SmartContract{}
+	if err := msgpack.Unmarshal(data, &smartTx); err != nil {
+		logger.WithFields(log.Fields{"type": consts.UnmarshallingError, "error": err}).Error("unmarshalling smart tx msgpack")
+		errorResponse(w, err, http.StatusBadRequest)
+		return nil, 0
+	}
+	if smartTx.KeyID == 0 {
+		smartTx.KeyID = client.KeyID
+	} else if smartTx.KeyID != client.KeyID {
+		errorResponse(w, errDiffKey)
+		return nil, 0
+	}
+	if smartTx.EcosystemID == 0 {
+		smartTx.EcosystemID = client.EcosystemID
+	}
+	if smartTx.Time == 0 {
+		smartTx.Time = time.Now().Unix()
+	}
+	if len(form.Name) > 0 {
+		contract := smart.GetContract(form.Name, uint32(client.EcosystemID))
+		if contract == nil {
+			errorResponse(w, errContract.Errorf(form.Name))
+			return nil, 0
+		}
+		smartTx.ID = int(contract.Info().ID)
+	}
+	return &smartTx, int64(len(data))
+}
+
+func newSimulateResult(simulation *smart.Simulation) *simulateResult {
+	result := &simulateResult{
+		Result:   simulation.Result,
+		Fuel:     simulation.Fuel,
+		ExtFuncs: make([]extFuncFuel, 0, len(simulation.ExtFunc)),
+		Tables:   make([]simulateTable, 0, len(simulation.Tables)),
+	}
+	if simulation.Error != nil {
+		errText := simulation.Error.Error()
+		if err := json.Unmarshal([]byte(errText), &result.Message); err != nil {
+			result.Message = &txstatusError{Type: "txError", Error: errText}
+		}
+	}
+	for _, item := range simulation.ExtFuncs() {
+		result.ExtFuncs = append(result.ExtFuncs, extFuncFuel{Name: item.Name, Calls: item.Calls, Fuel: item.Fuel})
+	}
+	for name, write := range simulation.Tables {
+		result.Tables = append(result.Tables, simulateTable{Name: name, Write: write})
+	}
+	sort.Slice(result.Tables, func(i, j int) bool { return result.Tables[i].Name < result.Tables[j].Name })
+	return result
+}
+
+// simulateHandler executes the unsigned contract call without committing it. The call is passed
+// as the msgpack encoded tx.SmartContract in the hex string or the multipart file 'data',
+// the contract can be specified by the name instead of the header identifier
+func simulateHandler(w http.ResponseWriter, r *http.Request) {
+	form := &simulateForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	smartTx, size := getSimulateTx(w, r, form)
+	if smartTx == nil {
+		return
+	}
+	simulation, err := smart.SimulateContract(smartTx, size, nil)
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+
+	jsonResponse(w, newSimulateResult(simulation))
+}
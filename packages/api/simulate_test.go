@@ -0,0 +1,131 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	msgpack "gopkg.in/vmihailenco/msgpack.v2"
+)
+
+func TestSimulate(t *testing.T) {
+	require.NoError(t, keyLogin(1))
+
+	name := randName(`simulate`)
+	data, err := msgpack.Marshal(tx.SmartContract{
+		Params: map[string]interface{}{
+			"Name":       name,
+			"Value":      "simulated",
+			"Conditions": `ContractConditions("MainCondition")`,
+		},
+	})
+	require.NoError(t, err)
+
+	var ret simulateResult
+	require.NoError(t, sendPost(`simulate`, &url.Values{
+		"name": {"NewParameter"},
+		"data": {hex.EncodeToString(data)},
+	}, &ret))
+	assert.Nil(t, ret.Message)
+	assert.True(t, ret.Fuel > 0)
+	assert.NotEmpty(t, ret.ExtFuncs)
+
+	var write bool
+	for _, table := range ret.Tables {
+		write = write || table.Write
+	}
+	assert.True(t, write)
+
+	// the changes of the simulation are rolled back
+	var params ecosystemParamsResult
+	require.NoError(t, sendGet(`ecosystemparams?names=`+name, nil, &params))
+	assert.Len(t, params.List, 0)
+
+	// the parameters are checked before the execution
+	data, err = msgpack.Marshal(tx.SmartContract{Params: map[string]interface{}{}})
+	require.NoError(t, err)
+	assert.Error(t, sendPost(`simulate`, &url.Values{
+		"name": {"NewParameter"},
+		"data": {hex.EncodeToString(data)},
+	}, &ret))
+}
+
+func TestSimulateGlobalState(t *testing.T) {
+	require.NoError(t, keyLogin(1))
+
+	simulate := func(name string, params map[string]interface{}) simulateResult {
+		data, err := msgpack.Marshal(tx.SmartContract{Params: params})
+		require.NoError(t, err)
+		var ret simulateResult
+		require.NoError(t, sendPost(`simulate`, &url.Values{
+			"name": {name},
+			"data": {hex.EncodeToString(data)},
+		}, &ret))
+		assert.Nil(t, ret.Message)
+		return ret
+	}
+
+	name := randName(`simstate`)
+	source := `contract ` + name + ` {
+		action {
+			$result = SysParamString("max_dbfind_cost") + "old"
+		}
+	}`
+	require.NoError(t, postTx(`NewContract`, &url.Values{
+		"Value":         {source},
+		"Conditions":    {`true`},
+		"ApplicationId": {"1"},
+	}))
+	var contract getContractResult
+	require.NoError(t, sendGet(`contract/`+name, nil, &contract))
+
+	var params ecosystemParamsResult
+	require.NoError(t, sendGet(`systemparams?names=max_dbfind_cost`, nil, &params))
+	require.Len(t, params.List, 1)
+	cost := params.List[0].Value
+
+	simulate(`EditContract`, map[string]interface{}{
+		"Id":         converter.StrToInt64(contract.TableID),
+		"Value":      strings.Replace(source, `"old"`, `"new"`, 1),
+		"Conditions": `true`,
+	})
+	simulate(`UpdateSysParam`, map[string]interface{}{
+		"Name":  "max_dbfind_cost",
+		"Value": converter.Int64ToStr(converter.StrToInt64(cost) + 1),
+	})
+	// neither the contract nor the system parameter of the node are changed by the simulations
+	assert.Equal(t, cost+`old`, simulate(name, map[string]interface{}{}).Result)
+}
@@ -327,6 +327,65 @@ func TestCutoff(t *testing.T) {
 	assert.Equal(t, longText, string(data))
 }
 
+func TestDBFindAggregate(t *testing.T) {
+	assert.NoError(t, keyLogin(1))
+
+	dept := randName(`dep`)
+	emp := randName(`emp`)
+	form := url.Values{
+		"Name":          {dept},
+		"Columns":       {`[{"name":"name","type":"varchar", "index": "0", "conditions":"true"}]`},
+		"Permissions":   {`{"insert": "true", "update" : "true", "new_column": "true"}`},
+		"ApplicationId": {"1"},
+	}
+	assert.NoError(t, postTx(`NewTable`, &form))
+	form = url.Values{
+		"Name": {emp},
+		"Columns": {`[{"name":"dept","type":"number", "index": "1", "conditions":"true"},
+			{"name":"salary","type":"number", "index": "0", "conditions":"true"},
+			{"name":"secret","type":"varchar", "index": "0", "conditions":{"read": "false", "update": "true"}}]`},
+		"Permissions":   {`{"insert": "true", "update" : "true", "new_column": "true"}`},
+		"ApplicationId": {"1"},
+	}
+	assert.NoError(t, postTx(`NewTable`, &form))
+	form = url.Values{
+		"Value": {`contract ` + emp + ` {
+			action {
+				DBInsert("` + dept + `", {name: "first"})
+				DBInsert("` + dept + `", {name: "second"})
+				DBInsert("` + emp + `", {dept: 1, salary: 100, secret: "a"})
+				DBInsert("` + emp + `", {dept: 1, salary: 200, secret: "b"})
+				DBInsert("` + emp + `", {dept: 2, salary: 50, secret: "c"})
+			}
+		}`},
+		"Conditions":    {`true`},
+		"ApplicationId": {"1"},
+	}
+	assert.NoError(t, postTx(`NewContract`, &form))
+	assert.NoError(t, postTx(emp, &url.Values{}))
+
+	var ret contentResult
+	for _, item := range []struct {
+		template string
+		want     string
+	}{
+		{`DBFind(` + emp + `, src).GroupBy(dept).Aggregate("total=sum(salary),cnt=count(*)").Count(c)`,
+			`"columns":["dept","total","cnt"],"count":"2","data":[["1","300","2"],["2","50","1"]]`},
+		{`DBFind(` + emp + `, src).Join(` + dept + `, dept).GroupBy(` + dept + `.name).Aggregate("top=max(salary)").Order([{"top": -1}])`,
+			`"columns":["` + dept + `.name","top"],"data":[["first","200"],["second","50"]]`},
+		{`DBFind(` + emp + `, src).Columns("salary").Join(` + dept + `, dept, name).Order(id).Limit(1)`,
+			`"columns":["salary","id","` + dept + `.name"],"data":[["100","1","first"]]`},
+		{`DBFind(` + emp + `, src).GroupBy(dept).Aggregate("m=max(secret)")`, `Access denied`},
+		{`DBFind(` + emp + `, src).Aggregate("m=median(salary)")`, `Wrong aggregate m=median(salary)`},
+		{`DBFind(` + emp + `, src).Join(` + dept + `, salary2, name)`, `Column salary2 must keep the id of ` + dept},
+	} {
+		assert.NoError(t, sendPost(`content`, &url.Values{`template`: {item.template}}, &ret))
+		if tree := RawToString(ret.Tree); !strings.Contains(tree, item.want) {
+			t.Errorf("Wrong tree %s != %s", tree, item.want)
+		}
+	}
+}
+
 var imageData = `iVBORw0KGgoAAAANSUhEUgAAADIAAAAyCAIAAACRXR/mAAAACXBIWXMAAAsTAAALEwEAmpwYAAAARklEQVRYw+3OMQ0AIBAEwQOzaCLBBQZfAd0XFLMCNjOyb1o7q2Ey82VYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYrwqjmwKzLUjCbwAAAABJRU5ErkJggg==`
 
 func TestBinary(t *testing.T) {
@@ -0,0 +1,302 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/rollback"
+	"github.com/AplaProject/go-apla/packages/script"
+	"github.com/AplaProject/go-apla/packages/smart"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTraceLimit = 10000
+	maxTraceLimit     = 100000
+
+	// debugTimeout is the time during which the paused session waits for the next command
+	debugTimeout = 30 * time.Second
+	// maxDebugDuration is the maximum lifetime of the debug session and its transaction
+	maxDebugDuration = 2 * time.Minute
+)
+
+type traceForm struct {
+	nopeValidator
+	Block int64 `schema:"block"`
+	Limit int   `schema:"limit"`
+}
+
+type traceResult struct {
+	*simulateResult
+	Steps     []*script.TraceStep `json:"steps"`
+	Truncated bool                `json:"truncated,omitempty"`
+}
+
+// traceHandler replays the contract transaction from the blockchain and returns the executed
+// byte-codes. The transaction is replayed on the state before it or after the block 'block'.
+// The request is allowed only from the local host because the replay rewinds the blocks
+func traceHandler(w http.ResponseWriter, r *http.Request) {
+	form := &traceForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	logger := getLogger(r)
+	hash, err := hex.DecodeString(mux.Vars(r)["hash"])
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.ConversionError, "error": err}).Error("decoding tx hash from hex")
+		errorResponse(w, errHashWrong)
+		return
+	}
+	recorder := &script.TraceRecorder{Limit: form.Limit}
+	if recorder.Limit <= 0 {
+		recorder.Limit = defaultTraceLimit
+	} else if recorder.Limit > maxTraceLimit {
+		recorder.Limit = maxTraceLimit
+	}
+
+	simulation, err := rollback.ReplayTransaction(hash, form.Block, recorder)
+	switch err {
+	case nil:
+	case rollback.ErrReplayNotFound:
+		errorResponse(w, errHashNotFound)
+		return
+	case rollback.ErrReplayNotContract:
+		errorResponse(w, errNotContractTx)
+		return
+	case rollback.ErrReplayTooFar:
+		errorResponse(w, errReplayTooFar.Errorf(rollback.MaxReplayBlocks))
+		return
+	default:
+		errorResponse(w, err)
+		return
+	}
+
+	jsonResponse(w, &traceResult{
+		simulateResult: newSimulateResult(simulation),
+		Steps:          recorder.Steps,
+		Truncated:      recorder.Truncated,
+	})
+}
+
+type debugSession struct {
+	sync.Mutex
+	keyID      int64
+	debugger   *script.Debugger
+	simulation *smart.Simulation
+	err        error
+}
+
+var debugSessions = struct {
+	sync.RWMutex
+	sessions map[string]*debugSession
+}{sessions: make(map[string]*debugSession)}
+
+func getDebugSession(id string) *debugSession {
+	debugSessions.RLock()
+	defer debugSessions.RUnlock()
+	return debugSessions.sessions[id]
+}
+
+func removeDebugSession(id string) {
+	debugSessions.Lock()
+	defer debugSessions.Unlock()
+	delete(debugSessions.sessions, id)
+}
+
+type debugForm struct {
+	nopeValidator
+	Data        string `schema:"data"`
+	Name        string `schema:"name"`
+	Breakpoints string `schema:"breakpoints"`
+	Step        bool   `schema:"step"`
+}
+
+type debugCommandForm struct {
+	nopeValidator
+	Breakpoints *string `schema:"breakpoints"`
+}
+
+type debugResult struct {
+	Session  string            `json:"session"`
+	Paused   *script.TraceStep `json:"paused,omitempty"`
+	Finished *simulateResult   `json:"finished,omitempty"`
+}
+
+// parseBreakpoints parses the comma separated list of breakpoints 'contract:line'.
+// The contract is empty for the functions out of contracts
+func parseBreakpoints(list string, ecosystemID int64) ([]script.Breakpoint, error) {
+	breakpoints := make([]script.Breakpoint, 0)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		off := strings.LastIndexByte(item, ':')
+		if off < 0 {
+			return nil, errBreakpoint.Errorf(item)
+		}
+		line, err := strconv.ParseUint(item[off+1:], 10, 32)
+		if err != nil {
+			return nil, errBreakpoint.Errorf(item)
+		}
+		breakpoint := script.Breakpoint{Line: uint32(line)}
+		if off > 0 {
+			contract := smart.GetContract(item[:off], uint32(ecosystemID))
+			if contract == nil {
+				return nil, errContract.Errorf(item[:off])
+			}
+			breakpoint.Contract = contract.Name
+		}
+		breakpoints = append(breakpoints, breakpoint)
+	}
+	return breakpoints, nil
+}
+
+func waitDebugSession(w http.ResponseWriter, id string, session *debugSession) {
+	result := &debugResult{Session: id}
+	if result.Paused = session.debugger.Wait(); result.Paused == nil {
+		removeDebugSession(id)
+		if session.err != nil {
+			errorResponse(w, session.err)
+			return
+		}
+		result.Finished = newSimulateResult(session.simulation)
+	}
+	jsonResponse(w, result)
+}
+
+// debugHandler starts the simulation of the contract call under the debugger. The parameters
+// are the same as for simulateHandler. The execution is paused on the first line if 'step' is
+// true or on the 'breakpoints'
+func debugHandler(w http.ResponseWriter, r *http.Request) {
+	form := &debugForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	client := getClient(r)
+	breakpoints, err := parseBreakpoints(form.Breakpoints, client.EcosystemID)
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+	smartTx, size := getSimulateTx(w, r, &simulateForm{Data: form.Data, Name: form.Name})
+	if smartTx == nil {
+		return
+	}
+	id, err := newSessionID()
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+	// the paused session must not hold the locks of rows for long
+	dbTransaction, err := model.StartSnapshotTransaction(debugTimeout)
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+
+	session := &debugSession{
+		keyID:    client.KeyID,
+		debugger: script.NewDebugger(debugTimeout, form.Step, breakpoints),
+	}
+	session.debugger.SetDeadline(time.Now().Add(maxDebugDuration))
+	debugSessions.Lock()
+	debugSessions.sessions[id] = session
+	debugSessions.Unlock()
+
+	go func() {
+		session.simulation, session.err = smart.SimulateContract(smartTx, size,
+			&smart.SimulateOptions{DbTransaction: dbTransaction, Tracer: session.debugger})
+		dbTransaction.Rollback()
+		session.debugger.Finish()
+		time.AfterFunc(debugTimeout, func() { removeDebugSession(id) })
+	}()
+
+	session.Lock()
+	defer session.Unlock()
+	waitDebugSession(w, id, session)
+}
+
+// debugCommandHandler resumes the paused debug session with the command step, continue or stop.
+// The breakpoints are replaced if the parameter 'breakpoints' is specified
+func debugCommandHandler(w http.ResponseWriter, r *http.Request) {
+	form := &debugCommandForm{}
+	if err := parseForm(r, form); err != nil {
+		errorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	client := getClient(r)
+	params := mux.Vars(r)
+	id := params["session"]
+	session := getDebugSession(id)
+	if session == nil || session.keyID != client.KeyID {
+		errorResponse(w, errDebugSession.Errorf(id))
+		return
+	}
+
+	var cmd script.DebugCommand
+	switch params["command"] {
+	case "step":
+		cmd = script.DebugStep
+	case "continue":
+		cmd = script.DebugContinue
+	case "stop":
+		cmd = script.DebugStop
+	default:
+		errorResponse(w, errNotFound)
+		return
+	}
+	if form.Breakpoints != nil {
+		breakpoints, err := parseBreakpoints(*form.Breakpoints, client.EcosystemID)
+		if err != nil {
+			errorResponse(w, err)
+			return
+		}
+		session.debugger.SetBreakpoints(breakpoints)
+	}
+
+	session.Lock()
+	defer session.Unlock()
+	session.debugger.Resume(cmd)
+	waitDebugSession(w, id, session)
+}
@@ -0,0 +1,109 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"net/url"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	msgpack "gopkg.in/vmihailenco/msgpack.v2"
+)
+
+func TestTrace(t *testing.T) {
+	require.NoError(t, keyLogin(1))
+
+	name := randName(`trace`)
+	data, err := newContractTx(`NewParameter`, map[string]interface{}{
+		"Name":       name,
+		"Value":      "traced",
+		"Conditions": `ContractConditions("MainCondition")`,
+	})
+	require.NoError(t, err)
+
+	ret := &sendTxResult{}
+	require.NoError(t, sendMultipart("sendTx", map[string][]byte{"data": data}, &ret))
+	_, err = waitTx(ret.Hashes["data"])
+	require.NoError(t, err)
+
+	var trace traceResult
+	require.NoError(t, sendGet(`trace/`+ret.Hashes["data"], nil, &trace))
+	assert.Nil(t, trace.Message)
+	assert.NotEmpty(t, trace.Steps)
+
+	var contracts bool
+	for _, step := range trace.Steps {
+		contracts = contracts || step.Contract == `@1NewParameter`
+	}
+	assert.True(t, contracts)
+
+	// the count of steps is limited
+	require.NoError(t, sendGet(`trace/`+ret.Hashes["data"], &url.Values{"limit": {"10"}}, &trace))
+	assert.Len(t, trace.Steps, 10)
+	assert.True(t, trace.Truncated)
+
+	assert.Error(t, sendGet(`trace/`+hex.EncodeToString([]byte(name)), nil, &trace))
+}
+
+func TestDebug(t *testing.T) {
+	require.NoError(t, keyLogin(1))
+
+	data, err := msgpack.Marshal(tx.SmartContract{
+		Params: map[string]interface{}{
+			"Name":       randName(`debug`),
+			"Value":      "debugged",
+			"Conditions": `ContractConditions("MainCondition")`,
+		},
+	})
+	require.NoError(t, err)
+
+	var ret debugResult
+	require.NoError(t, sendPost(`debug`, &url.Values{
+		"name": {"NewParameter"},
+		"data": {hex.EncodeToString(data)},
+		"step": {"true"},
+	}, &ret))
+	require.NotNil(t, ret.Paused)
+	assert.NotEmpty(t, ret.Session)
+
+	session := ret.Session
+	require.NoError(t, sendPost(`debug/`+session+`/step`, nil, &ret))
+	require.NotNil(t, ret.Paused)
+
+	require.NoError(t, sendPost(`debug/`+session+`/continue`, nil, &ret))
+	assert.Nil(t, ret.Paused)
+	if assert.NotNil(t, ret.Finished) {
+		assert.Nil(t, ret.Finished.Message)
+	}
+
+	assert.Error(t, sendPost(`debug/`+session+`/step`, nil, &ret))
+}
@@ -0,0 +1,89 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package api
+
+import (
+	"net/http"
+
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/crypto"
+)
+
+type validatorItem struct {
+	Position   int    `json:"position"`
+	KeyID      string `json:"key_id"`
+	PublicKey  string `json:"public_key"`
+	TCPAddress string `json:"tcp_address"`
+	APIAddress string `json:"api_address"`
+}
+
+type validatorSetResult struct {
+	Activation int64           `json:"activation"`
+	BlockID    int64           `json:"block_id"`
+	Nodes      []validatorItem `json:"nodes"`
+}
+
+type validatorsResult struct {
+	EpochLength int64                `json:"epoch_length"`
+	Current     validatorSetResult   `json:"current"`
+	Pending     []validatorSetResult `json:"pending"`
+}
+
+func newValidatorSetResult(set syspar.ValidatorSet) validatorSetResult {
+	result := validatorSetResult{
+		Activation: set.Activation,
+		BlockID:    set.BlockID,
+		Nodes:      make([]validatorItem, 0, len(set.Nodes)),
+	}
+	for i, node := range set.Nodes {
+		result.Nodes = append(result.Nodes, validatorItem{
+			Position:   i,
+			KeyID:      converter.Int64ToStr(node.KeyID),
+			PublicKey:  crypto.PubToHex(node.PublicKey),
+			TCPAddress: node.TCPAddress,
+			APIAddress: node.APIAddress,
+		})
+	}
+	return result
+}
+
+// validatorsHandler returns the validator set which is active at the next block and
+// the sets which are waiting for the activation at the start of the next epochs
+func validatorsHandler(w http.ResponseWriter, r *http.Request) {
+	pending := syspar.GetPendingValidatorSets()
+	result := &validatorsResult{
+		EpochLength: syspar.GetEpochLength(),
+		Current:     newValidatorSetResult(syspar.GetCurrentValidatorSet()),
+		Pending:     make([]validatorSetResult, 0, len(pending)),
+	}
+	for _, set := range pending {
+		result.Pending = append(result.Pending, newValidatorSetResult(set))
+	}
+	jsonResponse(w, result)
+}
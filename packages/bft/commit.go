@@ -0,0 +1,226 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+// Package bft implements the optional commit round of the validators. Every validator signs
+// the precommit vote for each block it has played and sends the vote to other validators.
+// The block which has got the votes of more than 2/3 of the validators becomes finalized,
+// the set of the signatures is the commit of the block. The commit is carried by a later block
+// so every node of the network learns the finalized height from the chain.
+//
+// The vote contains the last finalized block known to the validator and it's counted only by
+// the nodes whose chain contains that block. The validator is locked on its last vote: after
+// the rollback to another fork it doesn't vote again until a block at or above the height
+// of its last vote is finalized, so it never votes for the blocks of two conflicting forks.
+package bft
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/crypto"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+var (
+	// ErrValidator is returned if the vote is signed by the key which isn't a validator
+	ErrValidator = errors.New("Unknown validator")
+	// ErrVoteSign is returned if the signature of the vote is incorrect
+	ErrVoteSign = errors.New("Vote signature is incorrect")
+	// ErrDoubleVote is returned if the validator votes for different blocks at the same height
+	ErrDoubleVote = errors.New("Validator has already voted for another block")
+	// ErrQuorum is returned if the commit has no votes of more than 2/3 of the validators
+	ErrQuorum = errors.New("Not enough votes of the validators")
+	// ErrCommitBlock is returned if the commit doesn't match the block of the chain
+	ErrCommitBlock = errors.New("Commit doesn't match the block")
+	// ErrVoteLock is returned if the voted block doesn't extend the finalized block of the vote
+	// or that block doesn't match the chain
+	ErrVoteLock = errors.New("Vote doesn't extend the finalized block")
+	// ErrVoteHeight is returned if the vote is for the block far beyond the current height
+	ErrVoteHeight = errors.New("Vote block is too far beyond the current height")
+)
+
+// Validators is the set of the public keys of the validators by their key_id
+type Validators map[int64][]byte
+
+// ValidatorsAt returns the active full nodes of the validator set which is active at the block
+func ValidatorsAt(blockID int64) Validators {
+	validators := make(Validators)
+	for _, node := range syspar.GetNodesAt(blockID) {
+		validators[node.KeyID] = node.PublicKey
+	}
+	return validators
+}
+
+// Quorum returns the count of votes which is required for the commit
+func (vs Validators) Quorum() int {
+	return len(vs)*2/3 + 1
+}
+
+// CanReachQuorum returns false if the validators have voted for other blocks at the height
+// of the votes so that the block with the hash can't get the quorum anymore
+func (vs Validators) CanReachQuorum(hash []byte, votes []Vote) bool {
+	others := make(map[int64]bool)
+	for _, v := range votes {
+		if _, ok := vs[v.KeyID]; ok && !bytes.Equal(v.Hash, hash) {
+			others[v.KeyID] = true
+		}
+	}
+	return len(vs)-len(others) >= vs.Quorum()
+}
+
+// Vote is the precommit of the validator for the block. LockID and LockHash are the last
+// finalized block known to the validator, the voted block extends it
+type Vote struct {
+	BlockID  int64
+	Hash     []byte
+	LockID   int64
+	LockHash []byte
+	KeyID    int64
+	Sign     []byte
+}
+
+// ForSign returns the signed data of the vote
+func (v *Vote) ForSign() string {
+	return fmt.Sprintf("precommit,%d,%x,%d,%x", v.BlockID, v.Hash, v.LockID, v.LockHash)
+}
+
+// NewVote returns the vote for the block signed by the private key of the validator
+func NewVote(blockID int64, hash []byte, lockID int64, lockHash []byte, keyID int64, privateKey string) (*Vote, error) {
+	v := &Vote{BlockID: blockID, Hash: hash, LockID: lockID, LockHash: lockHash, KeyID: keyID}
+	sign, err := crypto.SignString(privateKey, v.ForSign())
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("signing vote")
+		return nil, err
+	}
+	v.Sign = sign
+	return v, nil
+}
+
+// Verify checks that the vote is signed by the validator
+func (v *Vote) Verify(validators Validators) error {
+	publicKey, ok := validators[v.KeyID]
+	if !ok {
+		return ErrValidator
+	}
+	if len(v.Hash) != consts.HashSize {
+		return ErrVoteSign
+	}
+	if v.LockID < 0 || v.LockID >= v.BlockID {
+		return ErrVoteLock
+	}
+	if (v.LockID == 0 && len(v.LockHash) != 0) || (v.LockID > 0 && len(v.LockHash) != consts.HashSize) {
+		return ErrVoteLock
+	}
+	if ok, err := crypto.CheckSign(publicKey, []byte(v.ForSign()), v.Sign); err != nil || !ok {
+		return ErrVoteSign
+	}
+	return nil
+}
+
+// Commit is the set of the votes of the validators for the block
+type Commit struct {
+	BlockID int64
+	Hash    []byte
+	Votes   []Vote
+}
+
+// NewCommit returns the commit of the block, the votes are sorted by the validator
+func NewCommit(blockID int64, hash []byte, votes []Vote) *Commit {
+	c := &Commit{BlockID: blockID, Hash: hash, Votes: append([]Vote{}, votes...)}
+	sort.Slice(c.Votes, func(i, j int) bool {
+		return c.Votes[i].KeyID < c.Votes[j].KeyID
+	})
+	return c
+}
+
+// Bytes returns the binary representation of the commit
+func (c *Commit) Bytes() ([]byte, error) {
+	data, err := msgpack.Marshal(c)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.MarshallingError, "error": err}).Error("marshalling commit")
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseCommit returns the commit from its binary representation
+func ParseCommit(data []byte) (*Commit, error) {
+	c := &Commit{}
+	if err := msgpack.Unmarshal(data, c); err != nil {
+		log.WithFields(log.Fields{"type": consts.UnmarshallingError, "error": err}).Error("unmarshalling commit")
+		return nil, err
+	}
+	return c, nil
+}
+
+// Verify checks that the commit contains the correct votes of more than 2/3 of the validators
+func (c *Commit) Verify(validators Validators) error {
+	voted := make(map[int64]bool, len(c.Votes))
+	for i := range c.Votes {
+		v := &c.Votes[i]
+		if v.BlockID != c.BlockID || !bytes.Equal(v.Hash, c.Hash) {
+			return ErrCommitBlock
+		}
+		if voted[v.KeyID] {
+			return ErrDoubleVote
+		}
+		if err := v.Verify(validators); err != nil {
+			return err
+		}
+		voted[v.KeyID] = true
+	}
+	if len(voted) < validators.Quorum() {
+		return ErrQuorum
+	}
+	return nil
+}
+
+// MarshalVotes returns the binary representation of the list of votes
+func MarshalVotes(votes []*Vote) ([]byte, error) {
+	data, err := msgpack.Marshal(votes)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.MarshallingError, "error": err}).Error("marshalling votes")
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseVotes returns the list of votes from its binary representation
+func ParseVotes(data []byte) ([]*Vote, error) {
+	var votes []*Vote
+	if err := msgpack.Unmarshal(data, &votes); err != nil {
+		log.WithFields(log.Fields{"type": consts.UnmarshallingError, "error": err}).Error("unmarshalling votes")
+		return nil, err
+	}
+	return votes, nil
+}
@@ -0,0 +1,178 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package bft
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/crypto"
+
+	"github.com/stretchr/testify/require"
+)
+
+type validatorKey struct {
+	*ecdsa.PrivateKey
+}
+
+func newValidatorKey(t *testing.T) validatorKey {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return validatorKey{private}
+}
+
+func (key validatorKey) public() []byte {
+	return append(converter.FillLeft(key.X.Bytes()), converter.FillLeft(key.Y.Bytes())...)
+}
+
+func (key validatorKey) vote(t *testing.T, blockID int64, hash []byte, keyID int64) Vote {
+	return key.sign(t, Vote{BlockID: blockID, Hash: hash, KeyID: keyID})
+}
+
+func (key validatorKey) sign(t *testing.T, v Vote) Vote {
+	data, err := crypto.Hash([]byte(v.ForSign()))
+	require.NoError(t, err)
+	r, s, err := ecdsa.Sign(rand.Reader, key.PrivateKey, data)
+	require.NoError(t, err)
+	v.Sign = append(converter.FillLeft(r.Bytes()), converter.FillLeft(s.Bytes())...)
+	return v
+}
+
+func TestQuorum(t *testing.T) {
+	for count, quorum := range map[int]int{1: 1, 2: 2, 3: 3, 4: 3, 5: 4, 7: 5, 10: 7} {
+		validators := make(Validators)
+		for i := 0; i < count; i++ {
+			validators[int64(i)] = nil
+		}
+		require.Equal(t, quorum, validators.Quorum(), "validators %d", count)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	keys := make([]validatorKey, 4)
+	validators := make(Validators)
+	for i := range keys {
+		keys[i] = newValidatorKey(t)
+		validators[int64(i+1)] = keys[i].public()
+	}
+	hash, err := crypto.Hash([]byte("block"))
+	require.NoError(t, err)
+	otherHash, err := crypto.Hash([]byte("other block"))
+	require.NoError(t, err)
+
+	votes := []Vote{keys[2].vote(t, 10, hash, 3), keys[0].vote(t, 10, hash, 1)}
+	require.NoError(t, votes[0].Verify(validators))
+	unknown, foreign := keys[0].vote(t, 10, hash, 5), keys[0].vote(t, 10, hash, 2)
+	require.Equal(t, ErrValidator, unknown.Verify(validators))
+	require.Equal(t, ErrVoteSign, foreign.Verify(validators))
+
+	commit := NewCommit(10, hash, votes)
+	require.Equal(t, int64(1), commit.Votes[0].KeyID)
+	require.Equal(t, ErrQuorum, commit.Verify(validators))
+
+	commit = NewCommit(10, hash, append(votes, keys[1].vote(t, 10, hash, 2)))
+	require.NoError(t, commit.Verify(validators))
+
+	data, err := commit.Bytes()
+	require.NoError(t, err)
+	parsed, err := ParseCommit(data)
+	require.NoError(t, err)
+	require.Equal(t, commit, parsed)
+	require.NoError(t, parsed.Verify(validators))
+
+	require.Equal(t, ErrDoubleVote, NewCommit(10, hash, append(votes, votes[0])).Verify(validators))
+	require.Equal(t, ErrCommitBlock, NewCommit(10, hash, append(votes, keys[1].vote(t, 10, otherHash, 2))).Verify(validators))
+	require.Equal(t, ErrCommitBlock, NewCommit(10, hash, append(votes, keys[1].vote(t, 11, hash, 2))).Verify(validators))
+}
+
+func TestVotesEncoding(t *testing.T) {
+	key := newValidatorKey(t)
+	hash, err := crypto.Hash([]byte("block"))
+	require.NoError(t, err)
+	v1, v2 := key.vote(t, 5, hash, 1), key.vote(t, 6, hash, 1)
+	data, err := MarshalVotes([]*Vote{&v1, &v2})
+	require.NoError(t, err)
+	votes, err := ParseVotes(data)
+	require.NoError(t, err)
+	require.Equal(t, []*Vote{&v1, &v2}, votes)
+}
+
+func TestVoteLock(t *testing.T) {
+	key := newValidatorKey(t)
+	validators := Validators{1: key.public()}
+	hash, err := crypto.Hash([]byte("block"))
+	require.NoError(t, err)
+	lockHash, err := crypto.Hash([]byte("finalized block"))
+	require.NoError(t, err)
+
+	v := key.sign(t, Vote{BlockID: 10, Hash: hash, LockID: 5, LockHash: lockHash, KeyID: 1})
+	require.NoError(t, v.Verify(validators))
+
+	// the lock is signed
+	changed := v
+	changed.LockID = 6
+	require.Equal(t, ErrVoteSign, changed.Verify(validators))
+
+	for _, lock := range []Vote{
+		{BlockID: 10, Hash: hash, LockID: 10, LockHash: lockHash, KeyID: 1},
+		{BlockID: 10, Hash: hash, LockID: -1, KeyID: 1},
+		{BlockID: 10, Hash: hash, LockID: 5, KeyID: 1},
+		{BlockID: 10, Hash: hash, LockID: 0, LockHash: lockHash, KeyID: 1},
+	} {
+		wrong := key.sign(t, lock)
+		require.Equal(t, ErrVoteLock, wrong.Verify(validators))
+	}
+}
+
+func TestSplitVote(t *testing.T) {
+	validators := make(Validators)
+	for i := int64(1); i <= 4; i++ {
+		validators[i] = nil
+	}
+	hash, otherHash := []byte("block"), []byte("other block")
+	vote := func(keyID int64, hash []byte) Vote {
+		return Vote{BlockID: 10, Hash: hash, KeyID: keyID}
+	}
+
+	// the validators are released from both blocks after the split vote
+	split := []Vote{vote(1, hash), vote(2, hash), vote(3, otherHash), vote(4, otherHash)}
+	require.False(t, validators.CanReachQuorum(hash, split))
+	require.False(t, validators.CanReachQuorum(otherHash, split))
+
+	// the block can get the quorum while the rest votes are unknown
+	pending := []Vote{vote(1, hash), vote(2, hash), vote(3, otherHash)}
+	require.True(t, validators.CanReachQuorum(hash, pending))
+	require.False(t, validators.CanReachQuorum(otherHash, pending))
+
+	// the votes of the unknown keys aren't counted
+	unknown := []Vote{vote(1, hash), vote(5, otherHash), vote(6, otherHash)}
+	require.True(t, validators.CanReachQuorum(hash, unknown))
+}
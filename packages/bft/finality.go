@@ -0,0 +1,388 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package bft
+
+import (
+	"bytes"
+
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxVotes is the maximum count of the votes which are made or sent at once
+const MaxVotes = 100
+
+// maxVoteAhead is the maximum distance between the current height and the height of the received vote
+const maxVoteAhead = MaxVotes
+
+// IsEnabled returns true if the commit round is turned on by bft_finality system parameter
+func IsEnabled() bool {
+	return syspar.IsBFTFinality()
+}
+
+// FinalizedBlockID returns the ID of the last finalized block or 0
+func FinalizedBlockID(dbTransaction *model.DbTransaction) (int64, error) {
+	blockID, err := model.GetFinalizedBlockID(dbTransaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting finalized block id")
+	}
+	return blockID, err
+}
+
+// lastFinalized returns the ID and the hash of the last finalized block, the ID is 0 if
+// there are no finalized blocks
+func lastFinalized() (int64, []byte, error) {
+	commit := &model.BlockCommit{}
+	found, err := commit.GetLast()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last commit")
+		return 0, nil, err
+	}
+	if !found {
+		return 0, nil, nil
+	}
+	return commit.BlockID, commit.Hash, nil
+}
+
+// isLocked returns true if the validator has voted for the block of another fork after the finalized
+// block and that block can still get the quorum. The validator is released from the vote when
+// the other validators have voted for other blocks at that height, e.g. after the split vote
+func isLocked(keyID, finalized int64) (bool, error) {
+	items, err := model.GetVotesFrom(keyID, finalized, -1)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting pending votes")
+		return false, err
+	}
+	for _, item := range items {
+		block := &model.Block{}
+		found, err := block.Get(item.BlockID)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting voted block")
+			return false, err
+		}
+		if found && bytes.Equal(block.Hash, item.Hash) {
+			continue
+		}
+		blockVotes, err := model.GetBlockVotes(item.BlockID)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block votes")
+			return false, err
+		}
+		votes := make([]Vote, 0, len(blockVotes))
+		for i := range blockVotes {
+			votes = append(votes, *voteFromModel(&blockVotes[i]))
+		}
+		if ValidatorsAt(item.BlockID).CanReachQuorum(item.Hash, votes) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// VoteBlocks signs the votes of the validator for the blocks of the chain which haven't been voted yet.
+// The validator votes once for each height so it never signs two different blocks with the same ID.
+// It doesn't vote while it's locked on the block of another fork which can still be finalized
+func VoteBlocks(keyID int64, privateKey string) ([]*Vote, error) {
+	last, err := model.GetLastVotedBlockID(keyID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last voted block id")
+		return nil, err
+	}
+	finalized, lockHash, err := lastFinalized()
+	if err != nil {
+		return nil, err
+	}
+	if last > finalized {
+		locked, err := isLocked(keyID, finalized)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			log.WithFields(log.Fields{"type": consts.BlockError, "block_id": last}).Warn("validator is locked on the vote for another fork")
+			return nil, nil
+		}
+	} else {
+		last = finalized
+	}
+	if last == 0 {
+		// the validator which has just turned on the commit round votes only for the recent blocks
+		maxBlock := &model.Block{}
+		if _, err = maxBlock.GetMaxBlock(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting max block")
+			return nil, err
+		}
+		if maxBlock.ID > MaxVotes {
+			last = maxBlock.ID - MaxVotes
+		}
+	}
+	blocks, err := (&model.Block{}).GetBlocksFrom(last, "asc", MaxVotes)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting blocks for voting")
+		return nil, err
+	}
+	votes := make([]*Vote, 0, len(blocks))
+	for _, block := range blocks {
+		v, err := NewVote(block.ID, block.Hash, finalized, lockHash, keyID, privateKey)
+		if err != nil {
+			return nil, err
+		}
+		if err = storeVote(v); err != nil {
+			return nil, err
+		}
+		votes = append(votes, v)
+	}
+	return votes, nil
+}
+
+// PendingVotes returns the votes of the validator for the blocks which aren't finalized yet
+func PendingVotes(keyID int64) ([]*Vote, error) {
+	finalized, err := FinalizedBlockID(nil)
+	if err != nil {
+		return nil, err
+	}
+	items, err := model.GetVotesFrom(keyID, finalized, MaxVotes)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting pending votes")
+		return nil, err
+	}
+	votes := make([]*Vote, 0, len(items))
+	for _, item := range items {
+		votes = append(votes, voteFromModel(&item))
+	}
+	return votes, nil
+}
+
+func voteFromModel(item *model.BlockVote) *Vote {
+	return &Vote{BlockID: item.BlockID, Hash: item.Hash, LockID: item.LockID, LockHash: item.LockHash,
+		KeyID: item.KeyID, Sign: item.Sign}
+}
+
+// matchLock checks the finalized block of the vote against the chain. It returns false if
+// the block isn't known yet and ErrVoteLock if the chain contains another block at that height
+func matchLock(v *Vote) (bool, error) {
+	if v.LockID == 0 {
+		return true, nil
+	}
+	block := &model.Block{}
+	found, err := block.Get(v.LockID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting vote lock block")
+		return false, err
+	}
+	if !found {
+		return false, nil
+	}
+	if !bytes.Equal(block.Hash, v.LockHash) {
+		return false, ErrVoteLock
+	}
+	return true, nil
+}
+
+// AddVote checks the vote which has been received from the validator and stores it. The votes for
+// the finalized heights are ignored, the votes for the blocks far beyond the current height and
+// the votes which extend another fork are rejected
+func AddVote(v *Vote) error {
+	if err := v.Verify(ValidatorsAt(v.BlockID)); err != nil {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "error": err, "key_id": v.KeyID, "block_id": v.BlockID}).Warn("checking vote")
+		return err
+	}
+	finalized, err := FinalizedBlockID(nil)
+	if err != nil {
+		return err
+	}
+	if v.BlockID <= finalized {
+		return nil
+	}
+	maxBlock := &model.Block{}
+	if _, err = maxBlock.GetMaxBlock(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting max block")
+		return err
+	}
+	if v.BlockID > maxBlock.ID+maxVoteAhead {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "key_id": v.KeyID, "block_id": v.BlockID, "max_block_id": maxBlock.ID}).Warn("vote is too far beyond the current height")
+		return ErrVoteHeight
+	}
+	if _, err = matchLock(v); err != nil {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "error": err, "key_id": v.KeyID, "block_id": v.BlockID}).Warn("checking vote lock")
+		return err
+	}
+	prev := &model.BlockVote{}
+	found, err := prev.Get(v.BlockID, v.KeyID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting vote")
+		return err
+	}
+	if found {
+		if !bytes.Equal(prev.Hash, v.Hash) {
+			log.WithFields(log.Fields{"type": consts.DuplicateObject, "key_id": v.KeyID, "block_id": v.BlockID}).Warn("validator voted for two blocks")
+			return ErrDoubleVote
+		}
+		return nil
+	}
+	return storeVote(v)
+}
+
+func storeVote(v *Vote) error {
+	item := &model.BlockVote{BlockID: v.BlockID, KeyID: v.KeyID, Hash: v.Hash, LockID: v.LockID,
+		LockHash: v.LockHash, Sign: v.Sign}
+	if err := item.Create(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating vote")
+		return err
+	}
+	return nil
+}
+
+// Finalize looks for the latest block of the chain which has got the quorum of the votes and
+// stores its commit. It returns the ID of the last finalized block
+func Finalize() (int64, error) {
+	finalized, err := FinalizedBlockID(nil)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := model.GetVotedBlockIDs(finalized)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting voted blocks")
+		return 0, err
+	}
+	// the commit of the block finalizes all its ancestors, so the latest block is checked first
+	for i := len(ids) - 1; i >= 0; i-- {
+		block := &model.Block{}
+		found, err := block.Get(ids[i])
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block")
+			return 0, err
+		}
+		if !found {
+			continue
+		}
+		items, err := model.GetBlockVotes(block.ID)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block votes")
+			return 0, err
+		}
+		validators := ValidatorsAt(block.ID)
+		votes := make([]Vote, 0, len(items))
+		for i := range items {
+			if _, ok := validators[items[i].KeyID]; !ok || !bytes.Equal(items[i].Hash, block.Hash) {
+				continue
+			}
+			// the vote is counted only if the voted block extends its finalized block in this chain
+			v := voteFromModel(&items[i])
+			matched, err := matchLock(v)
+			if err == ErrVoteLock || (err == nil && !matched) {
+				continue
+			} else if err != nil {
+				return 0, err
+			}
+			votes = append(votes, *v)
+		}
+		if len(votes) < validators.Quorum() {
+			continue
+		}
+		data, err := NewCommit(block.ID, block.Hash, votes).Bytes()
+		if err != nil {
+			return 0, err
+		}
+		commit := &model.BlockCommit{BlockID: block.ID, Hash: block.Hash, Data: data}
+		if err = commit.Save(nil); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("saving commit")
+			return 0, err
+		}
+		if _, err = model.DeleteVotesBefore(block.ID); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting old votes")
+		}
+		log.WithFields(log.Fields{"block_id": block.ID}).Debug("block finalized")
+		return block.ID, nil
+	}
+	return finalized, nil
+}
+
+// CommitToCarry returns the commit which should be included to the generated block or nil
+func CommitToCarry() ([]byte, error) {
+	commit := &model.BlockCommit{}
+	found, err := commit.GetToCarry()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting commit to carry")
+		return nil, err
+	}
+	if !found || len(commit.Data) > consts.MaxCommitSize {
+		return nil, nil
+	}
+	return commit.Data, nil
+}
+
+// CheckCommit checks the commit carried by the block blockID. The committed block must precede
+// the block and match the block of the chain
+func CheckCommit(data []byte, blockID int64) (*Commit, error) {
+	c, err := ParseCommit(data)
+	if err != nil {
+		return nil, err
+	}
+	if c.BlockID >= blockID || c.BlockID < 1 {
+		return nil, ErrCommitBlock
+	}
+	block := &model.Block{}
+	found, err := block.Get(c.BlockID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting committed block")
+		return nil, err
+	}
+	if !found || !bytes.Equal(block.Hash, c.Hash) {
+		return nil, ErrCommitBlock
+	}
+	if err = c.Verify(ValidatorsAt(c.BlockID)); err != nil {
+		return nil, err
+	}
+	for i := range c.Votes {
+		matched, err := matchLock(&c.Votes[i])
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return nil, ErrVoteLock
+		}
+	}
+	return c, nil
+}
+
+// SaveCommit stores the commit carried by the block blockID
+func SaveCommit(dbTransaction *model.DbTransaction, data []byte, blockID int64) error {
+	c, err := ParseCommit(data)
+	if err != nil {
+		return err
+	}
+	commit := &model.BlockCommit{BlockID: c.BlockID, Hash: c.Hash, Data: data, Block: blockID}
+	if err = commit.Save(dbTransaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("saving carried commit")
+		return err
+	}
+	return nil
+}
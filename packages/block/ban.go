@@ -31,6 +31,8 @@ package block
 import (
 	"sync"
 	"time"
+
+	"github.com/AplaProject/go-apla/packages/service"
 )
 
 const (
@@ -86,11 +88,11 @@ func BannedTill(keyID int64) string {
 // BadTxForBan adds info about bad tx of the key
 func BadTxForBan(keyID int64) {
 	var (
-		ban banKey
-		ok  bool
+		ban    banKey
+		ok     bool
+		banned bool
 	)
 	mutex.Lock()
-	defer mutex.Unlock()
 	now := time.Now()
 	if ban, ok = banList[keyID]; ok {
 		var bMin, count int
@@ -104,6 +106,7 @@ func BadTxForBan(keyID int64) {
 		}
 		ban.Bad[bMin] = now
 		if count >= maxBadTx-1 {
+			banned = now.After(ban.Time)
 			ban.Time = now.Add(banTime * time.Minute)
 		}
 	} else {
@@ -111,4 +114,10 @@ func BadTxForBan(keyID int64) {
 		ban.Bad[0] = time.Now()
 	}
 	banList[keyID] = ban
+	mutex.Unlock()
+
+	if banned {
+		// the banned key loses all its api sessions
+		service.GetSessionRevocationService().RevokeKey(keyID)
+	}
 }
@@ -34,15 +34,18 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/AplaProject/go-apla/packages/bft"
 	"github.com/AplaProject/go-apla/packages/conf/syspar"
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/converter"
 	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/events"
 	"github.com/AplaProject/go-apla/packages/model"
 	"github.com/AplaProject/go-apla/packages/notificator"
 	"github.com/AplaProject/go-apla/packages/protocols"
 	"github.com/AplaProject/go-apla/packages/script"
 	"github.com/AplaProject/go-apla/packages/smart"
+	"github.com/AplaProject/go-apla/packages/snapshot"
 	"github.com/AplaProject/go-apla/packages/transaction"
 	"github.com/AplaProject/go-apla/packages/transaction/custom"
 	"github.com/AplaProject/go-apla/packages/utils"
@@ -61,6 +64,10 @@ type Block struct {
 	GenBlock      bool // it equals true when we are generating a new block
 	StopCount     int  // The count of good tx in the block
 	Notifications []smart.NotifyInfo
+
+	txEvents    []events.TxEvent
+	changedRows []model.RollbackTx
+	stateHash   []byte
 }
 
 func (b Block) String() string {
@@ -89,29 +96,20 @@ func (b *Block) PlaySafe() error {
 		for _, tr := range doneTx {
 			trData = append(trData, tr.TxFullData)
 		}
-		NodePrivateKey, _, err := utils.GetNodeKeys()
-		if err != nil || len(NodePrivateKey) < 1 {
-			log.WithFields(log.Fields{"type": consts.NodePrivateKeyFilename, "error": err}).Error("reading node private key")
+		if err = b.repack(dbTransaction, trData); err != nil {
+			dbTransaction.Rollback()
 			return err
 		}
-
-		newBlockData, err := MarshallBlock(&b.Header, trData, b.PrevHeader, NodePrivateKey)
-		if err != nil {
-			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("marshalling new block")
-			return err
+	} else if err == nil && b.GenBlock && b.hasStateRoot() {
+		// the state root is known only after playing, so the generated block is signed again
+		trData := make([][]byte, 0, len(b.Transactions))
+		for _, tr := range b.Transactions {
+			trData = append(trData, tr.TxFullData)
 		}
-
-		isFirstBlock := b.Header.BlockID == 1
-		nb, err := UnmarshallBlock(bytes.NewBuffer(newBlockData), isFirstBlock, true)
-		if err != nil {
-			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("parsing new block")
+		if err = b.repack(dbTransaction, trData); err != nil {
+			dbTransaction.Rollback()
 			return err
 		}
-		b.BinData = newBlockData
-		b.Transactions = nb.Transactions
-		b.MrklRoot = nb.MrklRoot
-		b.SysUpdate = nb.SysUpdate
-		err = nil
 	} else if err != nil {
 		dbTransaction.Rollback()
 		if b.GenBlock && b.StopCount == 0 {
@@ -129,12 +127,22 @@ func (b *Block) PlaySafe() error {
 		return err
 	}
 
+	if len(b.Header.Commit) > 0 {
+		if err := bft.SaveCommit(dbTransaction, b.Header.Commit, b.Header.BlockID); err != nil {
+			dbTransaction.Rollback()
+			return err
+		}
+	}
+
 	if err := InsertIntoBlockchain(dbTransaction, b); err != nil {
 		dbTransaction.Rollback()
 		return err
 	}
 
 	dbTransaction.Commit()
+	syspar.SetBlockID(b.Header.BlockID)
+	snapshot.Schedule(b.Header.BlockID)
+	b.PublishEvents()
 	if b.SysUpdate {
 		b.SysUpdate = false
 		if err = syspar.SysUpdate(nil); err != nil {
@@ -142,6 +150,7 @@ func (b *Block) PlaySafe() error {
 			return err
 		}
 	}
+	b.InvalidatePages()
 	for _, item := range b.Notifications {
 		if item.Roles {
 			notificator.UpdateRolesNotifications(item.EcosystemID, item.List)
@@ -152,6 +161,40 @@ func (b *Block) PlaySafe() error {
 	return nil
 }
 
+// repack marshals the block again with the transactions trData and the state root of
+// the played transactions
+func (b *Block) repack(dbTransaction *model.DbTransaction, trData [][]byte) error {
+	if b.hasStateRoot() {
+		root, err := b.calcStateRoot(dbTransaction)
+		if err != nil {
+			return err
+		}
+		b.Header.StateRoot = root
+	}
+	NodePrivateKey, _, err := utils.GetNodeKeys()
+	if err != nil || len(NodePrivateKey) < 1 {
+		log.WithFields(log.Fields{"type": consts.NodePrivateKeyFilename, "error": err}).Error("reading node private key")
+		return err
+	}
+
+	newBlockData, err := MarshallBlock(&b.Header, trData, b.PrevHeader, NodePrivateKey)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("marshalling new block")
+		return err
+	}
+
+	isFirstBlock := b.Header.BlockID == 1
+	nb, err := UnmarshallBlock(bytes.NewBuffer(newBlockData), isFirstBlock, true)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("parsing new block")
+		return err
+	}
+	b.BinData = newBlockData
+	b.Transactions = nb.Transactions
+	b.MrklRoot = nb.MrklRoot
+	return nil
+}
+
 func (b *Block) readPreviousBlockFromBlockchainTable() error {
 	if b.Header.BlockID == 1 {
 		b.PrevHeader = &utils.BlockData{}
@@ -166,6 +209,8 @@ func (b *Block) readPreviousBlockFromBlockchainTable() error {
 	return nil
 }
 
+// Play executes the transactions of the block. The state root of the received block is
+// compared with the result of the execution, so every path which plays the block checks it.
 func (b *Block) Play(dbTransaction *model.DbTransaction) error {
 	logger := b.GetLogger()
 	if _, err := model.DeleteUsedTransactions(dbTransaction); err != nil {
@@ -174,6 +219,7 @@ func (b *Block) Play(dbTransaction *model.DbTransaction) error {
 	}
 
 	limits := NewLimits(b)
+	b.txEvents = nil
 
 	txHashes := make([][]byte, 0, len(b.Transactions))
 	for _, btx := range b.Transactions {
@@ -216,21 +262,7 @@ func (b *Block) Play(dbTransaction *model.DbTransaction) error {
 		}
 		if err != nil {
 			if flush != nil {
-				for i := len(flush) - 1; i >= 0; i-- {
-					finfo := flush[i]
-					if finfo.Prev == nil {
-						if finfo.ID != uint32(len(smart.GetVM().Children)-1) {
-							logger.WithFields(log.Fields{"type": consts.ContractError, "value": finfo.ID,
-								"len": len(smart.GetVM().Children) - 1}).Error("flush rollback")
-						} else {
-							smart.GetVM().Children = smart.GetVM().Children[:len(smart.GetVM().Children)-1]
-							delete(smart.GetVM().Objects, finfo.Name)
-						}
-					} else {
-						smart.GetVM().Children[finfo.ID] = finfo.Prev
-						smart.GetVM().Objects[finfo.Name] = finfo.Info
-					}
-				}
+				smart.RollbackFlush(flush)
 			}
 			if err == custom.ErrNetworkStopping {
 				return err
@@ -252,6 +284,7 @@ func (b *Block) Play(dbTransaction *model.DbTransaction) error {
 			}
 			// skip this transaction
 			transaction.MarkTransactionBad(t.DbTransaction, t.TxHash, err.Error())
+			b.addTxEvent(t.TxHash, "", err)
 			if t.SysUpdate {
 				if err = syspar.SysUpdate(t.DbTransaction); err != nil {
 					log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("updating syspar")
@@ -287,7 +320,24 @@ func (b *Block) Play(dbTransaction *model.DbTransaction) error {
 			return utils.ErrInfo(err)
 		}
 		b.Notifications = append(b.Notifications, t.Notifications...)
+		b.addTxEvent(t.TxHash, msg, nil)
+	}
+	if err := b.LoadChangedRows(dbTransaction); err != nil {
+		return err
 	}
+	if err := b.checkStateRoot(dbTransaction); err != nil {
+		return err
+	}
+	// the state hash of the checkpoint is calculated by every node before the block is committed,
+	// so the next checkpoint can always be validated
+	b.stateHash = nil
+	if snapshot.IsCheckpoint(&b.Header) {
+		if b.stateHash, err = snapshot.StateHash(dbTransaction); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("calculating checkpoint state hash")
+			return err
+		}
+	}
+	b.InvalidatePages()
 	return nil
 }
 
@@ -310,6 +360,38 @@ func (b *Block) Check() error {
 		return nil
 	}
 
+	if b.Header.Version < syspar.GetMinBlockVersion() {
+		logger.WithFields(log.Fields{"type": consts.InvalidObject, "min_version": syspar.GetMinBlockVersion()}).Error("block version is too low")
+		return utils.ErrInfo(fmt.Errorf("incorrect block version %d", b.Header.Version))
+	}
+
+	// the state root of the generated block is calculated after playing, the match of the
+	// state root with the result of the execution is checked in PlaySafe
+	if b.hasStateRoot() && !b.GenBlock && len(b.Header.StateRoot) != consts.HashSize {
+		logger.WithFields(log.Fields{"type": consts.InvalidObject, "size": len(b.Header.StateRoot)}).Error("incorrect state root")
+		return utils.ErrInfo(fmt.Errorf("incorrect state root"))
+	}
+
+	// the checkpoint block must contain the state hash of the previous checkpoint, the block
+	// isn't validated if this node doesn't know the hash
+	hash, err := snapshot.CheckpointHash(b.Header.BlockID)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.BlockError, "error": err}).Error("getting checkpoint state hash")
+		return utils.ErrInfo(err)
+	}
+	if !bytes.Equal(hash, b.Header.SnapshotHash) {
+		logger.WithFields(log.Fields{"type": consts.InvalidObject, "hash": fmt.Sprintf("%x", b.Header.SnapshotHash),
+			"expected": fmt.Sprintf("%x", hash)}).Error("snapshot hash doesn't match the state")
+		return utils.ErrInfo(fmt.Errorf("incorrect snapshot hash"))
+	}
+
+	if len(b.Header.Commit) > 0 {
+		if _, err := bft.CheckCommit(b.Header.Commit, b.Header.BlockID); err != nil {
+			logger.WithFields(log.Fields{"type": consts.InvalidObject, "error": err}).Error("incorrect commit")
+			return utils.ErrInfo(err)
+		}
+	}
+
 	// is this block too early? Allowable error = error_time
 	if b.PrevHeader != nil {
 		if b.Header.BlockID != b.PrevHeader.BlockID+1 {
@@ -320,7 +402,7 @@ func (b *Block) Check() error {
 		// skip time validation for first block
 		if b.Header.BlockID > 1 {
 
-			exists, err := protocols.NewBlockTimeCounter().BlockForTimeExists(time.Unix(b.Header.Time, 0), int(b.Header.NodePosition))
+			exists, err := protocols.NewBlockTimeCounterAt(b.Header.BlockID).BlockForTimeExists(time.Unix(b.Header.Time, 0), int(b.Header.NodePosition))
 			if err != nil {
 				logger.WithFields(log.Fields{"type": consts.BlockError, "error": err}).Error("calculating block time")
 				return err
@@ -376,7 +458,7 @@ func (b *Block) CheckHash() (bool, error) {
 	}
 	// check block signature
 	if b.PrevHeader != nil {
-		nodePublicKey, err := syspar.GetNodePublicKeyByPosition(b.Header.NodePosition)
+		nodePublicKey, err := syspar.GetNodePublicKeyByPositionAt(b.Header.NodePosition, b.Header.BlockID)
 		if err != nil {
 			return false, utils.ErrInfo(err)
 		}
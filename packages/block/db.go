@@ -135,10 +135,11 @@ func InsertIntoBlockchain(transaction *model.DbTransaction, block *Block) error
 		Time:          block.Header.Time,
 		RollbacksHash: rollbackTxsHash,
 		Tx:            int32(len(block.Transactions)),
+		StateHash:     block.stateHash,
 	}
 	validBlockTime := true
 	if blockID > 1 {
-		exists, err := protocols.NewBlockTimeCounter().BlockForTimeExists(time.Unix(b.Time, 0), int(b.NodePosition))
+		exists, err := protocols.NewBlockTimeCounterAt(blockID).BlockForTimeExists(time.Unix(b.Time, 0), int(b.NodePosition))
 		if err != nil {
 			log.WithFields(log.Fields{"type": consts.BlockError, "error": err}).Error("block validation")
 			return err
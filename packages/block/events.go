@@ -0,0 +1,106 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package block
+
+import (
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/events"
+	"github.com/AplaProject/go-apla/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func (b *Block) addTxEvent(hash []byte, msg string, err error) {
+	e := events.TxEvent{
+		Hash:    string(converter.BinToHex(hash)),
+		BlockID: b.Header.BlockID,
+		Result:  msg,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	b.txEvents = append(b.txEvents, e)
+}
+
+// PublishEvents sends events about the block, its transactions and changed rows to the subscribers,
+// it must be called after the block has been committed
+func (b *Block) PublishEvents() {
+	list := []events.Event{{
+		Type: events.TypeBlock,
+		Data: events.BlockEvent{
+			BlockID:      b.Header.BlockID,
+			Hash:         string(converter.BinToHex(b.Header.Hash)),
+			Time:         b.Header.Time,
+			EcosystemID:  b.Header.EcosystemID,
+			KeyID:        b.Header.KeyID,
+			NodePosition: b.Header.NodePosition,
+			TxCount:      len(b.Transactions),
+		},
+	}}
+
+	for _, e := range b.txEvents {
+		list = append(list, events.Event{Type: events.TypeTx, Data: e})
+	}
+	b.txEvents = nil
+
+	if events.HasSubscribers(events.TypeRow) {
+		rows, err := b.RowEvents(nil, false)
+		if err != nil {
+			b.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting changed rows of block")
+		}
+		list = append(list, rows...)
+	}
+
+	events.Publish(list...)
+}
+
+// RowEvents returns events about rows which have been changed by the block
+func (b *Block) RowEvents(dbTransaction *model.DbTransaction, rollback bool) ([]events.Event, error) {
+	rt := &model.RollbackTx{}
+	rows, err := rt.GetBlockRollbackTransactions(dbTransaction, b.Header.BlockID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]events.Event, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, events.Event{
+			Type: events.TypeRow,
+			Data: events.RowEvent{
+				Table:    row.NameTable,
+				ID:       row.TableID,
+				BlockID:  b.Header.BlockID,
+				TxHash:   string(converter.BinToHex(row.TxHash)),
+				Rollback: rollback,
+			},
+		})
+	}
+	return list, nil
+}
@@ -60,6 +60,7 @@ type Limits struct {
 type Limiter interface {
 	init(*Block)
 	check(*transaction.Transaction, int) error
+	clone() Limiter
 }
 
 type limiterModes struct {
@@ -104,6 +105,16 @@ func NewLimits(b *Block) (limits *Limits) {
 	return
 }
 
+// Clone returns the copy of the current limit information, it's used to restore the limits
+// when the group of transactions is rejected after some of them have been counted
+func (limits *Limits) Clone() *Limits {
+	ret := &Limits{Mode: limits.Mode, Block: limits.Block, Limiters: make([]Limiter, 0, len(limits.Limiters))}
+	for _, limiter := range limits.Limiters {
+		ret.Limiters = append(ret.Limiters, limiter.clone())
+	}
+	return ret
+}
+
 // CheckLimit calls each limiter
 func (limits *Limits) CheckLimit(t *transaction.Transaction) error {
 	for _, limiter := range limits.Limiters {
@@ -130,6 +141,11 @@ func (bl *txMaxLimit) init(b *Block) {
 	bl.Limit = syspar.GetMaxTxCount()
 }
 
+func (bl *txMaxLimit) clone() Limiter {
+	ret := *bl
+	return &ret
+}
+
 func (bl *txMaxLimit) check(t *transaction.Transaction, mode int) error {
 	bl.Count++
 	if bl.Count > bl.Limit {
@@ -152,6 +168,11 @@ func (bl *timeBlockLimit) init(b *Block) {
 	bl.Limit = time.Millisecond * time.Duration(syspar.GetMaxBlockGenerationTime())
 }
 
+func (bl *timeBlockLimit) clone() Limiter {
+	ret := *bl
+	return &ret
+}
+
 func (bl *timeBlockLimit) check(t *transaction.Transaction, mode int) error {
 	if time.Since(bl.Start) < bl.Limit {
 		return nil
@@ -175,6 +196,14 @@ func (bl *txUserLimit) init(b *Block) {
 	bl.Limit = syspar.GetMaxBlockUserTx()
 }
 
+func (bl *txUserLimit) clone() Limiter {
+	ret := &txUserLimit{TxUsers: make(map[int64]int, len(bl.TxUsers)), Limit: bl.Limit}
+	for keyID, count := range bl.TxUsers {
+		ret.TxUsers[keyID] = count
+	}
+	return ret
+}
+
 func (bl *txUserLimit) check(t *transaction.Transaction, mode int) error {
 	var (
 		count int
@@ -207,6 +236,18 @@ func (bl *txUserEcosysLimit) init(b *Block) {
 	bl.TxEcosys = make(map[int64]ecosysLimit)
 }
 
+func (bl *txUserEcosysLimit) clone() Limiter {
+	ret := &txUserEcosysLimit{TxEcosys: make(map[int64]ecosysLimit, len(bl.TxEcosys))}
+	for ecosystemID, val := range bl.TxEcosys {
+		users := make(map[int64]int, len(val.TxUsers))
+		for keyID, count := range val.TxUsers {
+			users[keyID] = count
+		}
+		ret.TxEcosys[ecosystemID] = ecosysLimit{TxUsers: users, Limit: val.Limit}
+	}
+	return ret
+}
+
 func (bl *txUserEcosysLimit) check(t *transaction.Transaction, mode int) error {
 	keyID := t.TxSmart.KeyID
 	ecosystemID := t.TxSmart.EcosystemID
@@ -252,6 +293,11 @@ func (bl *txMaxSize) init(b *Block) {
 	bl.LimitTx = syspar.GetMaxTxSize()
 }
 
+func (bl *txMaxSize) clone() Limiter {
+	ret := *bl
+	return &ret
+}
+
 func (bl *txMaxSize) check(t *transaction.Transaction, mode int) error {
 	size := int64(len(t.TxFullData))
 	if size > bl.LimitTx {
@@ -279,6 +325,11 @@ func (bl *txMaxFuel) init(b *Block) {
 	bl.LimitTx = syspar.GetMaxTxFuel()
 }
 
+func (bl *txMaxFuel) clone() Limiter {
+	ret := *bl
+	return &ret
+}
+
 func (bl *txMaxFuel) check(t *transaction.Transaction, mode int) error {
 	fuel := t.TxFuel
 	if fuel > bl.LimitTx {
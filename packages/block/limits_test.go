@@ -0,0 +1,56 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package block
+
+import (
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/transaction"
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsClone(t *testing.T) {
+	limits := &Limits{Mode: letPreprocess, Limiters: []Limiter{
+		&txUserLimit{TxUsers: make(map[int64]int), Limit: 2},
+		&txMaxSize{LimitBlock: 10, LimitTx: 10},
+	}}
+	t1 := &transaction.Transaction{TxSmart: &tx.SmartContract{Header: tx.Header{KeyID: 1}}, TxFullData: make([]byte, 4)}
+
+	require.NoError(t, limits.CheckLimit(t1))
+	saved := limits.Clone()
+	require.NoError(t, limits.CheckLimit(t1))
+	require.Equal(t, ErrLimitSkip, limits.CheckLimit(t1))
+
+	// the restored limits don't count the transactions checked after cloning
+	limits = saved
+	require.Equal(t, 1, limits.Limiters[0].(*txUserLimit).TxUsers[1])
+	require.Equal(t, int64(4), limits.Limiters[1].(*txMaxSize).Size)
+	require.NoError(t, limits.CheckLimit(t1))
+}
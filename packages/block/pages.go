@@ -0,0 +1,57 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package block
+
+import (
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/pagecache"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadChangedRows reads the rows which have been changed by the block, they are used by InvalidatePages
+func (b *Block) LoadChangedRows(dbTransaction *model.DbTransaction) error {
+	rt := &model.RollbackTx{}
+	rows, err := rt.GetBlockRollbackTransactions(dbTransaction, b.Header.BlockID)
+	if err != nil {
+		b.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting changed rows of block")
+		return err
+	}
+	b.changedRows = rows
+	return nil
+}
+
+// InvalidatePages removes the cached pages which depend on the rows changed by the block.
+// It is called when the block has been played and again after the commit because the pages
+// which have been rendered in between could read the previous data
+func (b *Block) InvalidatePages() {
+	for _, row := range b.changedRows {
+		pagecache.Invalidate(row.NameTable, row.TableID)
+	}
+}
@@ -80,6 +80,15 @@ func MarshallBlock(header *utils.BlockData, trData [][]byte, prev *utils.BlockDa
 	buf.Write(converter.DecToBin(header.EcosystemID, 4))
 	buf.Write(converter.EncodeLenInt64InPlace(header.KeyID))
 	buf.Write(converter.DecToBin(header.NodePosition, 1))
+	if header.Version >= consts.BV_STATE_ROOT {
+		buf.Write(converter.EncodeLengthPlusData(header.StateRoot))
+	}
+	if header.Version >= consts.BV_COMMIT {
+		buf.Write(converter.EncodeLengthPlusData(header.Commit))
+	}
+	if header.Version >= consts.BV_SNAPSHOT_HASH {
+		buf.Write(converter.EncodeLengthPlusData(header.SnapshotHash))
+	}
 	buf.Write(converter.EncodeLengthPlusData(signed))
 
 	// data
@@ -88,6 +97,41 @@ func MarshallBlock(header *utils.BlockData, trData [][]byte, prev *utils.BlockDa
 	return buf.Bytes(), nil
 }
 
+// HeaderHash parses the header of the binary block and calculates the hash of the block.
+// The transactions aren't unmarshalled so the block can be checked without the state.
+func HeaderHash(data []byte, prev *utils.BlockData) (*utils.BlockData, []byte, error) {
+	blockBuffer := bytes.NewBuffer(data)
+	header, err := utils.ParseBlockHeader(blockBuffer, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mrklSlice [][]byte
+	for blockBuffer.Len() > 0 {
+		transactionSize, err := converter.DecodeLengthBuf(blockBuffer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bad block format (%s)", err)
+		}
+		if transactionSize == 0 || blockBuffer.Len() < int(transactionSize) {
+			return nil, nil, fmt.Errorf("bad block format (transaction len is %d)", transactionSize)
+		}
+		dSha256Hash, err := crypto.DoubleHash(blockBuffer.Next(int(transactionSize)))
+		if err != nil {
+			return nil, nil, err
+		}
+		mrklSlice = append(mrklSlice, converter.BinToHex(dSha256Hash))
+	}
+	if len(mrklSlice) == 0 {
+		mrklSlice = append(mrklSlice, []byte("0"))
+	}
+
+	hash, err := crypto.DoubleHash([]byte(header.ForSha(prev, utils.MerkleTreeRoot(mrklSlice))))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &header, hash, nil
+}
+
 func UnmarshallBlock(blockBuffer *bytes.Buffer, firstBlock, fillData bool) (*Block, error) {
 	header, err := utils.ParseBlockHeader(blockBuffer, !firstBlock)
 	if err != nil {
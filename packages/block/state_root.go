@@ -0,0 +1,129 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package block
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/smart"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrStateRoot is returned when the state root of the block doesn't match the result of its execution
+var ErrStateRoot = errors.New("State root doesn't match")
+
+// hasStateRoot returns true if the header contains the state root. The version of the block
+// can't be lower than min_block_version, so the generator can't skip the state root after its activation.
+func (b *Block) hasStateRoot() bool {
+	return b.Header.Version >= consts.BV_STATE_ROOT && b.Header.BlockID > 1
+}
+
+// calcStateRoot returns the hash of the rows which have been changed by the block. The rows
+// are taken in the order of rollback_tx and are marshalled in the same format where data
+// contains the values of the row after the block instead of the previous values. The values
+// are encoded by the types of the columns so the hash doesn't depend on the database settings.
+func (b *Block) calcStateRoot(dbTransaction *model.DbTransaction) ([]byte, error) {
+	rt := &model.RollbackTx{}
+	rollbackTxs, err := rt.GetBlockRollbackTransactions(dbTransaction, b.Header.BlockID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block rollback txs")
+		return nil, err
+	}
+
+	rows := make([]model.RollbackTx, 0, len(rollbackTxs))
+	changed := make(map[string]int)
+	for _, item := range rollbackTxs {
+		key := item.NameTable + "." + item.TableID
+		if i, ok := changed[key]; ok {
+			rows[i].TxHash = item.TxHash
+			continue
+		}
+		changed[key] = len(rows)
+		rows = append(rows, item)
+	}
+
+	buffer := bytes.Buffer{}
+	columns := make(map[string][]model.SnapshotColumn)
+	for _, row := range rows {
+		// the system records describe the changes of the schema, they are taken as is
+		if row.NameTable != smart.SysName {
+			if _, ok := columns[row.NameTable]; !ok {
+				if columns[row.NameTable], err = model.GetSnapshotColumns(dbTransaction, row.NameTable); err != nil {
+					log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": row.NameTable}).Error("getting table columns")
+					return nil, err
+				}
+			}
+			values, err := model.GetCanonicalRow(dbTransaction, row.NameTable, columns[row.NameTable], row.TableID)
+			if err != nil {
+				log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": row.NameTable}).Error("getting changed row")
+				return nil, err
+			}
+			data, err := json.Marshal(values)
+			if err != nil {
+				log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling changed row")
+				return nil, err
+			}
+			row.Data = string(data)
+		}
+		rowBytes, err := json.Marshal(row)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling state row to json")
+			return nil, err
+		}
+		buffer.Write(rowBytes)
+	}
+	return crypto.Hash(buffer.Bytes())
+}
+
+// checkStateRoot compares the state root of the header with the result of the block execution
+func (b *Block) checkStateRoot(dbTransaction *model.DbTransaction) error {
+	if !b.hasStateRoot() || b.GenBlock {
+		return nil
+	}
+	root, err := b.calcStateRoot(dbTransaction)
+	if err != nil {
+		return err
+	}
+	return b.matchStateRoot(root)
+}
+
+func (b *Block) matchStateRoot(root []byte) error {
+	if !bytes.Equal(root, b.Header.StateRoot) {
+		b.GetLogger().WithFields(log.Fields{"type": consts.BlockError, "state_root": fmt.Sprintf("%x", root),
+			"expected": fmt.Sprintf("%x", b.Header.StateRoot)}).Error("state root doesn't match")
+		return ErrStateRoot
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package block
+
+import (
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStateRoot(t *testing.T) {
+	root := make([]byte, consts.HashSize)
+	root[0] = 1
+	b := &Block{Header: utils.BlockData{BlockID: 10, Version: consts.BV_STATE_ROOT, StateRoot: root}}
+
+	require.NoError(t, b.matchStateRoot(root))
+	require.Equal(t, ErrStateRoot, b.matchStateRoot(make([]byte, consts.HashSize)))
+	require.Equal(t, ErrStateRoot, b.matchStateRoot(nil))
+
+	// the blocks without the state root and the generated blocks are not checked
+	for _, item := range []*Block{
+		{Header: utils.BlockData{BlockID: 10, Version: consts.BV_STATE_ROOT - 1}},
+		{Header: utils.BlockData{BlockID: 1, Version: consts.BV_STATE_ROOT}},
+		{Header: utils.BlockData{BlockID: 10, Version: consts.BV_STATE_ROOT}, GenBlock: true},
+	} {
+		require.NoError(t, item.checkStateRoot(nil))
+	}
+}
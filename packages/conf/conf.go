@@ -54,6 +54,39 @@ func (h HostPort) Str() string {
 	return fmt.Sprintf("%s:%d", h.Host, h.Port)
 }
 
+// TCPServerConfig represents parameters of the node-to-node TCP protocol
+type TCPServerConfig struct {
+	Host     string // ipaddr, hostname, or "0.0.0.0"
+	Port     int    // must be in range 1..65535
+	Secure   bool   // the connections to other nodes are encrypted with TLS and authenticated by the node key
+	Strict   bool   // the privileged requests are accepted only from the authenticated full nodes, the legacy protocol is off
+	Announce bool   // the address of the node is shared with other nodes by the peers gossip
+}
+
+// Str converts host and port to string format
+func (t TCPServerConfig) Str() string {
+	return HostPort{Host: t.Host, Port: t.Port}.Str()
+}
+
+// SnapshotConfig represents parameters of the state snapshots
+type SnapshotConfig struct {
+	Interval int64 // the snapshot is created at every block which ID is divisible by Interval rounded up to consts.SnapshotCheckpoint, 0 disables snapshots
+	Keep     int   // count of the stored snapshots
+	FastSync bool  // the empty node loads the state from the snapshot of other nodes instead of playing all blocks
+}
+
+// ReplicaConfig represents parameters of the read-only replica
+type ReplicaConfig struct {
+	Validators  []string // TCP addresses of the nodes receiving the transactions, all full nodes by default
+	KeepHistory bool     // rollback_tx is never pruned so the history of the rows is available
+}
+
+// MempoolConfig represents limits of the pending transactions
+type MempoolConfig struct {
+	MaxSize   int // maximal count of the pending transactions, 0 is unlimited
+	MaxPerKey int // maximal count of the pending transactions of one key_id, 0 is unlimited
+}
+
 // DBConfig database connection parameters
 type DBConfig struct {
 	Name            string
@@ -103,6 +136,20 @@ type TokenMovementConfig struct {
 	Subject  string
 }
 
+// JWTKey is a named secret for signing api tokens
+type JWTKey struct {
+	ID     string
+	Secret string
+}
+
+// JWTConfig represents parameters of api tokens signing
+type JWTConfig struct {
+	Algorithm  string   // HS256 or ES256, ES256 signs tokens with the node private key
+	SigningKey string   // ID of the key used for new tokens, the last key by default
+	KeysFile   string   // json file with the list of keys, relative paths are resolved against KeysDir
+	Keys       []JWTKey // all keys that are accepted for checking tokens
+}
+
 // GlobalConfig is storing all startup config as global struct
 type GlobalConfig struct {
 	KeyID        int64  `toml:"-"`
@@ -123,15 +170,21 @@ type GlobalConfig struct {
 	HTTPServerMaxBodySize int64
 
 	MaxPageGenerationTime int64 // in milliseconds
+	PageCacheSize         int   // count of the cached pages, 0 disables the cache
+	Debugger              bool  // the trace and the debugger of contracts are available for the local requests
 
-	TCPServer HostPort
+	TCPServer TCPServerConfig
 	HTTP      HostPort
+	Snapshot  SnapshotConfig
+	Replica   ReplicaConfig
+	Mempool   MempoolConfig
 
 	DB            DBConfig
 	StatsD        StatsDConfig
 	Centrifugo    CentrifugoConfig
 	Log           LogConfig
 	TokenMovement TokenMovementConfig
+	JWT           JWTConfig
 
 	NodesAddr []string
 }
@@ -290,6 +343,11 @@ func (c GlobalConfig) IsSupportingOBS() bool {
 	return RunMode(c.OBSMode).IsSupportingOBS()
 }
 
+// IsReplica check running mode
+func (c GlobalConfig) IsReplica() bool {
+	return RunMode(c.OBSMode).IsReplica()
+}
+
 // IsNode check running mode
 func (c GlobalConfig) IsNode() bool {
 	return RunMode(c.OBSMode).IsNode()
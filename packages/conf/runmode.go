@@ -39,6 +39,9 @@ const obs RunMode = "OBS"
 // OBS const label for running mode
 const node RunMode = "NONE"
 
+// replica const label for running mode
+const replica RunMode = "Replica"
+
 // IsOBSMaster returns true if mode equal obsMaster
 func (rm RunMode) IsOBSMaster() bool {
 	return rm == obsMaster
@@ -54,6 +57,11 @@ func (rm RunMode) IsNode() bool {
 	return rm == node
 }
 
+// IsReplica returns true if mode equal replica
+func (rm RunMode) IsReplica() bool {
+	return rm == replica
+}
+
 // IsSupportingOBS returns true if mode support obs
 func (rm RunMode) IsSupportingOBS() bool {
 	return rm.IsOBS() || rm.IsOBSMaster()
@@ -29,6 +29,7 @@
 package syspar
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -96,6 +97,19 @@ const (
 	Test = `test`
 	// PrivateBlockchain is value defining blockchain mode
 	PrivateBlockchain = `private_blockchain`
+	// StrictContracts is the version of the frozen set of the lint rules whose errors reject
+	// the contracts, 0 turns the check off
+	StrictContracts = `strict_contracts`
+	// BFTFinality equals true or 1 if the blocks are finalized by the commit round of the validators
+	BFTFinality = `bft_finality`
+	// EpochLength is the count of blocks in the epoch. The changes of the positions of full nodes
+	// become active at the first block of the next epoch
+	EpochLength = `epoch_length`
+	// MaxDBFindCost is the maximum query cost of DBFind with aggregates or joins, 0 is unlimited
+	MaxDBFindCost = `max_dbfind_cost`
+	// MinBlockVersion is the minimum version of the blocks after the block which has changed it,
+	// so the features of the versions can't be skipped by the generator
+	MinBlockVersion = `min_block_version`
 
 	// CostDefault is the default maximum cost of F
 	CostDefault = int64(20000000)
@@ -123,16 +137,31 @@ func SysUpdate(dbTransaction *model.DbTransaction) error {
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting all system parameters")
 		return err
 	}
+	sets, err := model.GetValidatorSets(dbTransaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting validator sets")
+		return err
+	}
+	infoBlock := &model.InfoBlock{}
+	if _, err = infoBlock.GetTransaction(dbTransaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		return err
+	}
 	mutex.Lock()
 	defer mutex.Unlock()
 	for _, param := range systemParameters {
 		cache[param.Name] = param.Value
 	}
 	if len(cache[FullNodes]) > 0 {
-		if err = updateNodes(); err != nil {
+		if proposedNodes, err = parseNodes(cache[FullNodes]); err != nil {
 			return err
 		}
 	}
+	if err = loadValidatorSets(sets); err != nil {
+		return err
+	}
+	lastBlockID = infoBlock.BlockID
+	updateNodes()
 	getParams := func(name string) (map[int64]string, error) {
 		res := make(map[int64]string)
 		if len(cache[name]) > 0 {
@@ -157,21 +186,14 @@ func SysUpdate(dbTransaction *model.DbTransaction) error {
 	return err
 }
 
-func updateNodes() (err error) {
-	nodes = make(map[int64]*FullNode)
-	nodesByPosition = make([]*FullNode, 0)
-
-	items := make([]*FullNode, 0)
-	if len(cache[FullNodes]) > 0 {
-		err = json.Unmarshal([]byte(cache[FullNodes]), &items)
-
-		if err != nil {
-			log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err, "v": cache[FullNodes]}).Error("unmarshalling full nodes from json")
-			return err
-		}
+// updateNodes switches the nodes to the validator set which is active at the next block
+func updateNodes() {
+	items := nodesAt(lastBlockID + 1)
+	if len(items) == 0 {
+		return
 	}
-
-	nodesByPosition = []*FullNode{}
+	nodes = make(map[int64]*FullNode)
+	nodesByPosition = make([]*FullNode, 0, len(items))
 	for i := 0; i < len(items); i++ {
 		nodes[items[i].KeyID] = items[i]
 
@@ -179,8 +201,6 @@ func updateNodes() (err error) {
 			nodesByPosition = append(nodesByPosition, items[i])
 		}
 	}
-
-	return nil
 }
 
 // addFullNodeKeys adds node by keys to list of nodes
@@ -284,6 +304,19 @@ func GetNodeByHost(host string) (FullNode, error) {
 	return FullNode{}, fmt.Errorf("incorrect host")
 }
 
+// GetNodeByPublicKey returns the full node with the public key or nil if there is not such node
+func GetNodeByPublicKey(publicKey []byte) *FullNode {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	publicKey = crypto.CutPub(publicKey)
+	for _, n := range nodes {
+		if bytes.Equal(crypto.CutPub(n.PublicKey), publicKey) {
+			return n
+		}
+	}
+	return nil
+}
+
 // GetNodeHostByPosition is retrieving node host by position
 func GetNodeHostByPosition(position int64) (string, error) {
 	mutex.RLock()
@@ -360,6 +393,11 @@ func GetMaxBlockFuel() int64 {
 	return converter.StrToInt64(SysString(MaxBlockFuel))
 }
 
+// GetMaxDBFindCost returns the maximum query cost of DBFind with aggregates or joins
+func GetMaxDBFindCost() int64 {
+	return SysInt64(MaxDBFindCost)
+}
+
 // GetMaxTxFuel is returns max tx fuel
 func GetMaxTxFuel() int64 {
 	return converter.StrToInt64(SysString(MaxTxFuel))
@@ -495,6 +533,22 @@ func IsPrivateBlockchain() bool {
 	return len(par) > 0 && par != `0` && par != `false`
 }
 
+// GetStrictContracts returns the value of strict_contracts system parameter
+func GetStrictContracts() int64 {
+	return SysInt64(StrictContracts)
+}
+
+// GetMinBlockVersion returns the value of min_block_version system parameter
+func GetMinBlockVersion() int {
+	return SysInt(MinBlockVersion)
+}
+
+// IsBFTFinality returns the value of bft_finality system parameter
+func IsBFTFinality() bool {
+	par := SysString(BFTFinality)
+	return len(par) > 0 && par != `0` && par != `false`
+}
+
 func GetMaxCost() int64 {
 	cost := GetMaxTxFuel()
 	if cost == 0 {
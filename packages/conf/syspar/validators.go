@@ -0,0 +1,307 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package syspar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ValidatorSet is the list of the full nodes which is active since the block Activation
+type ValidatorSet struct {
+	Activation int64      `json:"activation"`
+	BlockID    int64      `json:"block_id"`
+	Nodes      []FullNode `json:"nodes"`
+}
+
+type validatorSet struct {
+	activation int64
+	blockID    int64
+	nodes      []*FullNode
+}
+
+var (
+	// lastBlockID is the last block of the blockchain
+	lastBlockID int64
+	// proposedNodes is the latest value of full_nodes
+	proposedNodes = make([]*FullNode, 0)
+	// validatorSets is the history of full_nodes in the order of activation
+	validatorSets = make([]*validatorSet, 0)
+)
+
+func parseNodes(value string) ([]*FullNode, error) {
+	items := make([]*FullNode, 0)
+	if len(value) > 0 {
+		if err := json.Unmarshal([]byte(value), &items); err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err, "v": value}).Error("unmarshalling full nodes from json")
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func loadValidatorSets(sets []model.ValidatorSet) error {
+	validatorSets = make([]*validatorSet, 0, len(sets))
+	for _, item := range sets {
+		items, err := parseNodes(item.Value)
+		if err != nil {
+			return err
+		}
+		validatorSets = append(validatorSets, &validatorSet{
+			activation: item.Activation,
+			blockID:    item.BlockID,
+			nodes:      items,
+		})
+	}
+	return nil
+}
+
+// firstBlockNodes returns the node of the first block which is active while full_nodes is empty
+func firstBlockNodes() []*FullNode {
+	if firstBlockData == nil {
+		return nil
+	}
+	return []*FullNode{{
+		KeyID:     crypto.Address(firstBlockData.PublicKey),
+		PublicKey: firstBlockData.NodePublicKey,
+	}}
+}
+
+// nodesAt returns all full nodes of the validator set which is active at the block
+func nodesAt(block int64) []*FullNode {
+	for i := len(validatorSets) - 1; i >= 0; i-- {
+		if validatorSets[i].activation <= block {
+			if len(validatorSets[i].nodes) == 0 {
+				return firstBlockNodes()
+			}
+			return validatorSets[i].nodes
+		}
+	}
+	return proposedNodes
+}
+
+// activeNodesAt returns the nodes with stopped = false of the validator set which is active at the block
+func activeNodesAt(block int64) []*FullNode {
+	if len(validatorSets) == 0 || block == lastBlockID+1 {
+		return nodesByPosition
+	}
+	active := make([]*FullNode, 0)
+	for _, item := range nodesAt(block) {
+		if !item.Stopped {
+			active = append(active, item)
+		}
+	}
+	if len(active) == 0 {
+		return nodesByPosition
+	}
+	return active
+}
+
+func copyNodes(items []*FullNode) []FullNode {
+	result := make([]FullNode, 0, len(items))
+	for _, node := range items {
+		result = append(result, *node)
+	}
+	return result
+}
+
+// GetEpochLength returns the count of blocks in the epoch of the validator set
+func GetEpochLength() int64 {
+	return SysInt64(EpochLength)
+}
+
+// EpochActivation returns the block which activates the changes of the validator set
+// proposed in the block. It is the first block of the next epoch or the next block
+// if epochs are disabled
+func EpochActivation(block int64) int64 {
+	length := GetEpochLength()
+	if length <= 0 {
+		return block + 1
+	}
+	return ((block-1)/length+1)*length + 1
+}
+
+// SetBlockID sets the last block of the blockchain and switches the validator set to
+// the one which is active at the next block. The sets proposed after the block are
+// removed as rolled back
+func SetBlockID(block int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	lastBlockID = block
+	sets := validatorSets[:0]
+	for _, item := range validatorSets {
+		if item.blockID <= block {
+			sets = append(sets, item)
+		}
+	}
+	validatorSets = sets
+	updateNodes()
+}
+
+// HasValidatorSets returns true if the changes of full_nodes are recorded with their activation
+func HasValidatorSets() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(validatorSets) > 0
+}
+
+// HasPendingValidatorSets returns true if there are validator sets which become active
+// after the block
+func HasPendingValidatorSets(block int64) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(validatorSets) > 0 && validatorSets[len(validatorSets)-1].activation > block
+}
+
+// IsSameValidators returns true if the nodes have the same positions in the generation
+// queue as the validator set which is active at the block
+func IsSameValidators(items []FullNode, block int64) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	active := activeNodesAt(block)
+	var i int
+	for _, item := range items {
+		if item.Stopped {
+			continue
+		}
+		if i >= len(active) || active[i].KeyID != item.KeyID ||
+			!bytes.Equal(crypto.CutPub(active[i].PublicKey), crypto.CutPub(item.PublicKey)) {
+			return false
+		}
+		i++
+	}
+	return i == len(active)
+}
+
+// GetNodesAt returns the active full nodes at the block
+func GetNodesAt(block int64) []FullNode {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return copyNodes(activeNodesAt(block))
+}
+
+// GetProposedNodes returns the active full nodes of the latest value of full_nodes
+func GetProposedNodes() []FullNode {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if len(proposedNodes) == 0 {
+		return copyNodes(nodesByPosition)
+	}
+	result := make([]FullNode, 0, len(proposedNodes))
+	for _, node := range proposedNodes {
+		if !node.Stopped {
+			result = append(result, *node)
+		}
+	}
+	return result
+}
+
+// GetCountOfActiveNodesAt is count of nodes with stopped = false at the block
+func GetCountOfActiveNodesAt(block int64) int64 {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return int64(len(activeNodesAt(block)))
+}
+
+// GetNodePositionByKeyIDAt is returning node position by key id at the block
+func GetNodePositionByKeyIDAt(keyID, block int64) (int64, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	for i, item := range activeNodesAt(block) {
+		if item.KeyID == keyID {
+			return int64(i), nil
+		}
+	}
+	for _, item := range nodesAt(block) {
+		if item.KeyID == keyID && item.Stopped {
+			return 0, errNodeDisabled
+		}
+	}
+	return 0, fmt.Errorf("Incorrect keyID")
+}
+
+// GetNodePublicKeyByPositionAt is retrieving node public key by position at the block
+func GetNodePublicKeyByPositionAt(position, block int64) ([]byte, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	active := activeNodesAt(block)
+	if position < 0 || int64(len(active)) <= position {
+		return nil, fmt.Errorf("incorrect position")
+	}
+	return active[position].PublicKey, nil
+}
+
+// GetCurrentValidatorSet returns the validator set which is active at the next block
+func GetCurrentValidatorSet() ValidatorSet {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	set := ValidatorSet{Nodes: copyNodes(nodesByPosition)}
+	for i := len(validatorSets) - 1; i >= 0; i-- {
+		if validatorSets[i].activation <= lastBlockID+1 {
+			set.Activation = validatorSets[i].activation
+			set.BlockID = validatorSets[i].blockID
+			break
+		}
+	}
+	return set
+}
+
+// GetPendingValidatorSets returns the validator sets which become active after the next block
+func GetPendingValidatorSets() []ValidatorSet {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	sets := make([]ValidatorSet, 0)
+	for _, item := range validatorSets {
+		if item.activation <= lastBlockID+1 {
+			continue
+		}
+		set := ValidatorSet{Activation: item.activation, BlockID: item.blockID,
+			Nodes: make([]FullNode, 0, len(item.nodes))}
+		for _, node := range item.nodes {
+			if !node.Stopped {
+				set.Nodes = append(set.Nodes, *node)
+			}
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
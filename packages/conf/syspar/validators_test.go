@@ -0,0 +1,156 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package syspar
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPublicKey = "c1a9e7b2fb8cea2a272e183c3e27e2d59a3ebe613f51873a46885c9201160bd263ef43b583b631edd1284ab42483712fd2ccc40864fe9368115ceeee47a7c7d0"
+
+func testNodes(keys ...int64) string {
+	var list string
+	for i, key := range keys {
+		if i > 0 {
+			list += ","
+		}
+		list += fmt.Sprintf(`{"tcp_address":"127.0.0.1:%d", "api_address":"https://127.0.0.1", "key_id":"%d", "public_key":"%s"}`,
+			7078+i, key, testPublicKey)
+	}
+	return "[" + list + "]"
+}
+
+func positions(nodes []FullNode) []int64 {
+	keys := make([]int64, 0, len(nodes))
+	for _, node := range nodes {
+		keys = append(keys, node.KeyID)
+	}
+	return keys
+}
+
+func TestEpochActivation(t *testing.T) {
+	defer func() { cache[EpochLength] = `` }()
+
+	cache[EpochLength] = `0`
+	assert.Equal(t, int64(11), EpochActivation(10))
+
+	cache[EpochLength] = `10`
+	for block, activation := range map[int64]int64{1: 11, 9: 11, 10: 11, 11: 21, 20: 21, 21: 31} {
+		assert.Equal(t, activation, EpochActivation(block), "block %d", block)
+	}
+}
+
+func TestValidatorSets(t *testing.T) {
+	defer func() {
+		require.NoError(t, loadValidatorSets(nil))
+		proposedNodes = make([]*FullNode, 0)
+		SetBlockID(0)
+	}()
+
+	var err error
+	proposedNodes, err = parseNodes(testNodes(3, 2, 1))
+	require.NoError(t, err)
+	require.NoError(t, loadValidatorSets([]model.ValidatorSet{
+		{ID: 1, Value: testNodes(1, 2), Activation: 0, BlockID: 5},
+		{ID: 2, Value: testNodes(2, 1), Activation: 6, BlockID: 5},
+		{ID: 3, Value: testNodes(3, 2, 1), Activation: 11, BlockID: 7},
+	}))
+
+	SetBlockID(7)
+	assert.Equal(t, []int64{2, 1}, positions(GetNodes()))
+	assert.Equal(t, []int64{1, 2}, positions(GetNodesAt(5)))
+	assert.Equal(t, []int64{2, 1}, positions(GetNodesAt(10)))
+	assert.Equal(t, []int64{3, 2, 1}, positions(GetNodesAt(11)))
+	assert.Equal(t, []int64{3, 2, 1}, positions(GetProposedNodes()))
+	assert.Equal(t, int64(2), GetCountOfActiveNodesAt(10))
+	assert.Equal(t, int64(3), GetCountOfActiveNodesAt(11))
+
+	pos, err := GetNodePositionByKeyIDAt(1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pos)
+	pos, err = GetNodePositionByKeyIDAt(1, 11)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pos)
+	_, err = GetNodePositionByKeyIDAt(3, 10)
+	assert.Error(t, err)
+	_, err = GetNodePublicKeyByPositionAt(2, 10)
+	assert.Error(t, err)
+
+	assert.Equal(t, int64(6), GetCurrentValidatorSet().Activation)
+	pending := GetPendingValidatorSets()
+	require.Len(t, pending, 1)
+	assert.Equal(t, int64(11), pending[0].Activation)
+	assert.True(t, HasPendingValidatorSets(8))
+	assert.False(t, HasPendingValidatorSets(11))
+
+	var same []FullNode
+	for _, node := range nodesAt(8) {
+		same = append(same, *node)
+	}
+	assert.True(t, IsSameValidators(same, 8))
+	assert.False(t, IsSameValidators(same[:1], 8))
+	assert.False(t, IsSameValidators(same, 11))
+
+	SetBlockID(10)
+	assert.Equal(t, []int64{3, 2, 1}, positions(GetNodes()))
+	assert.Empty(t, GetPendingValidatorSets())
+
+	// the set proposed in the rolled back block is removed
+	SetBlockID(6)
+	assert.Equal(t, []int64{2, 1}, positions(GetNodes()))
+	assert.Equal(t, []int64{2, 1}, positions(GetNodesAt(11)))
+}
+
+func TestFirstBlockValidatorSet(t *testing.T) {
+	defer func() {
+		require.NoError(t, loadValidatorSets(nil))
+		firstBlockData = nil
+		SetBlockID(0)
+	}()
+
+	publicKey, err := hex.DecodeString(testPublicKey)
+	require.NoError(t, err)
+	firstBlockData = &consts.FirstBlock{PublicKey: publicKey, NodePublicKey: publicKey}
+	// the empty value of full_nodes means the node of the first block
+	require.NoError(t, loadValidatorSets([]model.ValidatorSet{
+		{ID: 1, Value: "", Activation: 0, BlockID: 5},
+		{ID: 2, Value: testNodes(2, 1), Activation: 6, BlockID: 5},
+	}))
+	SetBlockID(7)
+	assert.Equal(t, []int64{crypto.Address(publicKey)}, positions(GetNodesAt(5)))
+	assert.Equal(t, []int64{2, 1}, positions(GetNodesAt(6)))
+}
@@ -33,12 +33,29 @@ import (
 )
 
 // VERSION is current version
-const VERSION = "1.2.7"
+const VERSION = "1.3.4"
 
 const BV_ROLLBACK_HASH = 2
 
+// BV_STATE_ROOT is the version of the blocks which headers contain the hash of the changed rows
+const BV_STATE_ROOT = 3
+
+// BV_COMMIT is the version of the blocks which headers can carry the commit of the finalized block
+const BV_COMMIT = 4
+
+// MaxCommitSize is the maximum size of the commit in the block header
+const MaxCommitSize = 65536
+
+// BV_SNAPSHOT_HASH is the version of the blocks which headers of the checkpoints contain
+// the state hash of the previous checkpoint
+const BV_SNAPSHOT_HASH = 5
+
+// SnapshotCheckpoint is the interval of the checkpoint blocks. The header of the checkpoint
+// commits the state hash after the previous checkpoint so the snapshots can be verified.
+const SnapshotCheckpoint = 1000
+
 // BLOCK_VERSION is block version
-const BLOCK_VERSION = BV_ROLLBACK_HASH
+const BLOCK_VERSION = BV_SNAPSHOT_HASH
 
 // NETWORK_ID is id of network
 const NETWORK_ID = 1
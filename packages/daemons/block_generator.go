@@ -33,6 +33,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/AplaProject/go-apla/packages/bft"
 	"github.com/AplaProject/go-apla/packages/block"
 	"github.com/AplaProject/go-apla/packages/conf"
 	"github.com/AplaProject/go-apla/packages/conf/syspar"
@@ -41,6 +42,7 @@ import (
 	"github.com/AplaProject/go-apla/packages/notificator"
 	"github.com/AplaProject/go-apla/packages/protocols"
 	"github.com/AplaProject/go-apla/packages/service"
+	"github.com/AplaProject/go-apla/packages/snapshot"
 	"github.com/AplaProject/go-apla/packages/transaction"
 	"github.com/AplaProject/go-apla/packages/utils"
 
@@ -67,14 +69,22 @@ func BlockGenerator(ctx context.Context, d *daemon) error {
 	DBLock()
 	defer DBUnlock()
 
+	prevBlock := &model.InfoBlock{}
+	_, err = prevBlock.Get()
+	if err != nil {
+		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting previous block")
+		return err
+	}
+
 	// wee need fresh myNodePosition after locking
-	nodePosition, err = syspar.GetNodePositionByKeyID(conf.Config.KeyID)
+	// the position is taken from the validator set which is active at the next block
+	nodePosition, err = syspar.GetNodePositionByKeyIDAt(conf.Config.KeyID, prevBlock.BlockID+1)
 	if err != nil {
 		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting node position by key id")
 		return err
 	}
 
-	btc := protocols.NewBlockTimeCounter()
+	btc := protocols.NewBlockTimeCounterAt(prevBlock.BlockID + 1)
 	at := time.Now()
 
 	if exists, err := btc.BlockForTimeExists(at, int(nodePosition)); exists || err != nil {
@@ -98,12 +108,6 @@ func BlockGenerator(ctx context.Context, d *daemon) error {
 	}
 
 	done := time.After(endTime.Sub(time.Now()))
-	prevBlock := &model.InfoBlock{}
-	_, err = prevBlock.Get()
-	if err != nil {
-		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting previous block")
-		return err
-	}
 
 	NodePrivateKey, NodePublicKey, err := utils.GetNodeKeys()
 	if err != nil || len(NodePrivateKey) < 1 {
@@ -139,6 +143,14 @@ func BlockGenerator(ctx context.Context, d *daemon) error {
 		NodePosition: nodePosition,
 		Version:      consts.BLOCK_VERSION,
 	}
+	if header.SnapshotHash, err = snapshot.CheckpointHash(header.BlockID); err != nil {
+		return err
+	}
+	if bft.IsEnabled() {
+		if header.Commit, err = bft.CommitToCarry(); err != nil {
+			return err
+		}
+	}
 
 	pb := &utils.BlockData{
 		BlockID:       prevBlock.BlockID,
@@ -226,43 +238,88 @@ func processTransactions(logger *log.Entry, done <-chan time.Time) ([]*model.Tra
 		close(attemptCountChan)
 	}()
 
+	// checkTx returns true if the transaction can be added to the block
+	checkTx := func(i int, txItem *model.Transaction) bool {
+		bufTransaction := bytes.NewBuffer(txItem.Data)
+		p, err := transaction.UnmarshallTransaction(bufTransaction, true)
+		if err != nil {
+			if p != nil {
+				txBadChan <- badTxStruct{hash: p.TxHash, msg: err.Error(), keyID: p.TxHeader.KeyID}
+			}
+			return false
+		}
+
+		if err := p.Check(time.Now().Unix(), false); err != nil {
+			txBadChan <- badTxStruct{hash: p.TxHash, msg: err.Error(), keyID: p.TxHeader.KeyID}
+			return false
+		}
+
+		if p.TxSmart != nil {
+			err = limits.CheckLimit(p)
+			if err == block.ErrLimitStop && i > 0 {
+				attemptCountChan <- p.TxHash
+				return false
+			} else if err != nil {
+				if err == block.ErrLimitSkip {
+					attemptCountChan <- p.TxHash
+				} else {
+					txBadChan <- badTxStruct{hash: p.TxHash, msg: err.Error(), keyID: p.TxHeader.KeyID}
+				}
+				return false
+			}
+		}
+		return true
+	}
+
 	// Checks preprocessing count limits
 	txList := make([]*model.Transaction, 0, len(trs))
-	for i, txItem := range trs {
+	for i := 0; i < len(trs); {
 		select {
 		case <-done:
 			return txList, err
 		default:
-			bufTransaction := bytes.NewBuffer(txItem.Data)
-			p, err := transaction.UnmarshallTransaction(bufTransaction, true)
-			if err != nil {
-				if p != nil {
-					txBadChan <- badTxStruct{hash: p.TxHash, msg: err.Error(), keyID: p.TxHeader.KeyID}
-				}
-				continue
-			}
-
-			if err := p.Check(time.Now().Unix(), false); err != nil {
-				txBadChan <- badTxStruct{hash: p.TxHash, msg: err.Error(), keyID: p.TxHeader.KeyID}
-				continue
-			}
-
-			if p.TxSmart != nil {
-				err = limits.CheckLimit(p)
-				if err == block.ErrLimitStop && i > 0 {
-					attemptCountChan <- p.TxHash
-					break
-				} else if err != nil {
-					if err == block.ErrLimitSkip {
-						attemptCountChan <- p.TxHash
-					} else {
-						txBadChan <- badTxStruct{hash: p.TxHash, msg: err.Error(), keyID: p.TxHeader.KeyID}
-					}
-					continue
-				}
+		}
+		// transactions of a batch are added to the block all together or not added at all
+		group := nextTxGroup(trs, i)
+		first := i
+		i += len(group)
+		if len(group[0].Batch) > 0 && !isBatchReady(logger, group) {
+			continue
+		}
+		// the limits counted for the rejected group are released
+		saved := limits.Clone()
+		ok := true
+		for j, txItem := range group {
+			if ok = checkTx(first+j, txItem); !ok {
+				break
 			}
-			txList = append(txList, trs[i])
+		}
+		if ok {
+			txList = append(txList, group...)
+		} else {
+			limits = saved
 		}
 	}
 	return txList, nil
 }
+
+// nextTxGroup returns the transaction at the position or all transactions of its batch
+func nextTxGroup(trs []*model.Transaction, i int) []*model.Transaction {
+	end := i + 1
+	if len(trs[i].Batch) > 0 {
+		for end < len(trs) && bytes.Equal(trs[end].Batch, trs[i].Batch) {
+			end++
+		}
+	}
+	return trs[i:end]
+}
+
+// isBatchReady returns true if all transactions of the batch have been verified and loaded
+func isBatchReady(logger *log.Entry, group []*model.Transaction) bool {
+	verified, queued, err := model.GetBatchCounts(group[0].Batch)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting batch counts")
+		return false
+	}
+	return queued == 0 && verified == int64(len(group))
+}
@@ -37,6 +37,7 @@ import (
 
 	"github.com/AplaProject/go-apla/packages/block"
 	"github.com/AplaProject/go-apla/packages/network"
+	"github.com/AplaProject/go-apla/packages/network/peers"
 	"github.com/AplaProject/go-apla/packages/network/tcpclient"
 
 	"github.com/AplaProject/go-apla/packages/conf"
@@ -116,6 +117,12 @@ func blocksCollection(ctx context.Context, d *daemon) (err error) {
 		service.NodeDoneUpdatingBlockchain()
 	}()
 
+	if needFastSync(infoBlock.BlockID) {
+		if err := FastSync(ctx, d.logger); err != nil {
+			d.logger.WithFields(log.Fields{"error": err}).Warn("fast sync failed, playing all blocks")
+		}
+	}
+
 	// update our chain till maxBlockID from the host
 	return UpdateChain(ctx, d, host, maxBlockID)
 }
@@ -278,6 +285,7 @@ func banNode(host string, block *block.Block, err error) {
 	}
 
 	log.WithFields(log.Fields{"reason": reason, "host": host, "block_id": blockId, "block_time": blockTime}).Debug("ban node")
+	peers.GetManager().RecordBadBlock(host)
 
 	n, err := syspar.GetNodeByHost(host)
 	if err != nil {
@@ -300,15 +308,34 @@ func getHostWithMaxID(ctx context.Context, logger *log.Entry) (host string, maxB
 		logger.WithFields(log.Fields{"error": err}).Error("on filtering banned hosts")
 	}
 
+	// the max block is taken from the full nodes only, the discovered peers can claim any block
+	hosts = peers.GetManager().Filter(hosts)
 	host, maxBlockID, err = tcpclient.HostWithMaxBlock(ctx, hosts)
 	if len(hosts) == 0 || err == tcpclient.ErrNodesUnavailable {
 		hosts = conf.GetNodesAddr()
 		return tcpclient.HostWithMaxBlock(ctx, hosts)
 	}
-
+	if err == nil && len(host) > 0 {
+		host = betterPeer(host, maxBlockID)
+	}
 	return
 }
 
+// betterPeer returns the discovered peer which shares the load of the full nodes. The peer is
+// used if it has the better score than the host and has the max block of the full nodes.
+func betterPeer(host string, maxBlockID int64) string {
+	manager := peers.GetManager()
+	discovered := manager.Filter(manager.Discovered())
+	manager.Sort(discovered)
+	if len(discovered) == 0 || manager.Score(discovered[0]) <= manager.Score(host) {
+		return host
+	}
+	if blockID, err := tcpclient.GetMaxBlockID(discovered[0]); err != nil || blockID < maxBlockID {
+		return host
+	}
+	return discovered[0]
+}
+
 // GetBlocks is returning blocks
 func GetBlocks(ctx context.Context, blockID int64, host string) error {
 	blocks, err := getBlocks(ctx, blockID, host)
@@ -331,6 +358,9 @@ func GetBlocks(ctx context.Context, blockID int64, host string) error {
 	if len(blocks) > 0 {
 		blockID = blocks[len(blocks)-1].Header.BlockID
 	}
+	if err = rollback.CheckFinality(blockID - 1); err != nil {
+		return err
+	}
 
 	// we have the slice of blocks for applying
 	// first of all we should rollback old blocks
@@ -391,7 +421,7 @@ func getBlocks(ctx context.Context, blockID int64, host string) ([]*block.Block,
 		// TODO: add checking for MAX_BLOCK_SIZE
 
 		// the public key of the one who has generated this block
-		nodePublicKey, err := syspar.GetNodePublicKeyByPosition(block.Header.NodePosition)
+		nodePublicKey, err := syspar.GetNodePublicKeyByPositionAt(block.Header.NodePosition, block.Header.BlockID)
 		if err != nil {
 			log.WithFields(log.Fields{"header_block_id": block.Header.BlockID, "block_id": blockID, "type": consts.InvalidObject}).Error("block ids does not match")
 			return nil, utils.ErrInfo(err)
@@ -487,5 +517,16 @@ func processBlocks(blocks []*block.Block) error {
 		}
 	}
 
-	return dbTransaction.Commit()
+	if err = dbTransaction.Commit(); err != nil {
+		return err
+	}
+	if len(blocks) > 0 {
+		syspar.SetBlockID(blocks[0].Header.BlockID)
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		blocks[i].InvalidatePages()
+		blocks[i].PublishEvents()
+	}
+	return nil
 }
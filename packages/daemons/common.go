@@ -62,6 +62,10 @@ var daemonsList = map[string]func(context.Context, *daemon) error{
 	"QueueParserBlocks": QueueParserBlocks,
 	"Confirmations":     Confirmations,
 	"Scheduler":         Scheduler,
+	"PeersDiscovery":    PeersDiscovery,
+	"TxForwarder":       TxForwarder,
+	"RollbackPruner":    RollbackPruner,
+	"Finalizer":         Finalizer,
 }
 
 var rollbackList = []string{
@@ -31,6 +31,7 @@ package daemons
 import (
 	"context"
 
+	"github.com/AplaProject/go-apla/packages/network/peers"
 	"github.com/AplaProject/go-apla/packages/network/tcpclient"
 
 	"github.com/AplaProject/go-apla/packages/conf"
@@ -90,6 +91,7 @@ func sendTransactions(ctx context.Context, logger *log.Entry) error {
 		log.WithFields(log.Fields{"type": consts.NetworkError, "error": err}).Error("on sending transactions")
 		return err
 	}
+	gossipTransactions(ctx, hosts, *trs, logger)
 
 	// set all transactions as sent
 	for _, tr := range *trs {
@@ -132,6 +134,9 @@ func sendBlockWithTxHashes(ctx context.Context, fullNodeID int64, logger *log.En
 		log.WithFields(log.Fields{"type": consts.TCPClientError, "error": err}).Warn("on sending block with hashes to all")
 		return err
 	}
+	if trs != nil {
+		gossipTransactions(ctx, hosts, *trs, logger)
+	}
 
 	// mark all transactions and block as sent
 	if block != nil {
@@ -153,3 +158,33 @@ func sendBlockWithTxHashes(ctx context.Context, fullNodeID int64, logger *log.En
 
 	return nil
 }
+
+// gossipTransactions sends the pending transactions to the shared peers which aren't the full nodes,
+// the full nodes have already received them. The transactions of the batches are sent with
+// their batches to the full nodes only. The errors don't stop the dissemination
+func gossipTransactions(ctx context.Context, fullNodes []string, trs []model.Transaction, logger *log.Entry) {
+	txs := make([]model.Transaction, 0, len(trs))
+	for _, tr := range trs {
+		if len(tr.Batch) == 0 && len(tr.Data) > 0 {
+			txs = append(txs, tr)
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+
+	skip := make(map[string]bool, len(fullNodes))
+	for _, host := range fullNodes {
+		skip[host] = true
+	}
+	hosts := make([]string, 0)
+	for _, host := range peers.GetManager().Shared() {
+		if !skip[host] {
+			hosts = append(hosts, host)
+		}
+	}
+
+	if err := tcpclient.GossipTransactions(ctx, hosts, txs); err != nil {
+		logger.WithFields(log.Fields{"type": consts.NetworkError, "error": err}).Debug("on gossiping transactions")
+	}
+}
@@ -0,0 +1,260 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package daemons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/AplaProject/go-apla/packages/block"
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/network"
+	"github.com/AplaProject/go-apla/packages/network/tcpclient"
+	"github.com/AplaProject/go-apla/packages/service"
+	"github.com/AplaProject/go-apla/packages/smart"
+	"github.com/AplaProject/go-apla/packages/snapshot"
+	"github.com/AplaProject/go-apla/packages/transaction"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errNoSnapshot          = errors.New("Snapshot is not available")
+	errSnapshotUnconfirmed = errors.New("Snapshot block is not confirmed by the nodes")
+	errSnapshotMismatch    = errors.New("Nodes have different state hashes of the snapshot")
+	errCheckpoint          = errors.New("State hash of the snapshot doesn't match the checkpoint block")
+)
+
+// needFastSync returns true if the node has only the first block and can load the snapshot.
+// The replica keeping the full history plays all blocks because the snapshot doesn't have the rollback records.
+func needFastSync(blockID int64) bool {
+	return conf.Config.Snapshot.FastSync && blockID == 1 && !(conf.Config.IsReplica() && conf.Config.Replica.KeepHistory)
+}
+
+// FastSync loads the state from the latest snapshot of the nodes. The block of the snapshot
+// and its checkpoint block must be confirmed by the majority of the nodes, the state hash must
+// match the header of the checkpoint block. The blocks after the snapshot are collected as usual.
+func FastSync(ctx context.Context, logger *log.Entry) error {
+	hosts, err := service.GetNodesBanService().FilterBannedHosts(syspar.GetRemoteHosts())
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("on filtering banned hosts")
+	}
+	if len(hosts) == 0 {
+		hosts = conf.GetNodesAddr()
+	}
+
+	var blockID int64
+	for _, resp := range getSnapshotInfo(hosts, 0) {
+		if resp.BlockID > blockID {
+			blockID = resp.BlockID
+		}
+	}
+	if blockID <= 1 {
+		return errNoSnapshot
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	infos := getSnapshotInfo(hosts, blockID)
+	candidate, err := confirmSnapshot(hosts, infos, logger)
+	if err != nil {
+		return err
+	}
+	stateHash, err := checkCheckpoint(hosts, candidate, logger)
+	if err != nil {
+		return err
+	}
+
+	logger = logger.WithFields(log.Fields{"block_id": blockID})
+	for host, resp := range infos {
+		if resp.BlockID != blockID {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err = loadSnapshot(host, candidate, stateHash); err != nil {
+			logger.WithFields(log.Fields{"host": host, "error": err}).Error("loading snapshot")
+			continue
+		}
+		logger.WithFields(log.Fields{"host": host}).Info("state is loaded from snapshot")
+		return nil
+	}
+	return err
+}
+
+// getSnapshotInfo requests the info of the snapshot from the hosts
+func getSnapshotInfo(hosts []string, blockID int64) map[string]*network.SnapshotResponse {
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+	)
+	infos := make(map[string]*network.SnapshotResponse)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			resp, err := tcpclient.GetSnapshotInfo(host, blockID)
+			if err != nil || resp.BlockID == 0 {
+				return
+			}
+			mutex.Lock()
+			infos[host] = resp
+			mutex.Unlock()
+		}(host)
+	}
+	wg.Wait()
+	return infos
+}
+
+// confirmSnapshot checks that the block of the snapshot is the same at the majority of the nodes
+// and the nodes don't have different state hashes at this block
+func confirmSnapshot(hosts []string, infos map[string]*network.SnapshotResponse, logger *log.Entry) (*network.SnapshotResponse, error) {
+	var candidate *network.SnapshotResponse
+	for _, resp := range infos {
+		if candidate == nil {
+			candidate = resp
+			continue
+		}
+		if !bytes.Equal(resp.BlockHash, candidate.BlockHash) || !bytes.Equal(resp.StateHash, candidate.StateHash) {
+			return nil, errSnapshotMismatch
+		}
+	}
+	if candidate == nil {
+		return nil, errNoSnapshot
+	}
+	if err := confirmBlock(hosts, candidate.BlockID, candidate.BlockHash, logger); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// confirmBlock checks that the majority of the nodes have the block with the hash
+func confirmBlock(hosts []string, blockID int64, blockHash []byte, logger *log.Entry) error {
+	hash := string(converter.BinToHex(blockHash))
+	ch := make(chan string)
+	for _, host := range hosts {
+		go IsReachable(host, blockID, ch, logger)
+	}
+	var good, bad int
+	for range hosts {
+		switch answer := <-ch; answer {
+		case hash:
+			good++
+		case "0":
+		default:
+			bad++
+		}
+	}
+	if good == 0 || good <= bad || good < consts.MIN_CONFIRMED_NODES {
+		logger.WithFields(log.Fields{"type": consts.BlockError, "block_id": blockID, "good": good, "bad": bad}).Error("snapshot block is not confirmed")
+		return errSnapshotUnconfirmed
+	}
+	return nil
+}
+
+// checkCheckpoint confirms the checkpoint block of the snapshot and returns the state hash
+// from its header. The hash of the block is calculated from the header so the state hash
+// can't be changed by the node which sends the snapshot.
+func checkCheckpoint(hosts []string, candidate *network.SnapshotResponse, logger *log.Entry) ([]byte, error) {
+	cp := &snapshot.Checkpoint{}
+	if err := json.Unmarshal(candidate.Checkpoint, cp); err != nil {
+		logger.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling snapshot checkpoint")
+		return nil, err
+	}
+	if cp.Block == nil || cp.Prev == nil || cp.Block.ID != candidate.BlockID+consts.SnapshotCheckpoint ||
+		cp.Prev.ID != cp.Block.ID-1 {
+		return nil, errCheckpoint
+	}
+	if err := confirmBlock(hosts, cp.Block.ID, cp.Block.Hash, logger); err != nil {
+		return nil, err
+	}
+
+	prev := &utils.BlockData{BlockID: cp.Prev.ID, Hash: cp.Prev.Hash, RollbacksHash: cp.Prev.RollbacksHash}
+	header, hash, err := block.HeaderHash(cp.Block.Data, prev)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.UnmarshallingError, "error": err}).Error("parsing checkpoint block")
+		return nil, err
+	}
+	if header.BlockID != cp.Block.ID || !bytes.Equal(hash, cp.Block.Hash) || len(header.SnapshotHash) == 0 ||
+		!bytes.Equal(header.SnapshotHash, candidate.StateHash) {
+		logger.WithFields(log.Fields{"type": consts.InvalidObject, "block_id": cp.Block.ID}).Error("state hash doesn't match checkpoint block")
+		return nil, errCheckpoint
+	}
+	return header.SnapshotHash, nil
+}
+
+func loadSnapshot(host string, candidate *network.SnapshotResponse, stateHash []byte) error {
+	resp, data, err := tcpclient.GetSnapshot(host, candidate.BlockID)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errNoSnapshot
+	}
+	defer data.Close()
+
+	if resp.BlockID != candidate.BlockID || !bytes.Equal(resp.BlockHash, candidate.BlockHash) ||
+		!bytes.Equal(resp.StateHash, candidate.StateHash) {
+		return errSnapshotMismatch
+	}
+	info := &snapshot.Info{BlockID: resp.BlockID, BlockHash: resp.BlockHash, StateHash: stateHash}
+	if err = json.Unmarshal(resp.Block, &info.Block); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling snapshot block")
+		return err
+	}
+
+	dbTransaction, err := model.StartTransaction()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("starting transaction")
+		return err
+	}
+	if err = snapshot.Import(dbTransaction, data, info); err != nil {
+		dbTransaction.Rollback()
+		return err
+	}
+	if err = dbTransaction.Commit(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("committing snapshot")
+		return err
+	}
+	transaction.CleanCache()
+	if err = syspar.SysUpdate(nil); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("updating syspar")
+		return err
+	}
+	return smart.LoadContracts()
+}
@@ -0,0 +1,106 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package daemons
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/bft"
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/network/tcpclient"
+	"github.com/AplaProject/go-apla/packages/service"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Finalizer is the commit round of the validators. The validator votes for the played blocks
+// and sends its votes for the blocks which aren't finalized yet to other validators. The daemon
+// stores the commit of the latest block which has got the votes of more than 2/3 of the validators
+func Finalizer(ctx context.Context, d *daemon) error {
+	d.sleepTime = time.Second
+	if !bft.IsEnabled() {
+		d.sleepTime = 10 * time.Second
+		return nil
+	}
+	if service.IsNodePaused() {
+		return nil
+	}
+
+	if _, err := syspar.GetNodePositionByKeyID(conf.Config.KeyID); err == nil {
+		if err = sendVotes(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	_, err := bft.Finalize()
+	return err
+}
+
+func sendVotes(ctx context.Context, d *daemon) error {
+	privateKey, _, err := utils.GetNodeKeys()
+	if err != nil || len(privateKey) < 1 {
+		if err == nil {
+			d.logger.WithFields(log.Fields{"type": consts.EmptyObject}).Error("node private key is empty")
+		}
+		return err
+	}
+	if _, err = bft.VoteBlocks(conf.Config.KeyID, privateKey); err != nil {
+		return err
+	}
+
+	votes, err := bft.PendingVotes(conf.Config.KeyID)
+	if err != nil || len(votes) == 0 {
+		return err
+	}
+	data, err := bft.MarshalVotes(votes)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, host := range syspar.GetRemoteHosts() {
+		if ctx.Err() != nil {
+			d.logger.WithFields(log.Fields{"type": consts.ContextError, "error": ctx.Err()}).Error("context error")
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			if err := tcpclient.SendVotes(host, data); err != nil {
+				d.logger.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": host}).Debug("sending votes")
+			}
+		}(host)
+	}
+	wg.Wait()
+	return nil
+}
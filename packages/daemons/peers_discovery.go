@@ -0,0 +1,115 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package daemons
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/network/peers"
+	"github.com/AplaProject/go-apla/packages/network/tcpclient"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	peersDiscoveryInterval = time.Minute
+	// peersFanout is the count of the nodes which are asked for the peers at once
+	peersFanout = 3
+	// maxNewPeers is the maximum count of the new peers which are taken from one response
+	maxNewPeers = 10
+	// peersProbes is the count of the unchecked peers which are requested in parallel
+	peersProbes = 10
+)
+
+// PeersDiscovery requests the known peers from other nodes and checks the discovered peers.
+// The peers which have responded are used as the sources of blocks and are shared with other nodes.
+func PeersDiscovery(ctx context.Context, d *daemon) error {
+	d.sleepTime = peersDiscoveryInterval
+	manager := peers.GetManager()
+
+	var address string
+	if conf.Config.TCPServer.Announce {
+		address = conf.Config.TCPServer.Str()
+	}
+
+	hosts := append(syspar.GetRemoteHosts(), conf.GetNodesAddr()...)
+	hosts = manager.Filter(append(hosts, manager.Discovered()...))
+	utils.ShuffleSlice(hosts)
+	if len(hosts) > peersFanout {
+		hosts = hosts[:peersFanout]
+	}
+	for _, host := range hosts {
+		if ctx.Err() != nil {
+			d.logger.WithFields(log.Fields{"type": consts.ContextError, "error": ctx.Err()}).Error("context error")
+			return ctx.Err()
+		}
+		list, err := tcpclient.GetPeers(host, address)
+		if err != nil {
+			d.logger.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": host}).Debug("getting peers")
+			continue
+		}
+		var added int
+		for _, peer := range list {
+			if added >= maxNewPeers {
+				break
+			}
+			if manager.AddPeer(peer) {
+				added++
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	probes := make(chan struct{}, peersProbes)
+	for _, host := range manager.Unchecked() {
+		if ctx.Err() != nil {
+			wg.Wait()
+			d.logger.WithFields(log.Fields{"type": consts.ContextError, "error": ctx.Err()}).Error("context error")
+			return ctx.Err()
+		}
+		probes <- struct{}{}
+		wg.Add(1)
+		go func(host string) {
+			defer func() {
+				<-probes
+				wg.Done()
+			}()
+			// the request registers the latency of the peer or its failure
+			tcpclient.GetMaxBlockID(host)
+		}(host)
+	}
+	wg.Wait()
+	manager.Prune()
+	return nil
+}
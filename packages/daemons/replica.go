@@ -0,0 +1,128 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package daemons
+
+import (
+	"context"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/network/tcpclient"
+	"github.com/AplaProject/go-apla/packages/service"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// forwardTxLimit is the maximum count of the transactions which are forwarded at once
+	forwardTxLimit = 100
+	pruneInterval  = 10 * time.Minute
+	// pruneLimit is the maximum count of the rollback records which are deleted under DBLock at once
+	pruneLimit = 1000
+)
+
+// TxForwarder sends the transactions which have been submitted to the replica to the validators
+func TxForwarder(ctx context.Context, d *daemon) error {
+	d.sleepTime = time.Second
+
+	txs, err := model.GetQueueTxs(forwardTxLimit)
+	if err != nil {
+		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting queued transactions")
+		return err
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+
+	hosts := conf.Config.Replica.Validators
+	if len(hosts) == 0 {
+		if hosts, err = service.GetNodesBanService().FilterBannedHosts(syspar.GetRemoteHosts()); err != nil {
+			d.logger.WithFields(log.Fields{"error": err}).Error("on getting remotes hosts")
+			return err
+		}
+	}
+
+	trs := make([]model.Transaction, 0, len(txs))
+	for _, qtx := range txs {
+		trs = append(trs, model.Transaction{Hash: qtx.Hash, Data: qtx.Data})
+	}
+	if err = tcpclient.SendTransacitionsToAll(ctx, hosts, trs); err != nil {
+		d.logger.WithFields(log.Fields{"type": consts.NetworkError, "error": err}).Warn("forwarding transactions to validators")
+		return err
+	}
+
+	for _, qtx := range txs {
+		if _, err = model.DeleteQueueTxByHash(nil, qtx.Hash); err != nil {
+			d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting forwarded transaction")
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackPruner deletes the rollback records of the blocks which can't be rolled back anymore,
+// it isn't started if the replica keeps the full history
+func RollbackPruner(ctx context.Context, d *daemon) error {
+	d.sleepTime = pruneInterval
+
+	infoBlock := &model.InfoBlock{}
+	if _, err := infoBlock.Get(); err != nil {
+		d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		return err
+	}
+	blockID := infoBlock.BlockID - syspar.GetRbBlocks1()
+	if blockID <= 1 {
+		return nil
+	}
+
+	var total int64
+	for {
+		// the lock is released between the chunks so that the blocks can be played meanwhile
+		DBLock()
+		count, err := model.DeleteRollbackTxsBefore(blockID, pruneLimit)
+		DBUnlock()
+		if err != nil {
+			d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("pruning rollback transactions")
+			return err
+		}
+		total += count
+		if count < pruneLimit {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	d.logger.WithFields(log.Fields{"block_id": blockID, "count": total}).Debug("rollback transactions pruned")
+	return nil
+}
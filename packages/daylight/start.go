@@ -51,6 +51,7 @@ import (
 	"github.com/AplaProject/go-apla/packages/network/httpserver"
 	"github.com/AplaProject/go-apla/packages/obsmanager"
 	"github.com/AplaProject/go-apla/packages/publisher"
+	"github.com/AplaProject/go-apla/packages/service"
 	"github.com/AplaProject/go-apla/packages/smart"
 	"github.com/AplaProject/go-apla/packages/statsd"
 	"github.com/AplaProject/go-apla/packages/utils"
@@ -262,6 +263,11 @@ func Start() {
 			os.Exit(1)
 		}
 
+		if err := service.InitSessionRevocationService(); err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("on loading revoked sessions")
+			os.Exit(1)
+		}
+
 		ctx, cancel := context.WithCancel(context.Background())
 		utils.CancelFunc = cancel
 		utils.ReturnCh = make(chan string)
@@ -277,6 +283,11 @@ func Start() {
 	}
 	daemons.WaitForSignals()
 
+	if err := api.InitJWT(conf.Config.JWT); err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("can't init jwt keys")
+		Exit(1)
+	}
+
 	initRoutes(conf.Config.HTTP.Str())
 
 	select {}
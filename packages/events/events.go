@@ -0,0 +1,201 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package events
+
+import (
+	"sync"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Types of events
+const (
+	TypeBlock    = "block"
+	TypeTx       = "tx"
+	TypeRow      = "row"
+	TypeRollback = "rollback"
+)
+
+// subscriptionBuffer is the count of events which can wait for reading by subscriber,
+// a subscriber which is slower than that is unsubscribed
+const subscriptionBuffer = 256
+
+// Event is the message which is sent to subscribers
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// BlockEvent is sent when the block has been inserted into the blockchain
+type BlockEvent struct {
+	BlockID      int64  `json:"block_id"`
+	Hash         string `json:"hash"`
+	Time         int64  `json:"time"`
+	EcosystemID  int64  `json:"ecosystem_id"`
+	KeyID        int64  `json:"key_id"`
+	NodePosition int64  `json:"node_position"`
+	TxCount      int    `json:"tx_count"`
+}
+
+// TxEvent is sent when the transaction has been processed in the block
+type TxEvent struct {
+	Hash    string `json:"hash"`
+	BlockID int64  `json:"block_id"`
+	Result  string `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RowEvent is sent when the row of the table has been changed by the block or rolled back
+type RowEvent struct {
+	Table    string `json:"table"`
+	ID       string `json:"id"`
+	BlockID  int64  `json:"block_id"`
+	TxHash   string `json:"tx_hash"`
+	Rollback bool   `json:"rollback,omitempty"`
+}
+
+// RollbackEvent is sent when the block has been rolled back
+type RollbackEvent struct {
+	BlockID int64 `json:"block_id"`
+}
+
+// Filter defines events which the subscriber is interested in, rollback events are sent to all subscribers
+type Filter struct {
+	Blocks   bool
+	TxHashes map[string]bool
+	Tables   map[string]bool
+}
+
+func (f Filter) match(e Event) bool {
+	switch data := e.Data.(type) {
+	case BlockEvent:
+		return f.Blocks
+	case TxEvent:
+		return f.TxHashes[data.Hash]
+	case RowEvent:
+		return f.Tables[data.Table]
+	case RollbackEvent:
+		return true
+	}
+	return false
+}
+
+// Subscription receives events matching the filter from C until it has been closed
+type Subscription struct {
+	C      chan Event
+	filter Filter
+	closed bool
+}
+
+type hub struct {
+	subscriptions map[*Subscription]struct{}
+	m             *sync.RWMutex
+}
+
+var h = &hub{
+	subscriptions: make(map[*Subscription]struct{}),
+	m:             &sync.RWMutex{},
+}
+
+// Subscribe creates the new subscription
+func Subscribe(filter Filter) *Subscription {
+	s := &Subscription{
+		C:      make(chan Event, subscriptionBuffer),
+		filter: filter,
+	}
+
+	h.m.Lock()
+	h.subscriptions[s] = struct{}{}
+	h.m.Unlock()
+	return s
+}
+
+// Close unsubscribes the subscription
+func (s *Subscription) Close() {
+	h.m.Lock()
+	defer h.m.Unlock()
+	h.remove(s)
+}
+
+func (h *hub) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(h.subscriptions, s)
+	close(s.C)
+}
+
+// HasSubscribers returns true if there are subscribers of the type of events
+func HasSubscribers(eventType string) bool {
+	h.m.RLock()
+	defer h.m.RUnlock()
+
+	for s := range h.subscriptions {
+		switch eventType {
+		case TypeBlock:
+			if s.filter.Blocks {
+				return true
+			}
+		case TypeTx:
+			if len(s.filter.TxHashes) > 0 {
+				return true
+			}
+		case TypeRow:
+			if len(s.filter.Tables) > 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Publish sends the events to the subscribers
+func Publish(list ...Event) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for _, e := range list {
+		for s := range h.subscriptions {
+			if !s.filter.match(e) {
+				continue
+			}
+			select {
+			case s.C <- e:
+			default:
+				log.WithFields(log.Fields{"type": consts.ParameterExceeded, "event": e.Type}).Warning("events subscriber is too slow, unsubscribing")
+				h.remove(s)
+			}
+		}
+	}
+}
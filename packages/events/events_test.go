@@ -0,0 +1,78 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	blocks := Subscribe(Filter{Blocks: true})
+	defer blocks.Close()
+	rows := Subscribe(Filter{Tables: map[string]bool{"1_keys": true}, TxHashes: map[string]bool{"aa": true}})
+	defer rows.Close()
+
+	assert.True(t, HasSubscribers(TypeBlock))
+	assert.True(t, HasSubscribers(TypeRow))
+
+	Publish(
+		Event{Type: TypeBlock, Data: BlockEvent{BlockID: 10}},
+		Event{Type: TypeTx, Data: TxEvent{Hash: "aa", BlockID: 10}},
+		Event{Type: TypeTx, Data: TxEvent{Hash: "bb", BlockID: 10}},
+		Event{Type: TypeRow, Data: RowEvent{Table: "1_keys", ID: "5", BlockID: 10}},
+		Event{Type: TypeRow, Data: RowEvent{Table: "1_pages", ID: "1", BlockID: 10}},
+		Event{Type: TypeRollback, Data: RollbackEvent{BlockID: 10}},
+	)
+
+	assert.Len(t, blocks.C, 2)
+	assert.Equal(t, TypeBlock, (<-blocks.C).Type)
+	assert.Equal(t, TypeRollback, (<-blocks.C).Type)
+
+	assert.Len(t, rows.C, 3)
+	assert.Equal(t, "aa", (<-rows.C).Data.(TxEvent).Hash)
+	assert.Equal(t, "5", (<-rows.C).Data.(RowEvent).ID)
+	assert.Equal(t, TypeRollback, (<-rows.C).Type)
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	s := Subscribe(Filter{Blocks: true})
+	for i := 0; i <= subscriptionBuffer; i++ {
+		Publish(Event{Type: TypeBlock, Data: BlockEvent{BlockID: int64(i)}})
+	}
+
+	count := 0
+	for range s.C {
+		count++
+	}
+	assert.Equal(t, subscriptionBuffer, count)
+	assert.False(t, HasSubscribers(TypeBlock))
+	s.Close()
+}
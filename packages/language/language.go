@@ -95,6 +95,31 @@ func UpdateLang(state int, name, value string) {
 	}
 }
 
+// LangSource returns the language sources of the state with the specified name
+func LangSource(state int, name string) *map[string]string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if _, ok := lang[state]; !ok {
+		return nil
+	}
+	return (*lang[state]).res[name]
+}
+
+// RestoreLang sets the language sources which have been returned by LangSource,
+// the sources are removed if res is nil
+func RestoreLang(state int, name string, res *map[string]string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, ok := lang[state]; !ok {
+		lang[state] = &cacheLang{make(map[string]*map[string]string)}
+	}
+	if res == nil {
+		delete((*lang[state]).res, name)
+	} else {
+		(*lang[state]).res[name] = res
+	}
+}
+
 // loadLang download the language sources from database for the state
 func loadLang(state int) error {
 	language := &model.Language{}
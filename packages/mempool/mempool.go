@@ -0,0 +1,166 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+// Package mempool keeps the limits of the pending transactions. The transactions are stored
+// in the transactions table, the package decides which of them are accepted, replaced or evicted.
+//
+// The transaction header has no nonce, so the nonce is the hash of the transaction data without
+// the offered fee. A transaction offering a higher fee replaces the pending transaction of the same
+// key and nonce, so the key can raise the fee of its transaction but can't replace other ones.
+// The replaced transaction can still be included in a block by another node, so the nonce is
+// recorded in log_transactions and the second transaction with the same key and nonce isn't executed.
+package mempool
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+var (
+	// ErrFull is returned if the mempool is full of transactions offering the same or a higher fee
+	ErrFull = errors.New("Mempool is full")
+	// ErrKeyQuota is returned if the key has too many pending transactions
+	ErrKeyQuota = errors.New("Too many pending transactions of the key")
+)
+
+// Fee returns the fuel rate offered over the rate of the ecosystem and the maximal cost of the contract
+func Fee(smart *tx.SmartContract) (priority decimal.Decimal, maxSum int64) {
+	if smart == nil {
+		return decimal.Zero, 0
+	}
+	if len(smart.PayOver) > 0 {
+		if payOver, err := decimal.NewFromString(smart.PayOver); err == nil && payOver.Sign() > 0 {
+			priority = payOver
+		}
+	}
+	if len(smart.MaxSum) > 0 {
+		maxSum = converter.StrToInt64(smart.MaxSum)
+	}
+	return
+}
+
+// Nonce returns the hash of the transaction without the offered fee, the transactions which
+// differ only by the fee have the same nonce. It returns nil if the nonce can't be calculated.
+func Nonce(smart *tx.SmartContract) []byte {
+	if smart == nil {
+		return nil
+	}
+	data := *smart
+	data.MaxSum, data.PayOver = "", ""
+	buf := &bytes.Buffer{}
+	if err := msgpack.NewEncoder(buf).SortMapKeys(true).Encode(&data); err != nil {
+		log.WithFields(log.Fields{"type": consts.MarshallingError, "error": err}).Error("marshalling transaction nonce")
+		return nil
+	}
+	hash, err := crypto.Hash(buf.Bytes())
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("hashing transaction nonce")
+		return nil
+	}
+	return hash
+}
+
+// Compare returns 1 if the transaction a offers a higher fee than b, -1 if a offers a lower fee and 0 otherwise
+func Compare(a, b *model.Transaction) int {
+	if cmp := a.Priority.Cmp(b.Priority); cmp != 0 {
+		return cmp
+	}
+	switch {
+	case a.MaxSum > b.MaxSum:
+		return 1
+	case a.MaxSum < b.MaxSum:
+		return -1
+	}
+	return 0
+}
+
+// isExempt returns true if the limits are not applied to the transaction
+func isExempt(t *model.Transaction) bool {
+	return t.HighRate > 0 || t.Type == consts.TxTypeStopNetwork
+}
+
+// Admit checks if the verified transaction can be added to the mempool within the transaction.
+// It returns the hashes of the pending transactions which must be dropped for it
+func Admit(dbTransaction *model.DbTransaction, t *model.Transaction) ([][]byte, error) {
+	if isExempt(t) {
+		return nil, nil
+	}
+	if len(t.Batch) == 0 && len(t.Nonce) > 0 {
+		prev, found, err := model.GetPendingTransactionByNonce(dbTransaction, t.KeyID, t.Nonce)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting pending transaction by nonce")
+			return nil, err
+		}
+		// the replacement takes the place of the previous transaction so the limits are not checked
+		if found && len(prev.Batch) == 0 && !bytes.Equal(prev.Hash, t.Hash) && Compare(t, prev) > 0 {
+			return [][]byte{prev.Hash}, nil
+		}
+	}
+
+	limits := conf.Config.Mempool
+	if limits.MaxPerKey > 0 {
+		count, err := model.GetPendingTransactionsCount(dbTransaction, t.KeyID)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting pending transactions count of key")
+			return nil, err
+		}
+		if count >= int64(limits.MaxPerKey) {
+			return nil, ErrKeyQuota
+		}
+	}
+	if limits.MaxSize > 0 {
+		count, err := model.GetPendingTransactionsCount(dbTransaction, 0)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting pending transactions count")
+			return nil, err
+		}
+		if count < int64(limits.MaxSize) {
+			return nil, nil
+		}
+		lowest, found, err := model.GetLowestPriorityTransaction(dbTransaction)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting lowest priority transaction")
+			return nil, err
+		}
+		if !found || Compare(t, lowest) <= 0 {
+			return nil, ErrFull
+		}
+		return [][]byte{lowest.Hash}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,95 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package mempool
+
+import (
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFee(t *testing.T) {
+	priority, maxSum := Fee(nil)
+	assert.True(t, priority.Equal(decimal.Zero))
+	assert.Equal(t, int64(0), maxSum)
+
+	priority, maxSum = Fee(&tx.SmartContract{PayOver: "2.5", MaxSum: "1000"})
+	assert.Equal(t, "2.5", priority.String())
+	assert.Equal(t, int64(1000), maxSum)
+
+	priority, _ = Fee(&tx.SmartContract{PayOver: "-3"})
+	assert.True(t, priority.Equal(decimal.Zero))
+
+	priority, _ = Fee(&tx.SmartContract{PayOver: "wrong"})
+	assert.True(t, priority.Equal(decimal.Zero))
+}
+
+func TestCompare(t *testing.T) {
+	low := &model.Transaction{Priority: decimal.New(1, 0), MaxSum: 500}
+	high := &model.Transaction{Priority: decimal.New(2, 0), MaxSum: 100}
+	sameRate := &model.Transaction{Priority: decimal.New(1, 0), MaxSum: 600}
+
+	assert.Equal(t, 1, Compare(high, low))
+	assert.Equal(t, -1, Compare(low, high))
+	assert.Equal(t, 1, Compare(sameRate, low))
+	assert.Equal(t, 0, Compare(low, &model.Transaction{Priority: decimal.New(10, -1), MaxSum: 500}))
+}
+
+func TestNonce(t *testing.T) {
+	smart := &tx.SmartContract{
+		Header:  tx.Header{ID: 5, Time: 1520000000, KeyID: 100},
+		MaxSum:  "1000",
+		PayOver: "1.5",
+		Params:  map[string]interface{}{"Recipient": "200", "Amount": "10"},
+	}
+	nonce := Nonce(smart)
+	assert.NotEmpty(t, nonce)
+
+	// the fee doesn't change the nonce
+	other := *smart
+	other.MaxSum, other.PayOver = "2000", "3"
+	other.Params = map[string]interface{}{"Amount": "10", "Recipient": "200"}
+	assert.Equal(t, nonce, Nonce(&other))
+
+	// other transaction of the key in the same second has other nonce
+	other.Params = map[string]interface{}{"Recipient": "300", "Amount": "10"}
+	assert.NotEqual(t, nonce, Nonce(&other))
+
+	assert.Nil(t, Nonce(nil))
+}
+
+func TestAdmitExempt(t *testing.T) {
+	drop, err := Admit(nil, &model.Transaction{Type: consts.TxTypeStopNetwork})
+	assert.NoError(t, err)
+	assert.Empty(t, drop)
+}
@@ -0,0 +1,79 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package migration_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/script"
+	"github.com/AplaProject/go-apla/packages/smart"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLintContracts checks that the linter doesn't find anything in the system contracts
+func TestLintContracts(t *testing.T) {
+	smart.InitVM()
+	vm := smart.GetVM()
+	require.NoError(t, smart.LoadSysFuncs(vm, 1))
+	owner := &script.OwnerInfo{StateID: 1}
+
+	sources := make(map[string]string)
+	for _, dir := range []string{"ecosystem", "first_ecosystem"} {
+		files, err := filepath.Glob(filepath.Join("contracts", dir, "*.sim"))
+		require.NoError(t, err)
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			require.NoError(t, err)
+			sources[file] = string(data)
+		}
+	}
+	// the contracts call each other so all of them must be loaded before the linting
+	// and the contract can be compiled only after the contracts which it calls
+	pending := make(map[string]error)
+	for file := range sources {
+		pending[file] = nil
+	}
+	for len(pending) > 0 {
+		count := len(pending)
+		for file := range pending {
+			if pending[file] = vm.Compile([]rune(sources[file]), owner); pending[file] == nil {
+				delete(pending, file)
+			}
+		}
+		if len(pending) == count {
+			require.Empty(t, pending)
+		}
+	}
+	for file, src := range sources {
+		assert.Empty(t, smart.VMLint(vm, src, owner), file)
+	}
+}
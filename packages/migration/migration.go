@@ -64,6 +64,15 @@ var updateMigrations = []*migration{
 	&migration{"1.2.5", updates.M125},
 	&migration{"1.2.6", updates.M126},
 	&migration{"1.2.7", updates.M127},
+	&migration{"1.2.8", updates.M128},
+	&migration{"1.2.9", updates.M129},
+	&migration{"1.3.0", updates.M130},
+	&migration{"1.3.1", updates.M131},
+	&migration{"1.3.2", updates.M132},
+	&migration{"1.3.3", updates.M133},
+	&migration{"1.3.4", updates.M134},
+	&migration{"1.3.5", updates.M135},
+	&migration{"1.3.6", updates.M136},
 }
 
 type migration struct {
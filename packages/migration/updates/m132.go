@@ -0,0 +1,52 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package updates
+
+var M132 = `
+	DROP TABLE IF EXISTS "block_votes"; CREATE TABLE "block_votes" (
+	"block_id" bigint NOT NULL DEFAULT '0',
+	"key_id" bigint NOT NULL DEFAULT '0',
+	"hash" bytea NOT NULL DEFAULT '',
+	"lock_id" bigint NOT NULL DEFAULT '0',
+	"lock_hash" bytea NOT NULL DEFAULT '',
+	"sign" bytea NOT NULL DEFAULT ''
+	);
+	ALTER TABLE ONLY "block_votes" ADD CONSTRAINT block_votes_pkey PRIMARY KEY (block_id, key_id);
+
+	DROP TABLE IF EXISTS "block_commits"; CREATE TABLE "block_commits" (
+	"block_id" bigint NOT NULL DEFAULT '0',
+	"hash" bytea NOT NULL DEFAULT '',
+	"data" bytea NOT NULL DEFAULT '',
+	"block" bigint NOT NULL DEFAULT '0'
+	);
+	ALTER TABLE ONLY "block_commits" ADD CONSTRAINT block_commits_pkey PRIMARY KEY (block_id);
+
+	INSERT INTO "1_system_parameters" ("id","name", "value", "conditions")
+		SELECT '68','bft_finality', '0', 'ContractAccess("@1UpdateSysParam")'
+		WHERE NOT EXISTS (SELECT 1 FROM "1_system_parameters" WHERE name = 'bft_finality');
+`
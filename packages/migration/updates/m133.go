@@ -0,0 +1,59 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package updates
+
+var M133 = `
+	DROP TABLE IF EXISTS "1_validator_sets"; CREATE TABLE "1_validator_sets" (
+	"id" bigint NOT NULL DEFAULT '0',
+	"value" text NOT NULL DEFAULT '',
+	"activation" bigint NOT NULL DEFAULT '0',
+	"block_id" bigint NOT NULL DEFAULT '0'
+	);
+	ALTER TABLE ONLY "1_validator_sets" ADD CONSTRAINT "1_validator_sets_pkey" PRIMARY KEY (id);
+	CREATE INDEX "1_validator_sets_index_activation" ON "1_validator_sets" (activation);
+
+	-- the history of full_nodes is restored from the rollback records of its changes
+	-- like they would have been recorded by UpdateSysParam without epochs
+	WITH changes AS (
+		SELECT id, block_id, data::jsonb->>'value' AS prev FROM "rollback_tx"
+		WHERE table_name = '1_system_parameters' AND data LIKE '%"value":%'
+			AND table_id = (SELECT id::text FROM "1_system_parameters" WHERE name = 'full_nodes')
+	)
+	INSERT INTO "1_validator_sets" ("id", "value", "activation", "block_id")
+	SELECT row_number() OVER (ORDER BY activation, id), value, activation, block_id FROM (
+		(SELECT 0 AS id, prev AS value, 0 AS activation, block_id FROM changes ORDER BY changes.id LIMIT 1)
+		UNION ALL
+		SELECT id, coalesce(lead(prev) OVER (ORDER BY id),
+			(SELECT value FROM "1_system_parameters" WHERE name = 'full_nodes')), block_id + 1, block_id
+		FROM changes
+	) AS history;
+
+	INSERT INTO "1_system_parameters" ("id","name", "value", "conditions")
+		SELECT '69','epoch_length', '0', 'ContractAccess("@1UpdateSysParam")'
+		WHERE NOT EXISTS (SELECT 1 FROM "1_system_parameters" WHERE name = 'epoch_length');
+`
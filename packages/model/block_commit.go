@@ -0,0 +1,146 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package model
+
+// BlockVote is the precommit of the validator for the block, LockID and LockHash are
+// the last finalized block which the voted block extends
+type BlockVote struct {
+	BlockID  int64  `gorm:"primary_key;not null"`
+	KeyID    int64  `gorm:"primary_key;not null"`
+	Hash     []byte `gorm:"not null"`
+	LockID   int64  `gorm:"not null"`
+	LockHash []byte `gorm:"not null"`
+	Sign     []byte `gorm:"not null"`
+}
+
+// TableName returns name of table
+func (BlockVote) TableName() string {
+	return "block_votes"
+}
+
+// Get is retrieving the vote of the validator for the block
+func (v *BlockVote) Get(blockID, keyID int64) (bool, error) {
+	return isFound(DBConn.Where("block_id = ? AND key_id = ?", blockID, keyID).First(v))
+}
+
+// Create is creating record of model
+func (v *BlockVote) Create() error {
+	return DBConn.Create(v).Error
+}
+
+// GetBlockVotes returns the votes for the block
+func GetBlockVotes(blockID int64) ([]BlockVote, error) {
+	var votes []BlockVote
+	err := DBConn.Where("block_id = ?", blockID).Order("key_id").Find(&votes).Error
+	return votes, err
+}
+
+// GetVotesFrom returns the votes of the validator for the blocks after blockID
+func GetVotesFrom(keyID, blockID int64, limit int) ([]BlockVote, error) {
+	var votes []BlockVote
+	err := DBConn.Where("key_id = ? AND block_id > ?", keyID, blockID).Order("block_id").Limit(limit).Find(&votes).Error
+	return votes, err
+}
+
+// GetLastVotedBlockID returns the last block which has been voted by the validator
+func GetLastVotedBlockID(keyID int64) (blockID int64, err error) {
+	err = DBConn.Raw("SELECT coalesce(max(block_id), 0) FROM block_votes WHERE key_id = ?", keyID).Row().Scan(&blockID)
+	return
+}
+
+// GetVotedBlockIDs returns the blocks after blockID which have been voted by anybody
+func GetVotedBlockIDs(blockID int64) ([]int64, error) {
+	var ids []int64
+	err := DBConn.Table("block_votes").Where("block_id > ?", blockID).Order("block_id").
+		Pluck("DISTINCT block_id", &ids).Error
+	return ids, err
+}
+
+// DeleteVotesBefore deletes the votes for the blocks before blockID
+func DeleteVotesBefore(blockID int64) (int64, error) {
+	query := DBConn.Exec("DELETE FROM block_votes WHERE block_id < ?", blockID)
+	return query.RowsAffected, query.Error
+}
+
+// BlockCommit is the set of the validator signatures which makes the block irreversible.
+// Block is the ID of the block which has carried the commit, it equals 0 if the commit is known
+// only from the votes received by this node
+type BlockCommit struct {
+	BlockID int64  `gorm:"primary_key;not null"`
+	Hash    []byte `gorm:"not null"`
+	Data    []byte `gorm:"not null"`
+	Block   int64  `gorm:"not null"`
+}
+
+// TableName returns name of table
+func (BlockCommit) TableName() string {
+	return "block_commits"
+}
+
+// Get is retrieving the commit of the block
+func (c *BlockCommit) Get(blockID int64) (bool, error) {
+	return isFound(DBConn.Where("block_id = ?", blockID).First(c))
+}
+
+// GetLast returns the commit of the last finalized block
+func (c *BlockCommit) GetLast() (bool, error) {
+	return isFound(DBConn.Order("block_id DESC").First(c))
+}
+
+// GetToCarry returns the latest commit which is newer than all commits carried by the blocks
+func (c *BlockCommit) GetToCarry() (bool, error) {
+	return isFound(DBConn.Where("block_id > (SELECT coalesce(max(block_id), 0) FROM block_commits WHERE block > 0)").
+		Order("block_id DESC").First(c))
+}
+
+// Save inserts the commit or marks it as carried by the block if it already exists
+func (c *BlockCommit) Save(transaction *DbTransaction) error {
+	prev := &BlockCommit{}
+	found, err := isFound(GetDB(transaction).Where("block_id = ?", c.BlockID).First(prev))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return GetDB(transaction).Create(c).Error
+	}
+	if prev.Block == 0 && c.Block > 0 {
+		return GetDB(transaction).Exec("UPDATE block_commits SET block = ? WHERE block_id = ?", c.Block, c.BlockID).Error
+	}
+	return nil
+}
+
+// GetFinalizedBlockID returns the ID of the last finalized block or 0
+func GetFinalizedBlockID(transaction *DbTransaction) (blockID int64, err error) {
+	err = GetDB(transaction).Raw("SELECT coalesce(max(block_id), 0) FROM block_commits").Row().Scan(&blockID)
+	return
+}
+
+// ResetCarriedCommits marks the commits carried by the block as not carried
+func ResetCarriedCommits(transaction *DbTransaction, blockID int64) error {
+	return GetDB(transaction).Exec("UPDATE block_commits SET block = 0 WHERE block = ?", blockID).Error
+}
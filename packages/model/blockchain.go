@@ -43,6 +43,8 @@ type Block struct {
 	NodePosition  int64  `gorm:"not null"`
 	Time          int64  `gorm:"not null"`
 	Tx            int32  `gorm:"not null"`
+	// StateHash is the state hash of the checkpoint block, it is calculated while the block is played
+	StateHash []byte `gorm:"not null"`
 }
 
 // TableName returns name of table
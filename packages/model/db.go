@@ -29,6 +29,7 @@
 package model
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"strings"
@@ -37,6 +38,7 @@ import (
 	"github.com/AplaProject/go-apla/packages/conf"
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/crypto"
 	"github.com/AplaProject/go-apla/packages/migration"
 	"github.com/AplaProject/go-apla/packages/migration/obs"
 
@@ -56,6 +58,9 @@ var (
 
 	// ErrDBConn database connection error
 	ErrDBConn = errors.New("Database connection error")
+
+	// ErrNoSavepoint is returned when there are no nested savepoints
+	ErrNoSavepoint = errors.New("There are no nested savepoints")
 )
 
 type KeyTableChecker struct{}
@@ -121,7 +126,8 @@ func GormClose() error {
 
 // DbTransaction is gorm.DB wrapper
 type DbTransaction struct {
-	conn *gorm.DB
+	conn   *gorm.DB
+	nested int // the count of the nested savepoints
 }
 
 // StartTransaction is beginning transaction
@@ -141,6 +147,33 @@ func StartTransaction() (*DbTransaction, error) {
 	}, nil
 }
 
+// StartSnapshotTransaction begins the transaction which works on the own snapshot of the database
+// and must never be committed. The waiting for locks, every statement and the idle time inside
+// the transaction are limited with timeout so it can't hold the locks of rows for long
+func StartSnapshotTransaction(timeout time.Duration) (*DbTransaction, error) {
+	conn := DBConn.Begin()
+	if conn.Error != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": conn.Error}).Error("cannot start transaction because of connection error")
+		return nil, conn.Error
+	}
+	ms := int64(timeout / time.Millisecond)
+	for _, query := range []string{
+		`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ`,
+		fmt.Sprintf(`SET LOCAL lock_timeout = %d`, ms),
+		fmt.Sprintf(`SET LOCAL statement_timeout = %d`, ms),
+		fmt.Sprintf(`SET LOCAL idle_in_transaction_session_timeout = %d`, ms),
+	} {
+		if err := conn.Exec(query).Error; err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": query}).Error("setting up snapshot transaction")
+			conn.Rollback()
+			return nil, err
+		}
+	}
+	return &DbTransaction{
+		conn: conn,
+	}, nil
+}
+
 // Rollback is transaction rollback
 func (tr *DbTransaction) Rollback() {
 	tr.conn.Rollback()
@@ -171,6 +204,38 @@ func (tr *DbTransaction) ReleaseSavepoint(idTx int) error {
 	return tr.Connection().Exec(fmt.Sprintf("RELEASE SAVEPOINT \"tx-%d\";", idTx)).Error
 }
 
+// NestedSavepoint creates PostgreSQL savepoint inside the last nested savepoint
+func (tr *DbTransaction) NestedSavepoint() error {
+	if err := tr.Connection().Exec(fmt.Sprintf("SAVEPOINT \"nested-%d\";", tr.nested+1)).Error; err != nil {
+		return err
+	}
+	tr.nested++
+	return nil
+}
+
+// RollbackNestedSavepoint rollbacks the changes made after the last nested savepoint and removes it
+func (tr *DbTransaction) RollbackNestedSavepoint() error {
+	if tr.nested == 0 {
+		return ErrNoSavepoint
+	}
+	if err := tr.Connection().Exec(fmt.Sprintf("ROLLBACK TO SAVEPOINT \"nested-%d\";", tr.nested)).Error; err != nil {
+		return err
+	}
+	return tr.ReleaseNestedSavepoint()
+}
+
+// ReleaseNestedSavepoint releases the last nested savepoint
+func (tr *DbTransaction) ReleaseNestedSavepoint() error {
+	if tr.nested == 0 {
+		return ErrNoSavepoint
+	}
+	if err := tr.Connection().Exec(fmt.Sprintf("RELEASE SAVEPOINT \"nested-%d\";", tr.nested)).Error; err != nil {
+		return err
+	}
+	tr.nested--
+	return nil
+}
+
 // GetDB is returning gorm.DB
 func GetDB(tr *DbTransaction) *gorm.DB {
 	if tr != nil && tr.conn != nil {
@@ -309,6 +374,63 @@ func SendTx(rtx RawTransaction, adminWallet int64) error {
 	return qtx.Create()
 }
 
+// BatchHash returns the identifier of the batch which is the hash of the ordered hashes of its transactions
+func BatchHash(hashes [][]byte) ([]byte, error) {
+	batch, err := crypto.Hash(bytes.Join(hashes, []byte{}))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("calculating batch hash")
+		return nil, err
+	}
+	return batch, nil
+}
+
+// SendBatchTx queues transactions as a single batch. Either all transactions of the batch
+// are added to the queue or none of them. It returns the hash identifying the batch
+func SendBatchTx(rtxs []RawTransaction, adminWallet int64) ([]byte, error) {
+	hashes := make([][]byte, 0, len(rtxs))
+	for _, rtx := range rtxs {
+		hashes = append(hashes, rtx.Hash())
+	}
+	batch, err := BatchHash(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTx, err := StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	for i, rtx := range rtxs {
+		ts := &TransactionStatus{
+			Hash:     rtx.Hash(),
+			Time:     time.Now().Unix(),
+			Type:     rtx.Type(),
+			WalletID: adminWallet,
+		}
+		if err = GetDB(dbTx).Create(ts).Error; err != nil {
+			dbTx.Rollback()
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("transaction status create")
+			return nil, err
+		}
+		qtx := &QueueTx{
+			Hash:       rtx.Hash(),
+			Data:       rtx.Bytes(),
+			Batch:      batch,
+			BatchIndex: i,
+		}
+		if err = GetDB(dbTx).Create(qtx).Error; err != nil {
+			dbTx.Rollback()
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("queue tx create")
+			return nil, err
+		}
+	}
+	if err = dbTx.Commit(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("committing batch")
+		return nil, err
+	}
+	return batch, nil
+}
+
 // AlterTableAddColumn is adding column to table
 func AlterTableAddColumn(transaction *DbTransaction, tableName, columnName, columnType string) error {
 	return GetDB(transaction).Exec(`ALTER TABLE "` + tableName + `" ADD COLUMN "` + columnName + `" ` + columnType).Error
@@ -32,6 +32,8 @@ package model
 type LogTransaction struct {
 	Hash  []byte `gorm:"primary_key;not null"`
 	Block int64  `gorm:"not null"`
+	KeyID int64  `gorm:"not null"`
+	Nonce []byte `gorm:"not null"`
 }
 
 // GetByHash returns LogTransactions existence by hash
@@ -39,6 +41,11 @@ func (lt *LogTransaction) GetByHash(hash []byte) (bool, error) {
 	return isFound(DBConn.Where("hash = ?", hash).First(lt))
 }
 
+// IsNonceUsed returns true if the transaction of the key with the nonce has been executed
+func IsNonceUsed(transaction *DbTransaction, keyID int64, nonce []byte) (bool, error) {
+	return isFound(GetDB(transaction).Where("key_id = ? AND nonce = ?", keyID, nonce).First(&LogTransaction{}))
+}
+
 // Create is creating record of model
 func (lt *LogTransaction) Create(transaction *DbTransaction) error {
 	return GetDB(transaction).Create(lt).Error
@@ -48,7 +48,7 @@ type ExplainQueryCoster struct {
 }
 
 func (*ExplainQueryCoster) QueryCost(transaction *model.DbTransaction, query string, args ...interface{}) (int64, error) {
-	return explainQueryCost(transaction, true, query, args...)
+	return explainQueryCost(transaction, false, query, args...)
 }
 
 type ExplainAnalyzeQueryCoster struct {
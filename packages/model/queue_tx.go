@@ -34,9 +34,11 @@ import (
 
 // QueueTx is model
 type QueueTx struct {
-	Hash     []byte `gorm:"primary_key;not null"`
-	Data     []byte `gorm:"not null"`
-	FromGate int    `gorm:"not null"`
+	Hash       []byte `gorm:"primary_key;not null"`
+	Data       []byte `gorm:"not null"`
+	FromGate   int    `gorm:"not null"`
+	Batch      []byte `gorm:"not null"`
+	BatchIndex int    `gorm:"not null"`
 }
 
 // TableName returns name of table
@@ -70,6 +72,13 @@ func DeleteQueueTxByHash(transaction *DbTransaction, hash []byte) (int64, error)
 	return query.RowsAffected, query.Error
 }
 
+// GetQueueTxs returns the first limit queued transactions
+func GetQueueTxs(limit int) ([]QueueTx, error) {
+	var txs []QueueTx
+	err := DBConn.Order("batch, batch_index").Limit(limit).Find(&txs).Error
+	return txs, err
+}
+
 // GetQueuedTransactionsCount counting queued transactions
 func GetQueuedTransactionsCount(hash []byte) (int64, error) {
 	var rowsCount int64
@@ -82,11 +91,15 @@ func GetAllUnverifiedAndUnusedTransactions() ([]*QueueTx, error) {
 	query := `SELECT *
 		  FROM (
 	              SELECT data,
-	                     hash
+	                     hash,
+	                     batch,
+	                     batch_index
 	              FROM queue_tx
 		      UNION
 		      SELECT data,
-			     hash
+			     hash,
+			     batch,
+			     batch_index
 		      FROM transactions
 		      WHERE verified = 0 AND used = 0
 			)  AS x`
@@ -95,13 +108,16 @@ func GetAllUnverifiedAndUnusedTransactions() ([]*QueueTx, error) {
 		return nil, err
 	}
 	defer rows.Close()
-	var data, hash []byte
 	result := []*QueueTx{}
 	for rows.Next() {
-		if err := rows.Scan(&data, &hash); err != nil {
+		var (
+			data, hash, batch []byte
+			batchIndex        int
+		)
+		if err := rows.Scan(&data, &hash, &batch, &batchIndex); err != nil {
 			return nil, err
 		}
-		result = append(result, &QueueTx{Data: data, Hash: hash})
+		result = append(result, &QueueTx{Data: data, Hash: hash, Batch: batch, BatchIndex: batchIndex})
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -118,6 +134,10 @@ func (qt QueueTx) FieldValue(fieldName string) (interface{}, error) {
 		return qt.Data, nil
 	case "from_gate":
 		return qt.FromGate, nil
+	case "batch":
+		return qt.Batch, nil
+	case "batch_index":
+		return qt.BatchIndex, nil
 	default:
 		return nil, fmt.Errorf("Unknown field '%s' for QueueTx", fieldName)
 	}
@@ -0,0 +1,71 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package model
+
+// RevokedSession is model of the revoked api session,
+// the empty SessionID means that all sessions of the key issued before RevokedAt are revoked,
+// RevokedAt is in nanoseconds and ExpiresAt is in seconds
+type RevokedSession struct {
+	ID        int64  `gorm:"primary_key;not null"`
+	SessionID string `gorm:"not null"`
+	KeyID     int64  `gorm:"not null"`
+	RevokedAt int64  `gorm:"not null"`
+	ExpiresAt int64  `gorm:"not null"`
+}
+
+// TableName returns name of table
+func (rs *RevokedSession) TableName() string {
+	return "revoked_sessions"
+}
+
+// Create is creating record of model
+func (rs *RevokedSession) Create() error {
+	return DBConn.Create(rs).Error
+}
+
+// CreateOnce is creating the revocation of the session if it doesn't exist yet,
+// it returns false if the session has already been revoked
+func (rs *RevokedSession) CreateOnce() (bool, error) {
+	query := DBConn.Exec(`INSERT INTO "revoked_sessions" (session_id, key_id, revoked_at, expires_at)
+		VALUES (?, ?, ?, ?) ON CONFLICT (session_id) WHERE session_id <> '' DO NOTHING`,
+		rs.SessionID, rs.KeyID, rs.RevokedAt, rs.ExpiresAt)
+	return query.RowsAffected > 0, query.Error
+}
+
+// GetActive returns revocations which haven't expired at the time
+func (rs *RevokedSession) GetActive(now int64) ([]RevokedSession, error) {
+	var sessions []RevokedSession
+	err := DBConn.Where("expires_at > ?", now).Find(&sessions).Error
+	return sessions, err
+}
+
+// DeleteExpired is deleting revocations which have expired at the time
+func (rs *RevokedSession) DeleteExpired(now int64) error {
+	return DBConn.Where("expires_at <= ?", now).Delete(&RevokedSession{}).Error
+}
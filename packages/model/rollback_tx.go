@@ -48,6 +48,16 @@ func (rt *RollbackTx) GetRollbackTransactions(dbTransaction *DbTransaction, tran
 	return GetAllTx(dbTransaction, "SELECT * from rollback_tx WHERE tx_hash = ? ORDER BY ID DESC", -1, transactionHash)
 }
 
+// GetRollbackTransactionsFrom returns rollback transactions of the transactions txHashes and
+// of the blocks after blockID, the latest records go first
+func (rt *RollbackTx) GetRollbackTransactionsFrom(dbTransaction *DbTransaction, txHashes [][]byte, blockID int64) ([]map[string]string, error) {
+	if len(txHashes) == 0 {
+		return GetAllTransaction(dbTransaction, `SELECT * from rollback_tx WHERE block_id > ? ORDER BY ID DESC`, -1, blockID)
+	}
+	return GetAllTransaction(dbTransaction, `SELECT * from rollback_tx WHERE tx_hash IN (?) OR block_id > ?
+		ORDER BY ID DESC`, -1, txHashes, blockID)
+}
+
 // GetBlockRollbackTransactions returns records of rollback by blockID
 func (rt *RollbackTx) GetBlockRollbackTransactions(dbTransaction *DbTransaction, blockID int64) ([]RollbackTx, error) {
 	var rollbackTransactions []RollbackTx
@@ -70,6 +80,13 @@ func (rt *RollbackTx) DeleteByHash(dbTransaction *DbTransaction) error {
 	return GetDB(dbTransaction).Exec("DELETE FROM rollback_tx WHERE tx_hash = ?", rt.TxHash).Error
 }
 
+// DeleteRollbackTxsBefore deletes at most limit records of the blocks before blockID
+func DeleteRollbackTxsBefore(blockID, limit int64) (int64, error) {
+	query := DBConn.Exec(`DELETE FROM rollback_tx WHERE id IN (
+		SELECT id FROM rollback_tx WHERE block_id < ? LIMIT ?)`, blockID, limit)
+	return query.RowsAffected, query.Error
+}
+
 // DeleteByHashAndTableName is deleting tx by hash and table name
 func (rt *RollbackTx) DeleteByHashAndTableName(transaction *DbTransaction) error {
 	return GetDB(transaction).Where("tx_hash = ? and table_name = ?", rt.TxHash, rt.NameTable).Delete(rt).Error
@@ -0,0 +1,237 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package model
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// SnapshotColumn is the column of the table in the state snapshot
+type SnapshotColumn struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default string
+}
+
+// Definition returns the column definition for CREATE TABLE and ALTER TABLE statements
+func (c SnapshotColumn) Definition() string {
+	def := `"` + c.Name + `" ` + c.Type
+	if c.Default != "" {
+		def += " DEFAULT " + c.Default
+	}
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	return def
+}
+
+// SnapshotIndex is the index on the columns of the table in the state snapshot
+type SnapshotIndex struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+// Definition returns the statement which creates the index on the table
+func (i SnapshotIndex) Definition(tblname string) string {
+	columns := `"` + strings.Join(i.Columns, `","`) + `"`
+	switch {
+	case i.Primary:
+		return `ALTER TABLE "` + tblname + `" ADD CONSTRAINT "` + i.Name + `" PRIMARY KEY (` + columns + `)`
+	case i.Unique:
+		return `CREATE UNIQUE INDEX "` + i.Name + `" ON "` + tblname + `" (` + columns + `)`
+	}
+	return `CREATE INDEX "` + i.Name + `" ON "` + tblname + `" (` + columns + `)`
+}
+
+// SetRepeatableRead makes the transaction see the state at the moment of its first query.
+// It must be called before any other query of the transaction.
+func SetRepeatableRead(transaction *DbTransaction) error {
+	if err := GetDB(transaction).Exec(`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`).Error; err != nil {
+		return err
+	}
+	return SetCanonicalOutput(transaction)
+}
+
+// SetCanonicalOutput makes the text output of the values independent of the settings of the node
+// till the end of the transaction or the rollback to the savepoint
+func SetCanonicalOutput(transaction *DbTransaction) error {
+	return GetDB(transaction).Exec(`SET LOCAL TimeZone = 'UTC'; SET LOCAL DateStyle = 'ISO, YMD';
+		SET LOCAL IntervalStyle = 'postgres'; SET LOCAL bytea_output = 'hex'; SET LOCAL extra_float_digits = 3`).Error
+}
+
+// GetStateTables returns the names of the ecosystem tables in alphabetical order
+func GetStateTables(transaction *DbTransaction) ([]string, error) {
+	var tables []string
+	err := GetDB(transaction).Table("information_schema.tables").
+		Where(`table_type = 'BASE TABLE' AND table_schema = 'public' AND table_name ~ '^[0-9]+_'`).
+		Order("table_name").Pluck("table_name", &tables).Error
+	return tables, err
+}
+
+// IsTableTx returns true if the table exists in the transaction
+func IsTableTx(transaction *DbTransaction, tblname string) (bool, error) {
+	var count int64
+	err := GetDB(transaction).Table("information_schema.tables").
+		Where("table_type = 'BASE TABLE' AND table_schema = 'public' AND table_name = ?", tblname).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetSnapshotColumns returns the columns of the table ordered by name
+func GetSnapshotColumns(transaction *DbTransaction, tblname string) ([]SnapshotColumn, error) {
+	rows, err := GetDB(transaction).Raw(`SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = quote_ident(?)::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attname`, tblname).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []SnapshotColumn
+	for rows.Next() {
+		var col SnapshotColumn
+		if err = rows.Scan(&col.Name, &col.Type, &col.NotNull, &col.Default); err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// GetTableIndexes returns the indexes on the columns of the table ordered by name. The indexes
+// on the expressions and the partial indexes are skipped.
+func GetTableIndexes(transaction *DbTransaction, tblname string) ([]SnapshotIndex, error) {
+	rows, err := GetDB(transaction).Raw(`SELECT i.relname, ix.indisunique, ix.indisprimary,
+			array_to_string(ARRAY(SELECT a.attname FROM unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, n)
+				JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum ORDER BY k.n), ',')
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		WHERE ix.indrelid = quote_ident(?)::regclass AND ix.indexprs IS NULL AND ix.indpred IS NULL
+		ORDER BY i.relname`, tblname).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []SnapshotIndex
+	for rows.Next() {
+		var (
+			index   SnapshotIndex
+			columns string
+		)
+		if err = rows.Scan(&index.Name, &index.Unique, &index.Primary, &columns); err != nil {
+			return nil, err
+		}
+		index.Columns = strings.Split(columns, ",")
+		indexes = append(indexes, index)
+	}
+	return indexes, rows.Err()
+}
+
+// GetSnapshotRows returns the text values of the columns of all rows of the table ordered by id
+func GetSnapshotRows(transaction *DbTransaction, tblname string, columns []string) (*sql.Rows, error) {
+	fields := make([]string, len(columns))
+	for i, col := range columns {
+		fields[i] = `"` + col + `"::text`
+	}
+	return GetDB(transaction).Raw(`SELECT ` + strings.Join(fields, ",") + ` FROM "` + tblname + `" ORDER BY id`).Rows()
+}
+
+// CanonicalValue returns the expression which converts the value of the column to the text by
+// its type. The result doesn't depend on the settings of the session such as DateStyle, TimeZone,
+// extra_float_digits, bytea_output or lc_monetary.
+func CanonicalValue(col SnapshotColumn) string {
+	name := `"` + col.Name + `"`
+	switch {
+	case strings.HasSuffix(col.Type, "[]"):
+		return name + `::text`
+	case strings.HasPrefix(col.Type, "timestamp") && strings.HasSuffix(col.Type, " with time zone"):
+		return `to_char(` + name + ` AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US')`
+	case strings.HasPrefix(col.Type, "timestamp"):
+		return `to_char(` + name + `, 'YYYY-MM-DD"T"HH24:MI:SS.US')`
+	case col.Type == "date":
+		return `to_char(` + name + `, 'YYYY-MM-DD')`
+	case strings.HasPrefix(col.Type, "interval"):
+		return `to_char(` + name + `, 'YYYY-MM-DD HH24:MI:SS.US')`
+	case col.Type == "double precision":
+		return `encode(float8send(` + name + `), 'hex')`
+	case col.Type == "real":
+		return `encode(float4send(` + name + `), 'hex')`
+	case col.Type == "bytea":
+		return `encode(` + name + `, 'hex')`
+	case col.Type == "money":
+		return name + `::numeric::text`
+	}
+	return name + `::text`
+}
+
+// GetCanonicalRow returns the values of the row with the id which are converted by CanonicalValue
+func GetCanonicalRow(transaction *DbTransaction, tblname string, columns []SnapshotColumn, id string) (map[string]string, error) {
+	fields := make([]string, len(columns))
+	for i, col := range columns {
+		fields[i] = CanonicalValue(col) + ` AS "` + col.Name + `"`
+	}
+	return GetOneRowTransaction(transaction, `SELECT `+strings.Join(fields, ",")+` FROM "`+tblname+`" WHERE id = ?`,
+		id).String()
+}
+
+// InsertSnapshotRows inserts the text values of the rows to the table
+func InsertSnapshotRows(transaction *DbTransaction, tblname string, columns []string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	fields := make([]string, len(columns))
+	for i, col := range columns {
+		fields[i] = `"` + col + `"`
+	}
+	values := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		values[i] = "(?" + strings.Repeat(",?", len(row)-1) + ")"
+		args = append(args, row...)
+	}
+	return GetDB(transaction).Exec(`INSERT INTO "`+tblname+`" (`+strings.Join(fields, ",")+`) VALUES `+
+		strings.Join(values, ","), args...).Error
+}
+
+// ClearTable deletes all rows of the table
+func ClearTable(transaction *DbTransaction, tblname string) error {
+	return GetDB(transaction).Exec(`DELETE FROM "` + tblname + `"`).Error
+}
+
+// CreateSnapshotTable creates the table with the column definitions
+func CreateSnapshotTable(transaction *DbTransaction, tblname string, columns []string) error {
+	return GetDB(transaction).Exec(`CREATE TABLE "` + tblname + `" (` + strings.Join(columns, ",") + `)`).Error
+}
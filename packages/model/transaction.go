@@ -30,6 +30,8 @@ package model
 
 import (
 	"github.com/AplaProject/go-apla/packages/consts"
+
+	"github.com/shopspring/decimal"
 )
 
 // This constants contains values of transactions priority
@@ -42,16 +44,32 @@ type transactionRate int8
 
 // Transaction is model
 type Transaction struct {
-	Hash     []byte          `gorm:"private_key;not null"`
-	Data     []byte          `gorm:"not null"`
-	Used     int8            `gorm:"not null"`
-	HighRate transactionRate `gorm:"not null"`
-	Type     int8            `gorm:"not null"`
-	KeyID    int64           `gorm:"not null"`
-	Counter  int8            `gorm:"not null"`
-	Sent     int8            `gorm:"not null"`
-	Attempt  int8            `gorm:"not null"`
-	Verified int8            `gorm:"not null;default:1"`
+	Hash       []byte          `gorm:"private_key;not null"`
+	Data       []byte          `gorm:"not null"`
+	Used       int8            `gorm:"not null"`
+	HighRate   transactionRate `gorm:"not null"`
+	Type       int8            `gorm:"not null"`
+	KeyID      int64           `gorm:"not null"`
+	Counter    int8            `gorm:"not null"`
+	Sent       int8            `gorm:"not null"`
+	Attempt    int8            `gorm:"not null"`
+	Verified   int8            `gorm:"not null;default:1"`
+	Batch      []byte          `gorm:"not null"`
+	BatchIndex int             `gorm:"not null"`
+	Time       int64           `gorm:"not null"`
+	Priority   decimal.Decimal `gorm:"not null"`
+	MaxSum     int64           `gorm:"not null"`
+	Nonce      []byte          `gorm:"not null"`
+}
+
+// MempoolStats is the summary of the pending transactions
+type MempoolStats struct {
+	Count       int64
+	Size        int64
+	Keys        int64
+	Batches     int64
+	MinPriority decimal.Decimal
+	MaxPriority decimal.Decimal
 }
 
 // GetAllTransactions is retrieving all transactions with limit
@@ -63,16 +81,26 @@ func GetAllTransactions(limit int) (*[]Transaction, error) {
 	return transactions, nil
 }
 
-// GetAllUnusedTransactions is retrieving all unused transactions
+// GetAllUnusedTransactions is retrieving all unused transactions in the order of priority.
+// Transactions of the same batch follow each other in submission order and get the highest priority of the batch
 func GetAllUnusedTransactions(limit int) ([]*Transaction, error) {
 	var transactions []*Transaction
 
-	query := DBConn.Where("used = ?", "0").Order("high_rate DESC")
+	query := `WITH groups AS (
+			SELECT batch, max(priority) AS priority, max(max_sum) AS max_sum FROM transactions
+			WHERE used = 0 AND batch != '' GROUP BY batch
+		)
+		SELECT t.* FROM transactions t LEFT JOIN groups g ON g.batch = t.batch
+		WHERE t.used = 0
+		ORDER BY t.high_rate DESC, coalesce(g.priority, t.priority) DESC, coalesce(g.max_sum, t.max_sum) DESC,
+			t.batch, t.batch_index, t.time`
+	args := []interface{}{}
 	if limit > 0 {
-		query = query.Limit(limit)
+		query += ` LIMIT ?`
+		args = append(args, limit)
 	}
 
-	if err := query.Find(&transactions).Error; err != nil {
+	if err := DBConn.Raw(query, args...).Scan(&transactions).Error; err != nil {
 		return nil, err
 	}
 	return transactions, nil
@@ -81,7 +109,7 @@ func GetAllUnusedTransactions(limit int) ([]*Transaction, error) {
 // GetAllUnsentTransactions is retrieving all unset transactions
 func GetAllUnsentTransactions() (*[]Transaction, error) {
 	transactions := new([]Transaction)
-	if err := DBConn.Where("sent = ?", "0").Find(transactions).Error; err != nil {
+	if err := DBConn.Where("sent = ?", "0").Order("high_rate DESC, priority DESC").Find(transactions).Error; err != nil {
 		return nil, err
 	}
 	return transactions, nil
@@ -206,3 +234,74 @@ func GetManyTransactions(dbtx *DbTransaction, hashes [][]byte) ([]Transaction, e
 
 	return txes, nil
 }
+
+// GetTransactionBatch returns the batch of the pending transaction or nil if it was sent alone
+func GetTransactionBatch(transaction *DbTransaction, transactionHash []byte) ([]byte, error) {
+	var batch [][]byte
+	err := GetDB(transaction).Raw(`SELECT batch FROM queue_tx WHERE hash = ?
+		UNION SELECT batch FROM transactions WHERE hash = ?`, transactionHash, transactionHash).Pluck("batch", &batch).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range batch {
+		if len(item) > 0 {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetBatchHashes returns the hashes of transactions of the batch which are not used yet
+func GetBatchHashes(transaction *DbTransaction, batch []byte) ([][]byte, error) {
+	var hashes [][]byte
+	err := GetDB(transaction).Raw(`SELECT hash FROM queue_tx WHERE batch = ?
+		UNION SELECT hash FROM transactions WHERE batch = ? AND used = 0`, batch, batch).Pluck("hash", &hashes).Error
+	return hashes, err
+}
+
+// GetBatchCounts returns the count of verified transactions of the batch
+// and the count of its transactions which are still waiting in the queue
+func GetBatchCounts(batch []byte) (verified int64, queued int64, err error) {
+	if err = DBConn.Table("transactions").Where("batch = ? AND used = 0 AND verified = 1", batch).Count(&verified).Error; err != nil {
+		return
+	}
+	err = DBConn.Table("queue_tx").Where("batch = ?", batch).Count(&queued).Error
+	return
+}
+
+// GetPendingTransactionByNonce returns the verified unused transaction of the key with the same nonce
+func GetPendingTransactionByNonce(transaction *DbTransaction, keyID int64, nonce []byte) (*Transaction, bool, error) {
+	t := &Transaction{}
+	found, err := isFound(GetDB(transaction).Where("key_id = ? AND nonce = ? AND used = 0 AND verified = 1", keyID, nonce).First(t))
+	return t, found, err
+}
+
+// GetPendingTransactionsCount returns the count of the verified unused transactions of the key,
+// the count of all of them is returned if keyID is 0
+func GetPendingTransactionsCount(transaction *DbTransaction, keyID int64) (count int64, err error) {
+	query := GetDB(transaction).Table("transactions").Where("used = 0 AND verified = 1")
+	if keyID != 0 {
+		query = query.Where("key_id = ?", keyID)
+	}
+	err = query.Count(&count).Error
+	return
+}
+
+// GetLowestPriorityTransaction returns the pending transaction which is evicted first when the mempool is full.
+// Transactions of batches and the high rate transactions are never evicted
+func GetLowestPriorityTransaction(transaction *DbTransaction) (*Transaction, bool, error) {
+	t := &Transaction{}
+	found, err := isFound(GetDB(transaction).Where("used = 0 AND verified = 1 AND high_rate = 0 AND batch = ''").
+		Order("priority, max_sum, time DESC").First(t))
+	return t, found, err
+}
+
+// GetMempoolStats returns the summary of the verified unused transactions
+func GetMempoolStats() (*MempoolStats, error) {
+	stats := &MempoolStats{}
+	err := DBConn.Raw(`SELECT count(*), coalesce(sum(length(data)), 0), count(DISTINCT key_id),
+		count(DISTINCT nullif(batch, '')), coalesce(min(priority), 0), coalesce(max(priority), 0)
+		FROM transactions WHERE used = 0 AND verified = 1`).Row().Scan(&stats.Count, &stats.Size,
+		&stats.Keys, &stats.Batches, &stats.MinPriority, &stats.MaxPriority)
+	return stats, err
+}
@@ -0,0 +1,52 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package model
+
+// ValidatorSet is the value of full_nodes which becomes active at the block Activation
+type ValidatorSet struct {
+	ID         int64  `gorm:"primary_key;not null"`
+	Value      string `gorm:"not null"`
+	Activation int64  `gorm:"not null"`
+	BlockID    int64  `gorm:"not null"`
+}
+
+// TableName returns name of table
+func (ValidatorSet) TableName() string {
+	return "1_validator_sets"
+}
+
+// GetValidatorSets returns all validator sets in the order of activation. The table is created
+// by the update migrations so there are no sets while the first block is being played
+func GetValidatorSets(transaction *DbTransaction) ([]ValidatorSet, error) {
+	var sets []ValidatorSet
+	if ok, err := IsTableTx(transaction, ValidatorSet{}.TableName()); err != nil || !ok {
+		return sets, err
+	}
+	err := GetDB(transaction).Order("activation, id").Find(&sets).Error
+	return sets, err
+}
@@ -16,25 +16,39 @@ import (
 	msgpack "gopkg.in/vmihailenco/msgpack.v2"
 )
 
-var ErrDiffKey = errors.New("Different keys")
+var (
+	ErrDiffKey        = errors.New("Different keys")
+	ErrBatchDuplicate = errors.New("Duplicated transaction in batch")
+	ErrBatchOBS       = errors.New("Batches are not supported in OBS mode")
+	ErrBatchReplica   = errors.New("Batches are not supported by replicas")
+)
 
 type blockchainTxPreprocessor struct{}
 
-func (p blockchainTxPreprocessor) ProcessClientTranstaction(txData []byte, key int64, le *log.Entry) (string, error) {
+func (p blockchainTxPreprocessor) parseClientTx(txData []byte, key int64, le *log.Entry) (*transaction.RawTransaction, error) {
 	rtx := &transaction.RawTransaction{}
 	if err := rtx.Unmarshall(bytes.NewBuffer(txData)); err != nil {
 		le.WithFields(log.Fields{"error": err}).Error("on unmarshalling to raw tx")
-		return "", err
+		return nil, err
 	}
 
 	smartTx := tx.SmartContract{}
 	if err := msgpack.Unmarshal(rtx.Payload(), &smartTx); err != nil {
 		le.WithFields(log.Fields{"error": err}).Error("on unmarshalling to sc")
-		return "", err
+		return nil, err
 	}
 
 	if smartTx.Header.KeyID != key {
-		return "", ErrDiffKey
+		return nil, ErrDiffKey
+	}
+
+	return rtx, nil
+}
+
+func (p blockchainTxPreprocessor) ProcessClientTranstaction(txData []byte, key int64, le *log.Entry) (string, error) {
+	rtx, err := p.parseClientTx(txData, key, le)
+	if err != nil {
+		return "", err
 	}
 
 	if err := model.SendTx(rtx, key); err != nil {
@@ -45,6 +59,40 @@ func (p blockchainTxPreprocessor) ProcessClientTranstaction(txData []byte, key i
 	return string(converter.BinToHex(rtx.Hash())), nil
 }
 
+// ProcessClientTxBatch checks all transactions of the batch before queueing them,
+// so the batch is either queued entirely or rejected
+func (p blockchainTxPreprocessor) ProcessClientTxBatch(txsData [][]byte, key int64, le *log.Entry) ([]string, string, error) {
+	// the replica forwards the transactions one by one so the batch can't be kept together
+	if conf.Config.IsReplica() {
+		return nil, "", ErrBatchReplica
+	}
+	rtxs := make([]model.RawTransaction, 0, len(txsData))
+	hashes := make([]string, 0, len(txsData))
+	unique := make(map[string]bool, len(txsData))
+	for _, txData := range txsData {
+		rtx, err := p.parseClientTx(txData, key, le)
+		if err != nil {
+			return nil, "", err
+		}
+		hash := string(converter.BinToHex(rtx.Hash()))
+		if unique[hash] {
+			le.WithFields(log.Fields{"type": consts.DuplicateObject, "tx_hash": hash}).Error("duplicated tx in batch")
+			return nil, "", ErrBatchDuplicate
+		}
+		unique[hash] = true
+		rtxs = append(rtxs, rtx)
+		hashes = append(hashes, hash)
+	}
+
+	batch, err := model.SendBatchTx(rtxs, key)
+	if err != nil {
+		le.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("sending batch")
+		return nil, "", err
+	}
+
+	return hashes, string(converter.BinToHex(batch)), nil
+}
+
 type ObsTxPreprocessor struct{}
 
 func (p ObsTxPreprocessor) ProcessClientTranstaction(txData []byte, key int64, le *log.Entry) (string, error) {
@@ -82,6 +130,11 @@ func (p ObsTxPreprocessor) ProcessClientTranstaction(txData []byte, key int64, l
 	return string(converter.BinToHex(tx.TxHash)), nil
 }
 
+// ProcessClientTxBatch isn't supported because OBS executes contracts immediately
+func (p ObsTxPreprocessor) ProcessClientTxBatch(txsData [][]byte, key int64, le *log.Entry) ([]string, string, error) {
+	return nil, "", ErrBatchOBS
+}
+
 func GetClientTxPreprocessor() types.ClientTxPreprocessor {
 	if conf.Config.IsSupportingOBS() {
 		return ObsTxPreprocessor{}
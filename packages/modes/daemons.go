@@ -6,6 +6,10 @@ import (
 )
 
 func GetDaemonListFactory() types.DaemonListFactory {
+	if conf.Config.IsReplica() {
+		return ReplicaDaemonsListFactory{}
+	}
+
 	if !conf.Config.IsSupportingOBS() {
 		return BlockchainDaemonsListsFactory{}
 	}
@@ -24,7 +28,24 @@ func (f BlockchainDaemonsListsFactory) GetDaemonsList() []string {
 		"Disseminator",
 		"Confirmations",
 		"Scheduler",
+		"PeersDiscovery",
+		"Finalizer",
+	}
+}
+
+// ReplicaDaemonsListFactory returns the daemons of the read-only replica which only follows the chain
+type ReplicaDaemonsListFactory struct{}
+
+func (f ReplicaDaemonsListFactory) GetDaemonsList() []string {
+	list := []string{
+		"BlocksCollection",
+		"TxForwarder",
+		"PeersDiscovery",
+	}
+	if !conf.Config.Replica.KeepHistory {
+		list = append(list, "RollbackPruner")
 	}
+	return list
 }
 
 type OBSDaemonsListFactory struct{}
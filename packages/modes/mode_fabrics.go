@@ -11,6 +11,7 @@ import (
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/daemons"
 	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/network"
 	"github.com/AplaProject/go-apla/packages/network/tcpserver"
 	"github.com/AplaProject/go-apla/packages/service"
 	"github.com/AplaProject/go-apla/packages/smart"
@@ -132,6 +133,9 @@ func (l BCDaemonLoader) Load(ctx context.Context) error {
 	if syspar.IsPrivateBlockchain() {
 		mode = "Private Blockchain"
 	}
+	if conf.Config.IsReplica() {
+		mode += " replica"
+	}
 
 	logMode(l.logger, mode)
 
@@ -141,6 +145,10 @@ func (l BCDaemonLoader) Load(ctx context.Context) error {
 		return err
 	}
 
+	if err := initTransport(); err != nil {
+		return err
+	}
+
 	l.logger.Info("start daemons")
 	daemons.StartDaemons(ctx, l.DaemonListFactory.GetDaemonsList())
 
@@ -169,6 +177,23 @@ func (l BCDaemonLoader) Load(ctx context.Context) error {
 	return nil
 }
 
+// initTransport creates TLS configuration of the node connections. It is required only
+// if the secure transport is enabled otherwise the node accepts the plain connections.
+func initTransport() error {
+	nodePrivateKey, err := utils.GetNodePrivateKey()
+	if err == nil {
+		err = network.InitTransport(nodePrivateKey)
+	}
+	if err != nil {
+		if conf.Config.TCPServer.Secure || conf.Config.TCPServer.Strict {
+			log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("initializing secure transport")
+			return err
+		}
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Warn("secure transport is not available")
+	}
+	return nil
+}
+
 // OBSDaemonLoader allows load obs daemons
 type OBSDaemonLoader struct {
 	logger            *log.Entry
@@ -190,6 +215,10 @@ func (l OBSDaemonLoader) Load(ctx context.Context) error {
 		return err
 	}
 
+	if err := initTransport(); err != nil {
+		return err
+	}
+
 	l.logger.Info("start daemons")
 	daemons.StartDaemons(ctx, l.DaemonListFactory.GetDaemonsList())
 
@@ -206,7 +235,7 @@ func GetDaemonLoader() types.DaemonLoader {
 
 	return BCDaemonLoader{
 		logger:            log.WithFields(log.Fields{"loader": "blockchain_daemon_loader"}),
-		DaemonListFactory: BlockchainDaemonsListsFactory{},
+		DaemonListFactory: GetDaemonListFactory(),
 	}
 }
 
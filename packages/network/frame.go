@@ -0,0 +1,234 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Kinds of the frames
+const (
+	frameData  = 0
+	frameError = 1
+)
+
+const (
+	// frameHeaderSize is the size of the payload length, the frame kind and the checksum
+	frameHeaderSize = 9
+	// MaxFrameSize is the max size of the frame payload, the larger messages are split
+	MaxFrameSize = 1 << 20
+)
+
+// ErrChecksum is returned when the checksum of the received frame is wrong
+var ErrChecksum = errors.New("Wrong frame checksum")
+
+// FramedConn is the connection which sends the data with the length prefixed frames with
+// CRC32 checksums. The written data is buffered and is sent either before the next reading,
+// when the frame is full or on Flush and Close.
+type FramedConn struct {
+	net.Conn
+	// Peer is the handshake of the remote node
+	Peer Handshake
+	// Version is the negotiated version of the protocol, it is the lower of the versions of the nodes
+	Version uint16
+
+	frame  []byte
+	output bytes.Buffer
+}
+
+// NewFramedConn returns the framed connection
+func NewFramedConn(conn net.Conn) *FramedConn {
+	return &FramedConn{Conn: conn}
+}
+
+// ClientHandshake sends the handshake request and returns the connection with the negotiated
+// protocol. The legacy nodes close the connection so the error is returned.
+func ClientHandshake(conn net.Conn) (*FramedConn, error) {
+	rt := &RequestType{Type: RequestTypeHandshake}
+	if err := rt.Write(conn); err != nil {
+		return nil, err
+	}
+	c := NewFramedConn(conn)
+	if err := NodeHandshake().Write(c); err != nil {
+		return nil, err
+	}
+	if err := c.readHandshake(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AcceptHandshake answers the handshake request which type has already been read
+func AcceptHandshake(conn net.Conn) (*FramedConn, error) {
+	c := NewFramedConn(conn)
+	if err := c.readHandshake(); err != nil {
+		return nil, err
+	}
+	if err := NodeHandshake().Write(c); err != nil {
+		return nil, err
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// PeerHas returns true if the remote node of the connection has negotiated the capability
+func PeerHas(conn io.Reader, capability uint64) bool {
+	framed, ok := conn.(*FramedConn)
+	return ok && framed.Peer.Has(capability)
+}
+
+func (c *FramedConn) readHandshake() error {
+	if err := c.Peer.Read(c); err != nil {
+		return err
+	}
+	if c.Peer.Version < MinProtocolVersion {
+		log.WithFields(log.Fields{"type": consts.ProtocolError, "version": c.Peer.Version}).Error("unsupported protocol version")
+		return ErrProtocolVersion
+	}
+	c.Version = ProtocolVersion
+	if c.Peer.Version < c.Version {
+		c.Version = c.Peer.Version
+	}
+	return nil
+}
+
+func (c *FramedConn) Read(b []byte) (int, error) {
+	if err := c.Flush(); err != nil {
+		return 0, err
+	}
+	for len(c.frame) == 0 {
+		if err := c.readFrame(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, c.frame)
+	c.frame = c.frame[n:]
+	return n, nil
+}
+
+func (c *FramedConn) Write(b []byte) (int, error) {
+	c.output.Write(b)
+	for c.output.Len() >= MaxFrameSize {
+		if err := c.writeFrame(frameData, c.output.Next(MaxFrameSize)); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends the buffered data
+func (c *FramedConn) Flush() error {
+	if c.output.Len() == 0 {
+		return nil
+	}
+	defer c.output.Reset()
+	return c.writeFrame(frameData, c.output.Bytes())
+}
+
+// Close sends the buffered data and closes the connection
+func (c *FramedConn) Close() error {
+	err := c.Flush()
+	if errClose := c.Conn.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// WriteError sends the error response. The buffered data is sent before it.
+func (c *FramedConn) WriteError(perr *ProtocolError) error {
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := perr.Write(buf); err != nil {
+		return err
+	}
+	return c.writeFrame(frameError, buf.Bytes())
+}
+
+func (c *FramedConn) writeFrame(kind byte, payload []byte) error {
+	header := make([]byte, frameHeaderSize)
+	binary.LittleEndian.PutUint32(header, uint32(len(payload)))
+	header[4] = kind
+	binary.LittleEndian.PutUint32(header[5:], crc32.ChecksumIEEE(payload))
+	if _, err := c.Conn.Write(append(header, payload...)); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on sending frame")
+		return err
+	}
+	return nil
+}
+
+func (c *FramedConn) readFrame() error {
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(c.Conn, header); err != nil {
+		return err
+	}
+	size := binary.LittleEndian.Uint32(header)
+	if size > MaxFrameSize {
+		log.WithFields(log.Fields{"type": consts.ProtocolError, "size": size}).Error("frame size exceeds max size")
+		return ErrMaxSize
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.Conn, payload); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading frame payload")
+		return err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[5:]) {
+		log.WithFields(log.Fields{"type": consts.ProtocolError}).Error("frame checksum mismatch")
+		return ErrChecksum
+	}
+	if header[4] == frameError {
+		perr := &ProtocolError{}
+		if err := perr.Read(bytes.NewReader(payload)); err != nil {
+			return err
+		}
+		return perr
+	}
+	c.frame = payload
+	return nil
+}
+
+// SendError sends the error response if the connection supports it. The legacy
+// connections can only be closed.
+func SendError(w io.Writer, code uint16, err error) error {
+	if c, ok := w.(*FramedConn); ok {
+		return c.WriteError(&ProtocolError{Code: code, Message: err.Error()})
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package network
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandshake(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	data := bytes.Repeat([]byte{1, 2, 3}, MaxFrameSize)
+	done := make(chan error, 1)
+	go func() {
+		rt := &RequestType{}
+		if err := rt.Read(serverConn); err != nil {
+			done <- err
+			return
+		}
+		require.Equal(t, uint16(RequestTypeHandshake), rt.Type)
+		conn, err := AcceptHandshake(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		req := &GetBodyResponse{}
+		if err = req.Read(conn); err == nil {
+			err = req.Write(conn)
+		}
+		if err == nil {
+			err = conn.Close()
+		}
+		done <- err
+	}()
+
+	conn, err := ClientHandshake(clientConn)
+	require.NoError(t, err)
+	require.Equal(t, uint16(ProtocolVersion), conn.Peer.Version)
+	require.Equal(t, uint16(ProtocolVersion), conn.Version)
+	require.True(t, conn.Peer.Has(CapMaxBlock))
+
+	req := &GetBodyResponse{Data: data}
+	require.NoError(t, req.Write(conn))
+	resp := &GetBodyResponse{}
+	require.NoError(t, resp.Read(conn))
+	require.Equal(t, data, resp.Data)
+	require.NoError(t, <-done)
+}
+
+func TestHandshakeVersion(t *testing.T) {
+	for _, item := range []struct {
+		peer    uint16
+		version uint16
+		err     error
+	}{
+		{ProtocolVersion + 1, ProtocolVersion, nil},
+		{MinProtocolVersion, MinProtocolVersion, nil},
+		{MinProtocolVersion - 1, 0, ErrProtocolVersion},
+	} {
+		serverConn, clientConn := net.Pipe()
+		go func() {
+			peer := NewFramedConn(serverConn)
+			(&Handshake{Version: item.peer}).Write(peer)
+			peer.Close()
+		}()
+		conn := NewFramedConn(clientConn)
+		require.Equal(t, item.err, conn.readHandshake())
+		require.Equal(t, item.version, conn.Version)
+	}
+}
+
+func TestFrameError(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		conn := NewFramedConn(serverConn)
+		conn.Write([]byte("partial"))
+		SendError(conn, ErrCodeUnknownRequest, ErrUnknownRequest)
+		conn.Close()
+	}()
+
+	conn := NewFramedConn(clientConn)
+	buf := make([]byte, 7)
+	_, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "partial", string(buf))
+	_, err = conn.Read(buf)
+	require.Equal(t, &ProtocolError{Code: ErrCodeUnknownRequest, Message: ErrUnknownRequest.Error()}, err)
+}
+
+func TestFrameChecksum(t *testing.T) {
+	buf := &bytes.Buffer{}
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		conn := NewFramedConn(serverConn)
+		conn.Write([]byte("data"))
+		conn.Close()
+	}()
+	frame, err := ioutil.ReadAll(clientConn)
+	require.NoError(t, err)
+	frame[len(frame)-1] ^= 0xff
+	buf.Write(frame)
+
+	serverConn, clientConn = net.Pipe()
+	go func() {
+		serverConn.Write(buf.Bytes())
+		serverConn.Close()
+	}()
+	_, err = ioutil.ReadAll(NewFramedConn(clientConn))
+	require.Equal(t, ErrChecksum, err)
+}
@@ -0,0 +1,330 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+// Package peers keeps the table of the known nodes and scores them by the results of the
+// requests. The validators are known from full_nodes, other nodes are discovered by gossip.
+package peers
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/network"
+)
+
+const (
+	// the discovered peer is removed if it hasn't responded during peerExpiration
+	peerExpiration = time.Hour
+	// maxDiscovered is the maximum count of the discovered peers in the table
+	maxDiscovered = 1000
+	// the peer which has never responded is removed after maxUncheckedFailures failed requests
+	maxUncheckedFailures = 3
+	// latencyWeight is the weight of the last measured latency in the average latency
+	latencyWeight = 0.2
+
+	maxScore          = 100.0
+	latencyPenalty    = 10.0 // points for every 100ms of the average latency
+	failurePenalty    = 50.0 // points for 100% of the failed requests
+	badBlockPenalty   = 50.0 // points for every bad block
+	stalePenalty      = 10.0 // the peer hasn't responded during staleTime
+	validatorPenalty  = 15.0 // the non-validating node prefers other replicas to the validators
+	staleTime         = 10 * time.Minute
+	maxPenaltyLatency = 5 * time.Second
+)
+
+// Peer is the record of the peer table
+type Peer struct {
+	Host         string
+	Discovered   bool // the peer has been learned from other nodes
+	Latency      time.Duration
+	Requests     int64
+	Failures     int64
+	BadBlocks    int64
+	LastBadBlock time.Time
+	LastSeen     time.Time
+	AddedAt      time.Time
+}
+
+// Manager is the scored peer table
+type Manager struct {
+	mu    sync.RWMutex
+	peers map[string]*Peer
+	now   func() time.Time
+}
+
+var manager = NewManager()
+
+// GetManager returns the peer manager of the node
+func GetManager() *Manager {
+	return manager
+}
+
+// NewManager returns the empty peer table
+func NewManager() *Manager {
+	return &Manager{
+		peers: make(map[string]*Peer),
+		now:   time.Now,
+	}
+}
+
+func (m *Manager) peer(host string) *Peer {
+	p, ok := m.peers[host]
+	if !ok {
+		p = &Peer{Host: host, AddedAt: m.now()}
+		m.peers[host] = p
+	}
+	return p
+}
+
+// AddPeer adds the host which has been discovered from other nodes, it returns false if the host
+// is already known or can't be added. If the table is full the peer which has never responded is
+// replaced, the peers which have responded are kept.
+func (m *Manager) AddPeer(host string) bool {
+	if len(host) == 0 || host == conf.Config.TCPServer.Str() || isValidatorHost(host) {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.peers[host]; ok {
+		return false
+	}
+	if m.countDiscovered() >= maxDiscovered && !m.removeUnchecked() {
+		return false
+	}
+	m.peer(host).Discovered = true
+	return true
+}
+
+func (m *Manager) countDiscovered() (count int) {
+	for _, p := range m.peers {
+		if p.Discovered {
+			count++
+		}
+	}
+	return
+}
+
+// removeUnchecked removes the earliest added peer which has never responded
+func (m *Manager) removeUnchecked() bool {
+	var oldest *Peer
+	for _, p := range m.peers {
+		if p.Discovered && p.LastSeen.IsZero() && (oldest == nil || p.AddedAt.Before(oldest.AddedAt)) {
+			oldest = p
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(m.peers, oldest.Host)
+	return true
+}
+
+// RecordSuccess registers the successful request to the host
+func (m *Manager) RecordSuccess(host string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.peer(host)
+	if p.Requests == p.Failures {
+		p.Latency = latency
+	} else {
+		p.Latency = time.Duration((1-latencyWeight)*float64(p.Latency) + latencyWeight*float64(latency))
+	}
+	p.Requests++
+	p.LastSeen = m.now()
+}
+
+// RecordFailure registers the failed request to the host
+func (m *Manager) RecordFailure(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.peer(host)
+	p.Requests++
+	p.Failures++
+}
+
+// RecordBadBlock registers the incorrect block which has been received from the host
+func (m *Manager) RecordBadBlock(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.peer(host)
+	p.BadBlocks++
+	p.LastBadBlock = m.now()
+}
+
+// Score returns the score of the host, the better host has the greater score
+func (m *Manager) Score(host string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.score(host, isValidator())
+}
+
+func (m *Manager) score(host string, validator bool) float64 {
+	score := maxScore
+	if !validator && isValidatorHost(host) {
+		score -= validatorPenalty
+	}
+	p, ok := m.peers[host]
+	if !ok {
+		return score
+	}
+	latency := p.Latency
+	if latency > maxPenaltyLatency {
+		latency = maxPenaltyLatency
+	}
+	score -= latencyPenalty * float64(latency) / float64(100*time.Millisecond)
+	if p.Requests > 0 {
+		score -= failurePenalty * float64(p.Failures) / float64(p.Requests)
+	}
+	score -= badBlockPenalty * float64(p.BadBlocks)
+	if m.now().Sub(p.LastSeen) > staleTime {
+		score -= stalePenalty
+	}
+	return score
+}
+
+// Sort sorts hosts by score, the best hosts go first
+func (m *Manager) Sort(hosts []string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	validator := isValidator()
+	scores := make(map[string]float64, len(hosts))
+	for _, host := range hosts {
+		scores[host] = m.score(host, validator)
+	}
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return scores[hosts[i]] > scores[hosts[j]]
+	})
+}
+
+// Filter removes hosts which have sent the bad block during the local ban time
+func (m *Manager) Filter(hosts []string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	banTime := syspar.GetLocalNodeBanTime()
+	ret := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if p, ok := m.peers[host]; ok && p.BadBlocks > 0 && m.now().Sub(p.LastBadBlock) < banTime {
+			continue
+		}
+		ret = append(ret, host)
+	}
+	return ret
+}
+
+// Discovered returns the discovered hosts which have responded at least once
+func (m *Manager) Discovered() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ret := make([]string, 0)
+	for host, p := range m.peers {
+		if p.Discovered && !p.LastSeen.IsZero() {
+			ret = append(ret, host)
+		}
+	}
+	return ret
+}
+
+// Unchecked returns the discovered hosts which have never responded
+func (m *Manager) Unchecked() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ret := make([]string, 0)
+	for host, p := range m.peers {
+		if p.Discovered && p.LastSeen.IsZero() {
+			ret = append(ret, host)
+		}
+	}
+	return ret
+}
+
+// Shared returns the best hosts which have responded recently, they are sent to other nodes
+func (m *Manager) Shared() []string {
+	m.mu.RLock()
+	hosts := make([]string, 0)
+	for host, p := range m.peers {
+		if !p.LastSeen.IsZero() && m.now().Sub(p.LastSeen) < staleTime && p.BadBlocks == 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	m.mu.RUnlock()
+
+	m.Sort(hosts)
+	if len(hosts) > network.MaxPeers {
+		hosts = hosts[:network.MaxPeers]
+	}
+	return hosts
+}
+
+// Prune removes the discovered peers which haven't responded during peerExpiration and
+// the peers which have never responded to maxUncheckedFailures requests
+func (m *Manager) Prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for host, p := range m.peers {
+		if !p.Discovered {
+			continue
+		}
+		lastSeen := p.LastSeen
+		if lastSeen.IsZero() {
+			if p.Failures >= maxUncheckedFailures {
+				delete(m.peers, host)
+				continue
+			}
+			lastSeen = p.AddedAt
+		}
+		if m.now().Sub(lastSeen) > peerExpiration {
+			delete(m.peers, host)
+		}
+	}
+}
+
+// Peers returns the copy of the peer table
+func (m *Manager) Peers() []Peer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ret := make([]Peer, 0, len(m.peers))
+	for _, p := range m.peers {
+		ret = append(ret, *p)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Host < ret[j].Host
+	})
+	return ret
+}
+
+func isValidator() bool {
+	_, err := syspar.GetNodePositionByKeyID(conf.Config.KeyID)
+	return err == nil
+}
+
+func isValidatorHost(host string) bool {
+	_, err := syspar.GetNodeByHost(host)
+	return err == nil
+}
@@ -0,0 +1,104 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package peers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	now := time.Unix(1520000000, 0)
+	m := NewManager()
+	m.now = func() time.Time { return now }
+
+	m.AddPeer("10.0.0.1:7078")
+	m.AddPeer("10.0.0.2:7078")
+	m.AddPeer("10.0.0.3:7078")
+	require.Empty(t, m.Discovered())
+	require.Len(t, m.Unchecked(), 3)
+
+	m.RecordSuccess("10.0.0.1:7078", 300*time.Millisecond)
+	m.RecordSuccess("10.0.0.2:7078", 50*time.Millisecond)
+	m.RecordSuccess("10.0.0.3:7078", 50*time.Millisecond)
+	m.RecordFailure("10.0.0.3:7078")
+	require.Len(t, m.Discovered(), 3)
+
+	hosts := []string{"10.0.0.1:7078", "10.0.0.3:7078", "10.0.0.2:7078"}
+	m.Sort(hosts)
+	require.Equal(t, []string{"10.0.0.2:7078", "10.0.0.1:7078", "10.0.0.3:7078"}, hosts)
+
+	m.RecordBadBlock("10.0.0.2:7078")
+	require.True(t, m.Score("10.0.0.2:7078") < m.Score("10.0.0.1:7078"))
+	require.NotContains(t, m.Shared(), "10.0.0.2:7078")
+
+	now = now.Add(2 * peerExpiration)
+	m.Prune()
+	require.Empty(t, m.Peers())
+}
+
+func TestManagerLimit(t *testing.T) {
+	now := time.Unix(1520000000, 0)
+	m := NewManager()
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < maxDiscovered; i++ {
+		now = now.Add(time.Second)
+		require.True(t, m.AddPeer(fmt.Sprintf("10.0.%d.%d:7078", i/256, i%256)))
+	}
+	require.False(t, m.AddPeer("10.0.0.0:7078"))
+	m.RecordSuccess("10.0.0.0:7078", 50*time.Millisecond)
+
+	// the peer which has never responded is replaced
+	require.True(t, m.AddPeer("10.1.0.0:7078"))
+	require.Len(t, m.Peers(), maxDiscovered)
+	require.Contains(t, m.Discovered(), "10.0.0.0:7078")
+	require.NotContains(t, m.Unchecked(), "10.0.0.1:7078")
+
+	// the table of the responded peers isn't changed
+	for _, host := range m.Unchecked() {
+		m.RecordSuccess(host, 50*time.Millisecond)
+	}
+	require.False(t, m.AddPeer("10.1.0.1:7078"))
+	require.Len(t, m.Discovered(), maxDiscovered)
+}
+
+func TestManagerPruneUnchecked(t *testing.T) {
+	m := NewManager()
+	m.AddPeer("10.0.0.1:7078")
+	m.AddPeer("10.0.0.2:7078")
+	for i := 0; i < maxUncheckedFailures; i++ {
+		m.RecordFailure("10.0.0.1:7078")
+	}
+	m.RecordFailure("10.0.0.2:7078")
+	m.Prune()
+	require.Equal(t, []string{"10.0.0.2:7078"}, m.Unchecked())
+}
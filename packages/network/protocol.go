@@ -34,6 +34,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/AplaProject/go-apla/packages/conf"
 	"github.com/AplaProject/go-apla/packages/conf/syspar"
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/converter"
@@ -49,15 +50,128 @@ const (
 	RequestTypeConfirmation    = 4
 	RequestTypeBlockCollection = 7
 	RequestTypeMaxBlock        = 10
+	RequestTypeHandshake       = 11
+	RequestTypeSnapshot        = 12
+	RequestTypePeers           = 13
+	RequestTypeVotes           = 14
+	RequestTypeTransactions    = 15
 
 	// BlocksPerRequest contains count of blocks per request
 	BlocksPerRequest int32 = 1000
 
 	MaxBlockSize = 10485760
+
+	// MaxPeers is the maximum count of the hosts in the peers response
+	MaxPeers = 100
+
+	// MaxGossipTxs is the maximum count of the transactions in the transactions request
+	MaxGossipTxs = 100
+
+	maxHostSize = 255
+)
+
+// Versions of the network protocol. The legacy nodes don't send the handshake and
+// exchange the unframed messages. The nodes use the lower of their versions, the peers
+// with the version below MinProtocolVersion are rejected.
+const (
+	LegacyProtocolVersion = 1
+	MinProtocolVersion    = 2
+	ProtocolVersion       = 2
+)
+
+// Capabilities of the node which are exchanged in the handshake
+const (
+	CapMaxBlock uint64 = 1 << iota
+	CapSecureTransport
+	CapSnapshot
+	CapPeers
+	// CapBatches means that the bodies of the transactions are sent with their batches
+	CapBatches
+	// CapTransactions means that the node accepts the pending transactions from the peers
+	CapTransactions
+)
+
+// Codes of the error responses
+const (
+	ErrCodeUnknownRequest uint16 = iota + 1
+	ErrCodeUnauthorized
 )
 
 var ErrNotAccepted = errors.New("Not accepted")
 var ErrMaxSize = errors.New("Size greater than max size")
+var ErrProtocolVersion = errors.New("Unsupported protocol version")
+var ErrUnknownRequest = errors.New("Unknown request type")
+
+// ProtocolError is the error response which is sent instead of the regular response
+type ProtocolError struct {
+	Code    uint16
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+func (e *ProtocolError) Read(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &e.Code); err != nil {
+		return err
+	}
+	msg, err := ReadSlice(r)
+	if err != nil {
+		return err
+	}
+	e.Message = string(msg)
+	return nil
+}
+
+func (e *ProtocolError) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, e.Code); err != nil {
+		return err
+	}
+	return writeSlice(w, []byte(e.Message))
+}
+
+// Handshake contains the protocol version and the capabilities of the node
+type Handshake struct {
+	Version      uint16
+	Capabilities uint64
+}
+
+// NodeHandshake returns the handshake of the current node
+func NodeHandshake() *Handshake {
+	caps := CapMaxBlock | CapPeers | CapBatches | CapTransactions
+	if nodeTransport != nil {
+		caps |= CapSecureTransport
+	}
+	if conf.Config.Snapshot.Interval > 0 {
+		caps |= CapSnapshot
+	}
+	return &Handshake{Version: ProtocolVersion, Capabilities: caps}
+}
+
+// Has returns true if the node supports the capability
+func (h *Handshake) Has(capability uint64) bool {
+	return h.Capabilities&capability != 0
+}
+
+func (h *Handshake) Read(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &h.Version); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Debug("on reading handshake version")
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &h.Capabilities); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading handshake capabilities")
+		return err
+	}
+	return nil
+}
+
+func (h *Handshake) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, h.Version); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, h.Capabilities)
+}
 
 // SelfReaderWriter read from Reader to himself and write to io.Writer from himself
 type SelfReaderWriter interface {
@@ -236,6 +350,212 @@ func (resp *DisHashResponse) Write(w io.Writer) error {
 	return writeSlice(w, resp.Data)
 }
 
+// SnapshotRequest contains the block of the snapshot, the latest snapshot is requested if BlockID is 0
+type SnapshotRequest struct {
+	BlockID  int64
+	WithData bool
+}
+
+func (req *SnapshotRequest) Read(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &req.BlockID); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading snapshot request block id")
+		return err
+	}
+	withData, err := readBool(r)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading snapshot request data flag")
+		return err
+	}
+	req.WithData = withData
+	return nil
+}
+
+func (req *SnapshotRequest) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, req.BlockID); err != nil {
+		return err
+	}
+	return writeBool(w, req.WithData)
+}
+
+// SnapshotResponse describes the snapshot, BlockID is 0 if the node doesn't have the snapshot.
+// Block contains the json of the block_chain record of the snapshot block, Checkpoint contains
+// the json of the records of the checkpoint block which header has the state hash and the block
+// before it. If the data has been requested it follows the response and has the length of Size.
+type SnapshotResponse struct {
+	BlockID    int64
+	BlockHash  []byte
+	StateHash  []byte
+	Block      []byte
+	Checkpoint []byte
+	Size       int64
+}
+
+func (resp *SnapshotResponse) Read(r io.Reader) (err error) {
+	if err = binary.Read(r, binary.LittleEndian, &resp.BlockID); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading snapshot response block id")
+		return err
+	}
+	if resp.BlockHash, err = ReadSliceWithMaxSize(r, consts.HashSize); err != nil {
+		return err
+	}
+	if resp.StateHash, err = ReadSliceWithMaxSize(r, consts.HashSize); err != nil {
+		return err
+	}
+	if resp.Block, err = ReadSliceWithMaxSize(r, 2*MaxBlockSize); err != nil {
+		return err
+	}
+	if resp.Checkpoint, err = ReadSliceWithMaxSize(r, 4*MaxBlockSize); err != nil {
+		return err
+	}
+	return binary.Read(r, binary.LittleEndian, &resp.Size)
+}
+
+func (resp *SnapshotResponse) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, resp.BlockID); err != nil {
+		return err
+	}
+	if err := writeSlice(w, resp.BlockHash); err != nil {
+		return err
+	}
+	if err := writeSlice(w, resp.StateHash); err != nil {
+		return err
+	}
+	if err := writeSlice(w, resp.Block); err != nil {
+		return err
+	}
+	if err := writeSlice(w, resp.Checkpoint); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, resp.Size)
+}
+
+// PeersRequest asks the node for the known peers. Address is the TCP address of the requesting
+// node if it accepts the connections from other nodes, otherwise it is empty.
+type PeersRequest struct {
+	Address string
+}
+
+func (req *PeersRequest) Read(r io.Reader) error {
+	addr, err := ReadSliceWithMaxSize(r, maxHostSize)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading peers request address")
+		return err
+	}
+	req.Address = string(addr)
+	return nil
+}
+
+func (req *PeersRequest) Write(w io.Writer) error {
+	return writeSlice(w, []byte(req.Address))
+}
+
+// PeersResponse contains the TCP addresses of the peers
+type PeersResponse struct {
+	Hosts []string
+}
+
+func (resp *PeersResponse) Read(r io.Reader) error {
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading peers count")
+		return err
+	}
+	if count > MaxPeers {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "count": count, "max": MaxPeers}).Error("too many peers")
+		return ErrMaxSize
+	}
+	resp.Hosts = make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		host, err := ReadSliceWithMaxSize(r, maxHostSize)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading peer host")
+			return err
+		}
+		resp.Hosts = append(resp.Hosts, string(host))
+	}
+	return nil
+}
+
+func (resp *PeersResponse) Write(w io.Writer) error {
+	hosts := resp.Hosts
+	if len(hosts) > MaxPeers {
+		hosts = hosts[:MaxPeers]
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(hosts))); err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		if err := writeSlice(w, []byte(host)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VotesRequest contains the precommit votes of the validator
+type VotesRequest struct {
+	Data []byte
+}
+
+func (req *VotesRequest) Read(r io.Reader) error {
+	slice, err := ReadSliceWithMaxSize(r, consts.MaxCommitSize)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading votes request")
+		return err
+	}
+	req.Data = slice
+	return nil
+}
+
+func (req *VotesRequest) Write(w io.Writer) error {
+	return writeSlice(w, req.Data)
+}
+
+// TransactionsRequest contains the pending transactions which are gossiped to the peers
+type TransactionsRequest struct {
+	Txs [][]byte
+}
+
+func (req *TransactionsRequest) Read(r io.Reader) error {
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading transactions count")
+		return err
+	}
+	if count > MaxGossipTxs {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "count": count, "max": MaxGossipTxs}).Error("too many transactions")
+		return ErrMaxSize
+	}
+	req.Txs = make([][]byte, 0, count)
+	left := uint64(MaxBlockSize)
+	for i := uint32(0); i < count; i++ {
+		tx, err := ReadSliceWithMaxSize(r, left)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("on reading transaction")
+			return err
+		}
+		left -= uint64(len(tx))
+		req.Txs = append(req.Txs, tx)
+	}
+	return nil
+}
+
+func (req *TransactionsRequest) Write(w io.Writer) error {
+	txs := req.Txs
+	if len(txs) > MaxGossipTxs {
+		txs = txs[:MaxGossipTxs]
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(txs))); err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		if err := writeSlice(w, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type StopNetworkRequest struct {
 	Data []byte
 }
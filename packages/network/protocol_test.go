@@ -100,3 +100,42 @@ func TestBodyResponse(t *testing.T) {
 	fmt.Println(rt, result)
 
 }
+
+func TestPeers(t *testing.T) {
+	b := &bytes.Buffer{}
+	req := PeersRequest{Address: "10.0.0.1:7078"}
+	result := PeersRequest{}
+	require.NoError(t, req.Write(b))
+	require.NoError(t, result.Read(b))
+	require.Equal(t, req, result)
+
+	resp := PeersResponse{Hosts: []string{"10.0.0.2:7078", "10.0.0.3:7078"}}
+	respResult := PeersResponse{}
+	require.NoError(t, resp.Write(b))
+	require.NoError(t, respResult.Read(b))
+	require.Equal(t, resp, respResult)
+
+	require.NoError(t, binary.Write(b, binary.LittleEndian, uint32(MaxPeers+1)))
+	require.Equal(t, ErrMaxSize, respResult.Read(b))
+}
+
+func TestVotes(t *testing.T) {
+	b := &bytes.Buffer{}
+	req := VotesRequest{Data: []byte("votes")}
+	result := VotesRequest{}
+	require.NoError(t, req.Write(b))
+	require.NoError(t, result.Read(b))
+	require.Equal(t, req, result)
+}
+
+func TestTransactions(t *testing.T) {
+	b := &bytes.Buffer{}
+	req := TransactionsRequest{Txs: [][]byte{[]byte("tx1"), []byte("tx2")}}
+	result := TransactionsRequest{}
+	require.NoError(t, req.Write(b))
+	require.NoError(t, result.Read(b))
+	require.Equal(t, req, result)
+
+	require.NoError(t, binary.Write(b, binary.LittleEndian, uint32(MaxGossipTxs+1)))
+	require.Equal(t, ErrMaxSize, result.Read(b))
+}
@@ -49,7 +49,7 @@ const sizeBytesLength = 4
 
 // GetBlocksBodies send GetBodiesRequest returns channel of binary blocks data
 func GetBlocksBodies(ctx context.Context, host string, blockID int64, reverseOrder bool) (<-chan []byte, error) {
-	conn, err := newConnection(host)
+	conn, err := newConnection(host, network.RequestTypeBlockCollection)
 	if err != nil {
 		return nil, err
 	}
@@ -33,9 +33,13 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/AplaProject/go-apla/packages/conf"
 	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/network"
+	"github.com/AplaProject/go-apla/packages/network/peers"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -56,7 +60,47 @@ func NormalizeHostAddress(address string, defaultPort int64) (string, error) {
 	return address, nil
 }
 
-func newConnection(addr string) (net.Conn, error) {
+// legacyHostTTL is the time after which the handshake is tried again with the legacy host
+const legacyHostTTL = 10 * time.Minute
+
+// legacyHosts keeps the hosts which have failed the handshake and the hosts which have
+// completed it. The latter are never downgraded to the legacy protocol.
+var legacyHosts = struct {
+	sync.Mutex
+	hosts  map[string]time.Time
+	framed map[string]bool
+}{hosts: make(map[string]time.Time), framed: make(map[string]bool)}
+
+func isLegacyHost(host string) bool {
+	legacyHosts.Lock()
+	defer legacyHosts.Unlock()
+	checked, ok := legacyHosts.hosts[host]
+	if ok && time.Since(checked) > legacyHostTTL {
+		delete(legacyHosts.hosts, host)
+		return false
+	}
+	return ok
+}
+
+// setLegacyHost marks the host as legacy if it hasn't completed the handshake before.
+// It returns false if the host can't be downgraded.
+func setLegacyHost(host string) bool {
+	legacyHosts.Lock()
+	defer legacyHosts.Unlock()
+	if legacyHosts.framed[host] {
+		return false
+	}
+	legacyHosts.hosts[host] = time.Now()
+	return true
+}
+
+func setFramedHost(host string) {
+	legacyHosts.Lock()
+	defer legacyHosts.Unlock()
+	legacyHosts.framed[host] = true
+}
+
+func newConnection(addr string, requestType uint16) (net.Conn, error) {
 	if len(addr) == 0 {
 		return nil, wrongAddressError
 	}
@@ -67,6 +111,36 @@ func newConnection(addr string) (net.Conn, error) {
 		return nil, err
 	}
 
+	fullNode := network.IsFullNodeRequest(requestType)
+	conn, err := dial(host, fullNode)
+	if err != nil {
+		peers.GetManager().RecordFailure(addr)
+		return nil, err
+	}
+	// the strict node never uses the legacy protocol
+	strict := conf.Config.TCPServer.Strict
+	if !strict && isLegacyHost(host) {
+		return conn, nil
+	}
+
+	framed, err := network.ClientHandshake(conn)
+	if err != nil {
+		conn.Close()
+		if strict || !setLegacyHost(host) {
+			log.WithFields(log.Fields{"type": consts.ProtocolError, "error": err, "address": host}).Error("handshake failed")
+			return nil, err
+		}
+		// the nodes of the previous versions close the connection on the handshake request
+		log.WithFields(log.Fields{"type": consts.ProtocolError, "error": err, "address": host}).Debug("handshake failed, using legacy protocol")
+		return dial(host, fullNode)
+	}
+	setFramedHost(host)
+	return framed, nil
+}
+
+// dial connects to the host, if the secure transport is on the host must be the full node
+// for fullNode requests
+func dial(host string, fullNode bool) (net.Conn, error) {
 	conn, err := net.DialTimeout("tcp", host, consts.TCPConnTimeout)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": host}).Debug("dialing tcp")
@@ -75,5 +149,14 @@ func newConnection(addr string) (net.Conn, error) {
 
 	conn.SetReadDeadline(time.Now().Add(consts.READ_TIMEOUT * time.Second))
 	conn.SetWriteDeadline(time.Now().Add(consts.WRITE_TIMEOUT * time.Second))
+	if conf.Config.TCPServer.Secure {
+		secureConn, err := network.GetTransport().Dial(conn, host, fullNode)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": host}).Error("establishing secure connection")
+			conn.Close()
+			return nil, err
+		}
+		return secureConn, nil
+	}
 	return conn, nil
 }
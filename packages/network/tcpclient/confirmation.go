@@ -36,7 +36,7 @@ import (
 )
 
 func CheckConfirmation(host string, blockID int64, logger *log.Entry) (hash string) {
-	conn, err := newConnection(host)
+	conn, err := newConnection(host, network.RequestTypeConfirmation)
 	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": host, "block_id": blockID}).Debug("dialing to host")
 		return "0"
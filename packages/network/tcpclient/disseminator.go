@@ -56,7 +56,7 @@ func SendTransactionsToHost(host string, txes []model.Transaction) error {
 }
 
 func sendRawTransacitionsToHost(host string, packet []byte) error {
-	con, err := newConnection(host)
+	con, err := newConnection(host, network.RequestTypeNotFullNode)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": host}).Error("on creating tcp connection")
 		return err
@@ -105,15 +105,94 @@ func SendTransacitionsToAll(ctx context.Context, hosts []string, txes []model.Tr
 	return nil
 }
 
+// GossipTransactions sends the pending transactions to the peers which accept them,
+// the transactions are sent by chunks of network.MaxGossipTxs
+func GossipTransactions(ctx context.Context, hosts []string, txes []model.Transaction) error {
+	if len(hosts) == 0 || len(txes) == 0 {
+		return nil
+	}
+
+	var chunks [][][]byte
+	for i := 0; i < len(txes); i += network.MaxGossipTxs {
+		end := i + network.MaxGossipTxs
+		if end > len(txes) {
+			end = len(txes)
+		}
+		chunk := make([][]byte, 0, end-i)
+		for _, tr := range txes[i:end] {
+			chunk = append(chunk, tr.Data)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	var wg sync.WaitGroup
+	var errCount int32
+	for _, h := range hosts {
+		if err := ctx.Err(); err != nil {
+			log.Debug("exit by context error")
+			return err
+		}
+
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+
+			if err := sendGossipToHost(host, chunks); err != nil {
+				atomic.AddInt32(&errCount, 1)
+			}
+		}(h)
+	}
+
+	wg.Wait()
+
+	if int(errCount) == len(hosts) {
+		return ErrNodesUnavailable
+	}
+
+	return nil
+}
+
+func sendGossipToHost(host string, chunks [][][]byte) error {
+	for _, chunk := range chunks {
+		con, err := newConnection(host, network.RequestTypeTransactions)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": host}).Debug("on creating tcp connection")
+			return err
+		}
+		if !network.PeerHas(con, network.CapTransactions) {
+			con.Close()
+			return nil
+		}
+
+		rt := &network.RequestType{Type: network.RequestTypeTransactions}
+		if err = rt.Write(con); err == nil {
+			err = (&network.TransactionsRequest{Txs: chunk}).Write(con)
+		}
+		// the framed connection sends the buffered request on close
+		if errClose := con.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Debug("sending transactions request")
+			return err
+		}
+	}
+	return nil
+}
+
 func SendFullBlockToAll(ctx context.Context, hosts []string, block *model.InfoBlock, txes []model.Transaction, nodeID int64) error {
 	if len(hosts) == 0 {
 		return nil
 	}
 
 	req := prepareFullBlockRequest(block, txes, nodeID)
-	txDataMap := make(map[string][]byte, len(txes))
-	for _, tx := range txes {
-		txDataMap[string(tx.Hash)] = tx.Data
+	txMap := make(map[string]*model.Transaction, len(txes))
+	batches := make(map[string][]*model.Transaction)
+	for i := range txes {
+		txMap[string(txes[i].Hash)] = &txes[i]
+		if len(txes[i].Batch) > 0 {
+			batches[string(txes[i].Batch)] = append(batches[string(txes[i].Batch)], &txes[i])
+		}
 	}
 
 	var errCount int32
@@ -128,7 +207,7 @@ func SendFullBlockToAll(ctx context.Context, hosts []string, block *model.InfoBl
 		go func(h string) {
 			defer wg.Done()
 
-			con, err := newConnection(h)
+			con, err := newConnection(h, network.RequestTypeFullNode)
 			if err != nil {
 				increaseErrCount()
 				log.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": h}).Error("on creating tcp connection")
@@ -149,12 +228,30 @@ func SendFullBlockToAll(ctx context.Context, hosts []string, block *model.InfoBl
 			}
 
 			var buf bytes.Buffer
+			withBatches := network.PeerHas(con, network.CapBatches)
 			requestedHashes := parseTxHashesFromResponse(response)
+			// the receiver checks the batch by all its transactions so the whole batch is sent
+			// if any of its transactions is requested
+			sent := make(map[string]bool, len(requestedHashes))
 			for _, txhash := range requestedHashes {
-				if data, ok := txDataMap[string(txhash)]; ok && len(data) > 0 {
-					log.WithFields(log.Fields{"len_of_tx": len(data)}).Debug("on prepare full tx package")
-					if _, err := buf.Write(converter.EncodeLengthPlusData(data)); err != nil {
-						log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Warn("on write tx hash to response buffer")
+				tx, ok := txMap[string(txhash)]
+				if !ok {
+					continue
+				}
+				group := []*model.Transaction{tx}
+				if withBatches && len(tx.Batch) > 0 {
+					group = batches[string(tx.Batch)]
+				}
+				for _, tx := range group {
+					if sent[string(tx.Hash)] || len(tx.Data) == 0 {
+						continue
+					}
+					sent[string(tx.Hash)] = true
+					log.WithFields(log.Fields{"len_of_tx": len(tx.Data)}).Debug("on prepare full tx package")
+					buf.Write(converter.EncodeLengthPlusData(tx.Data))
+					if withBatches {
+						buf.Write(converter.EncodeLengthPlusData(tx.Batch))
+						buf.Write(converter.DecToBin(tx.BatchIndex, 4))
 					}
 				}
 			}
@@ -31,9 +31,11 @@ package tcpclient
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/network"
+	"github.com/AplaProject/go-apla/packages/network/peers"
 	"github.com/AplaProject/go-apla/packages/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -51,12 +53,20 @@ func GetMaxBlockID(host string) (blockID int64, err error) {
 }
 
 func getMaxBlock(host string) (blockID int64, err error) {
-	con, err := newConnection(host)
+	start := time.Now()
+	con, err := newConnection(host, network.RequestTypeMaxBlock)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err, "type": consts.ConnectionError, "host": host}).Debug("error connecting to host")
 		return -1, err
 	}
 	defer con.Close()
+	defer func() {
+		if err != nil {
+			peers.GetManager().RecordFailure(host)
+		} else {
+			peers.GetManager().RecordSuccess(host, time.Since(start))
+		}
+	}()
 
 	// send max block request
 	rt := &network.RequestType{
@@ -126,8 +136,10 @@ func hostWithMaxBlock(ctx context.Context, hosts []string) (bestHost string, max
 			continue
 		}
 
-		// If blockID is maximal then the current host is the best
-		if bl.blockID > maxBlockID {
+		// If blockID is maximal then the current host is the best,
+		// the host with the better score is chosen from the hosts with the same block
+		if bl.blockID > maxBlockID || (bl.blockID == maxBlockID &&
+			peers.GetManager().Score(bl.host) > peers.GetManager().Score(bestHost)) {
 			maxBlockID = bl.blockID
 			bestHost = bl.host
 		}
@@ -0,0 +1,107 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package tcpclient
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/network"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// snapshotReader reads the snapshot data from the connection. The read deadline is prolonged
+// before every reading because the snapshot can't be downloaded within the usual timeout.
+type snapshotReader struct {
+	io.Reader
+	conn net.Conn
+}
+
+func (r *snapshotReader) Read(p []byte) (int, error) {
+	r.conn.SetReadDeadline(time.Now().Add(consts.READ_TIMEOUT * time.Second))
+	return r.Reader.Read(p)
+}
+
+func (r *snapshotReader) Close() error {
+	return r.conn.Close()
+}
+
+// GetSnapshotInfo returns the info of the host snapshot, the latest snapshot is requested if blockID is 0
+func GetSnapshotInfo(host string, blockID int64) (*network.SnapshotResponse, error) {
+	resp, conn, err := requestSnapshot(host, blockID, false)
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return resp, nil
+}
+
+// GetSnapshot returns the info and the data of the host snapshot. The data must be closed by the caller.
+func GetSnapshot(host string, blockID int64) (*network.SnapshotResponse, io.ReadCloser, error) {
+	resp, conn, err := requestSnapshot(host, blockID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.BlockID == 0 {
+		conn.Close()
+		return resp, nil, nil
+	}
+	return resp, &snapshotReader{Reader: io.LimitReader(conn, resp.Size), conn: conn}, nil
+}
+
+func requestSnapshot(host string, blockID int64, withData bool) (*network.SnapshotResponse, net.Conn, error) {
+	conn, err := newConnection(host, network.RequestTypeSnapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rt := &network.RequestType{Type: network.RequestTypeSnapshot}
+	if err = rt.Write(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending request type")
+		conn.Close()
+		return nil, nil, err
+	}
+
+	req := &network.SnapshotRequest{BlockID: blockID, WithData: withData}
+	if err = req.Write(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending snapshot request")
+		conn.Close()
+		return nil, nil, err
+	}
+
+	resp := &network.SnapshotResponse{}
+	if err = resp.Read(conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("receiving snapshot response")
+		conn.Close()
+		return nil, nil, err
+	}
+	return resp, conn, nil
+}
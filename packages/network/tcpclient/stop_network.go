@@ -34,7 +34,7 @@ import (
 )
 
 func SendStopNetwork(addr string, req *network.StopNetworkRequest) error {
-	conn, err := newConnection(addr)
+	conn, err := newConnection(addr, network.RequestTypeStopNetwork)
 	if err != nil {
 		return err
 	}
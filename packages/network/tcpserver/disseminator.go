@@ -39,10 +39,12 @@ import (
 	"github.com/AplaProject/go-apla/packages/network"
 	"github.com/AplaProject/go-apla/packages/transaction"
 	"github.com/AplaProject/go-apla/packages/utils"
+	txtypes "github.com/AplaProject/go-apla/packages/utils/tx"
 
 	"github.com/AplaProject/go-apla/packages/conf/syspar"
 	"github.com/AplaProject/go-apla/packages/service"
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/vmihailenco/msgpack.v2"
 )
 
 // Type1 get the list of transactions which belong to the sender from 'disseminator' daemon
@@ -118,7 +120,7 @@ func Type1(rw io.ReadWriter) error {
 	}
 
 	// and save them
-	return saveNewTransactions(txBodies)
+	return saveNewTransactions(txBodies, network.PeerHas(rw, network.CapBatches))
 }
 
 func resieveTxBodies(con io.Reader) ([]byte, error) {
@@ -240,8 +242,12 @@ func readHashes(buf *bytes.Buffer) ([][]byte, error) {
 	return hashes, nil
 }
 
-func saveNewTransactions(binaryTxs []byte) error {
-	queue := []model.BatchModel{}
+// saveNewTransactions queues the received transactions, the bodies of the nodes with CapBatches
+// are followed by the hash of the batch and the index of the transaction in the batch.
+// The batches which fail the check are dropped
+func saveNewTransactions(binaryTxs []byte, withBatches bool) error {
+	txs := []*model.QueueTx{}
+	keys := make(map[string]int64)
 	log.WithFields(log.Fields{"binaryTxs": binaryTxs}).Debug("trying to save binary txs")
 
 	for len(binaryTxs) > 0 {
@@ -272,13 +278,94 @@ func saveNewTransactions(binaryTxs []byte) error {
 			return err
 		}
 
-		queue = append(queue, &model.QueueTx{Hash: tx.Hash(), Data: txBinData, FromGate: 1})
+		qtx := &model.QueueTx{Hash: tx.Hash(), Data: txBinData, FromGate: 1, Batch: []byte{}}
+		if withBatches {
+			batchSize, err := converter.DecodeLength(&binaryTxs)
+			if err != nil || batchSize > consts.HashSize || int64(len(binaryTxs)) < batchSize+4 {
+				log.WithFields(log.Fields{"type": consts.ProtocolError, "size": batchSize, "len": len(binaryTxs)}).Error("incorrect batch of tx")
+				return utils.ErrInfo(errors.New("bad transactions packet"))
+			}
+			if batchSize > 0 {
+				qtx.Batch = converter.BytesShift(&binaryTxs, batchSize)
+				keys[string(qtx.Hash)] = txKeyID(&tx)
+			}
+			qtx.BatchIndex = int(converter.BinToDec(converter.BytesShift(&binaryTxs, 4)))
+		}
+		txs = append(txs, qtx)
 	}
 
-	if err := model.BatchInsert(queue, []string{"hash", "data", "from_gate"}); err != nil {
+	queue := []model.BatchModel{}
+	for _, qtx := range checkBatches(txs, keys) {
+		// the whole batch is sent even if the node has already known some of its transactions
+		if len(qtx.Batch) > 0 {
+			known, err := isKnownTransaction(qtx.Hash)
+			if err != nil {
+				return err
+			}
+			if known {
+				continue
+			}
+		}
+		queue = append(queue, qtx)
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+	if err := model.BatchInsert(queue, []string{"hash", "data", "from_gate", "batch", "batch_index"}); err != nil {
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("error creating QueueTx")
 		return err
 	}
 
 	return nil
 }
+
+// txKeyID returns the key of the contract transaction or 0 if it isn't the contract
+func txKeyID(tx *transaction.RawTransaction) int64 {
+	smartTx := txtypes.SmartContract{}
+	if err := msgpack.Unmarshal(tx.Payload(), &smartTx); err != nil {
+		return 0
+	}
+	return smartTx.Header.KeyID
+}
+
+// checkBatches returns the transactions without the batches which can't be trusted. The batch
+// is kept if the packet contains all its transactions, its hash matches the hashes of the
+// transactions ordered by their indexes and all transactions are signed by the same key,
+// so the peer can't attach the transactions of other keys to the failing batch
+func checkBatches(txs []*model.QueueTx, keys map[string]int64) []*model.QueueTx {
+	batches := make(map[string][]*model.QueueTx)
+	for _, qtx := range txs {
+		if len(qtx.Batch) > 0 {
+			batches[string(qtx.Batch)] = append(batches[string(qtx.Batch)], qtx)
+		}
+	}
+	bad := make(map[string]bool)
+	for batch, items := range batches {
+		hashes := make([][]byte, len(items))
+		keyID := keys[string(items[0].Hash)]
+		valid := keyID != 0
+		for _, qtx := range items {
+			if qtx.BatchIndex < 0 || qtx.BatchIndex >= len(items) || hashes[qtx.BatchIndex] != nil ||
+				keys[string(qtx.Hash)] != keyID {
+				valid = false
+				break
+			}
+			hashes[qtx.BatchIndex] = qtx.Hash
+		}
+		if valid {
+			hash, err := model.BatchHash(hashes)
+			valid = err == nil && bytes.Equal(hash, []byte(batch))
+		}
+		if !valid {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "batch": []byte(batch)}).Warning("dropping incorrect batch")
+			bad[batch] = true
+		}
+	}
+	ret := make([]*model.QueueTx, 0, len(txs))
+	for _, qtx := range txs {
+		if !bad[string(qtx.Batch)] {
+			ret = append(ret, qtx)
+		}
+	}
+	return ret
+}
@@ -33,6 +33,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/network"
 	"github.com/AplaProject/go-apla/packages/service"
@@ -41,15 +43,42 @@ import (
 )
 
 // HandleTCPRequest proceed TCP requests
-func HandleTCPRequest(rw net.Conn) {
+func HandleTCPRequest(conn net.Conn) {
+	rw, peerKey, err := network.GetTransport().Accept(conn)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": conn.RemoteAddr()}).Warn("accepting connection")
+		return
+	}
+
 	dType := &network.RequestType{}
-	err := dType.Read(rw)
+	err = dType.Read(rw)
 	if err != nil {
 		log.Errorf("read request type failed: %s", err)
 		return
 	}
 
+	if dType.Type == network.RequestTypeHandshake {
+		framed, err := network.AcceptHandshake(rw)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.ProtocolError, "error": err, "address": conn.RemoteAddr()}).Warn("accepting handshake")
+			return
+		}
+		defer framed.Flush()
+		rw = framed
+		if err = dType.Read(rw); err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("read request type failed")
+			return
+		}
+	}
+
 	log.WithFields(log.Fields{"request_type": dType.Type}).Debug("tcpserver got request type")
+	if conf.Config.TCPServer.Strict && network.IsPrivilegedRequest(dType.Type) &&
+		(peerKey == nil || syspar.GetNodeByPublicKey(peerKey) == nil) {
+		log.WithFields(log.Fields{"type": consts.AccessDenied, "request_type": dType.Type, "address": conn.RemoteAddr(),
+			"error": network.ErrUnauthorized}).Warn("rejecting privileged request")
+		network.SendError(rw, network.ErrCodeUnauthorized, network.ErrUnauthorized)
+		return
+	}
 	var response interface{}
 
 	switch dType.Type {
@@ -89,6 +118,38 @@ func HandleTCPRequest(rw net.Conn) {
 
 	case network.RequestTypeMaxBlock:
 		response, err = Type10()
+
+	case network.RequestTypeSnapshot:
+		req := &network.SnapshotRequest{}
+		if err = req.Read(rw); err == nil {
+			err = Type12(req, rw)
+		}
+
+	case network.RequestTypePeers:
+		req := &network.PeersRequest{}
+		if err = req.Read(rw); err == nil {
+			response, err = Type13(req, conn.RemoteAddr())
+		}
+
+	case network.RequestTypeVotes:
+		req := &network.VotesRequest{}
+		if err = req.Read(rw); err == nil {
+			err = Type14(req)
+		}
+
+	case network.RequestTypeTransactions:
+		if service.IsNodePaused() {
+			return
+		}
+		req := &network.TransactionsRequest{}
+		if err = req.Read(rw); err == nil {
+			err = Type15(req)
+		}
+
+	default:
+		log.WithFields(log.Fields{"type": consts.ProtocolError, "request_type": dType.Type, "address": conn.RemoteAddr()}).Warn("unknown request type")
+		network.SendError(rw, network.ErrCodeUnknownRequest, network.ErrUnknownRequest)
+		return
 	}
 
 	if err != nil || response == nil {
@@ -0,0 +1,102 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package tcpserver
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/network"
+	"github.com/AplaProject/go-apla/packages/snapshot"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type12 writes the info and the data of the state snapshot with its checkpoint. Only the
+// snapshots which checkpoint block has been generated are sent.
+// blocksCollection daemon sends this request on fast sync
+func Type12(request *network.SnapshotRequest, w io.Writer) error {
+	blockID := request.BlockID
+	var err error
+	if blockID == 0 {
+		blockID, err = snapshot.Latest()
+	}
+	var checkpoint *snapshot.Checkpoint
+	if err == nil {
+		checkpoint, err = snapshot.GetCheckpoint(blockID)
+	}
+	if err == snapshot.ErrNotFound {
+		return (&network.SnapshotResponse{}).Write(w)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": blockID}).Error("getting snapshot checkpoint")
+		return err
+	}
+	info, file, err := snapshot.Open(blockID)
+	if err == snapshot.ErrNotFound {
+		return (&network.SnapshotResponse{}).Write(w)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "block_id": blockID}).Error("opening snapshot")
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("getting snapshot size")
+		return err
+	}
+	block, err := json.Marshal(info.Block)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling snapshot block")
+		return err
+	}
+	cp, err := json.Marshal(checkpoint)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling snapshot checkpoint")
+		return err
+	}
+
+	resp := &network.SnapshotResponse{
+		BlockID:    info.BlockID,
+		BlockHash:  info.BlockHash,
+		StateHash:  info.StateHash,
+		Block:      block,
+		Checkpoint: cp,
+		Size:       stat.Size(),
+	}
+	if err = resp.Write(w); err != nil || !request.WithData {
+		return err
+	}
+	if _, err = io.Copy(w, file); err != nil {
+		log.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "block_id": info.BlockID}).Error("sending snapshot")
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package tcpserver
+
+import (
+	"net"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/network"
+	"github.com/AplaProject/go-apla/packages/network/peers"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type13 returns the known peers and registers the address of the requesting node.
+// PeersDiscovery daemon sends this request
+func Type13(request *network.PeersRequest, remote net.Addr) (*network.PeersResponse, error) {
+	manager := peers.GetManager()
+	if len(request.Address) > 0 {
+		// only the port is taken from the announced address, so the node can't announce other hosts
+		_, port, err := net.SplitHostPort(request.Address)
+		remoteHost, _, errRemote := net.SplitHostPort(remote.String())
+		if err != nil || errRemote != nil {
+			log.WithFields(log.Fields{"type": consts.ParseError, "error": err, "address": request.Address}).Warn("parsing announced address")
+		} else if host := net.JoinHostPort(remoteHost, port); host != conf.Config.TCPServer.Str() {
+			manager.AddPeer(host)
+		}
+	}
+
+	return &network.PeersResponse{Hosts: manager.Shared()}, nil
+}
@@ -0,0 +1,90 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package tcpserver
+
+import (
+	"bytes"
+
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/network"
+	"github.com/AplaProject/go-apla/packages/transaction"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type15 queues the pending transactions gossiped by the peers, the known transactions are skipped.
+// Disseminator daemon sends this request
+func Type15(request *network.TransactionsRequest) error {
+	queue := []model.BatchModel{}
+	for _, data := range request.Txs {
+		if len(data) == 0 || int64(len(data)) > syspar.GetMaxTxSize() {
+			log.WithFields(log.Fields{"type": consts.ParameterExceeded, "len": len(data), "size": syspar.GetMaxTxSize()}).Warning("skipping gossiped transaction of wrong size")
+			continue
+		}
+		tx := transaction.RawTransaction{}
+		if err := tx.Unmarshall(bytes.NewBuffer(data)); err != nil {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "error": err}).Error("unmarshalling gossiped transaction")
+			return err
+		}
+		known, err := isKnownTransaction(tx.Hash())
+		if err != nil {
+			return err
+		}
+		if !known {
+			queue = append(queue, &model.QueueTx{Hash: tx.Hash(), Data: data, FromGate: 1, Batch: []byte{}})
+		}
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+	if err := model.BatchInsert(queue, []string{"hash", "data", "from_gate", "batch", "batch_index"}); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating gossiped queue_tx")
+		return err
+	}
+	return nil
+}
+
+func isKnownTransaction(hash []byte) (bool, error) {
+	for _, count := range []func([]byte) (int64, error){
+		model.GetLogTransactionsCount,
+		model.GetTransactionsCount,
+		model.GetQueuedTransactionsCount,
+	} {
+		exists, err := count(hash)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "tx_hash": hash}).Error("checking known transaction")
+			return false, err
+		}
+		if exists > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
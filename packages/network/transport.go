@@ -0,0 +1,301 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// tlsHandshakeRecord is the first byte of TLS connection. Plain connections start with
+// the request type which never has such value.
+const tlsHandshakeRecord = 0x16
+
+// handshakeTimeout limits the time of the detection of TLS and the handshake of the accepted connection
+const handshakeTimeout = 10 * time.Second
+
+var (
+	// ErrUnknownNode is returned when the peer key doesn't belong to the full nodes
+	ErrUnknownNode = errors.New("Unknown node key")
+	// ErrWrongNodeKey is returned when the peer key differs from the key of the full node with the dialed address
+	ErrWrongNodeKey = errors.New("Node key doesn't match the address")
+	// ErrUnauthorized is returned when the privileged request is received from the unknown node
+	ErrUnauthorized = errors.New("Request requires authenticated full node")
+
+	errNoTransport  = errors.New("Secure transport is not initialized")
+	errNodeCertKey  = errors.New("Node certificate must have ECDSA P-256 key")
+	errNoPeerCert   = errors.New("Peer certificate is missing")
+	nodeTransport   *Transport
+	privilegedTypes = map[uint16]bool{
+		RequestTypeFullNode:        true,
+		RequestTypeNotFullNode:     true,
+		RequestTypeStopNetwork:     true,
+		RequestTypeBlockCollection: true,
+		RequestTypeVotes:           true,
+	}
+	// fullNodeTypes are the requests which can be sent only to the full nodes,
+	// the other requests are sent to the replicas and peers too
+	fullNodeTypes = map[uint16]bool{
+		RequestTypeFullNode:     true,
+		RequestTypeNotFullNode:  true,
+		RequestTypeStopNetwork:  true,
+		RequestTypeConfirmation: true,
+		RequestTypeVotes:        true,
+	}
+)
+
+// Transport contains TLS configurations of the node-to-node connections. The certificates are
+// self-signed with the node keys so the peers are identified by their public keys.
+type Transport struct {
+	server *tls.Config
+	client *tls.Config
+	// peer is used for the connections to any node, its key isn't verified
+	peer   *tls.Config
+	verify func(host string, publicKey []byte) error
+}
+
+// NewTransport creates the transport for the node private key. The verify function checks
+// the public key of the node which the connection is established to by its address.
+func NewTransport(privateKey []byte, verify func(host string, publicKey []byte) error) (*Transport, error) {
+	cert, err := nodeCertificate(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Transport{
+		server: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAnyClientCert,
+			MinVersion:   tls.VersionTLS12,
+			// any node can connect, the key is checked for the privileged requests
+			VerifyPeerCertificate: peerVerifier(nil),
+		},
+		client: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+			// the certificates are self-signed so the chain is not verified,
+			// the key of the peer is checked by VerifyPeerCertificate of the dialed host instead
+			InsecureSkipVerify: true,
+		},
+		peer: &tls.Config{
+			Certificates:          []tls.Certificate{cert},
+			MinVersion:            tls.VersionTLS12,
+			InsecureSkipVerify:    true,
+			VerifyPeerCertificate: peerVerifier(nil),
+		},
+		verify: verify,
+	}, nil
+}
+
+// peerVerifier returns the function which checks the public key of the peer certificate
+func peerVerifier(verify func(publicKey []byte) error) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errNoPeerCert
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		pub, err := certPublicKey(cert)
+		if err != nil || verify == nil {
+			return err
+		}
+		return verify(pub)
+	}
+}
+
+// nodeCertificate creates the self-signed certificate with the node key
+func nodeCertificate(privateKey []byte) (tls.Certificate, error) {
+	priv := new(ecdsa.PrivateKey)
+	priv.PublicKey.Curve = elliptic.P256()
+	priv.D = new(big.Int).SetBytes(privateKey)
+	priv.PublicKey.X, priv.PublicKey.Y = priv.PublicKey.Curve.ScalarBaseMult(privateKey)
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano()),
+		Subject:      pkix.Name{CommonName: consts.NodePublicKeyFilename},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("creating node certificate")
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv}, nil
+}
+
+// certPublicKey returns the public key of the certificate in the format of the node keys
+func certPublicKey(cert *x509.Certificate) ([]byte, error) {
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, errNodeCertKey
+	}
+	return append(converter.FillLeft(pub.X.Bytes()), converter.FillLeft(pub.Y.Bytes())...), nil
+}
+
+// Accept detects TLS connection and makes the handshake. It returns the public key of
+// the peer node or nil if the connection is plain. The detection and the handshake must be
+// completed during handshakeTimeout.
+func (t *Transport) Accept(conn net.Conn) (net.Conn, []byte, error) {
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return nil, nil, err
+	}
+	defer conn.SetDeadline(time.Time{})
+	reader := bufio.NewReader(conn)
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	peeked := &peekedConn{Conn: conn, reader: reader}
+	if first[0] != tlsHandshakeRecord {
+		return peeked, nil, nil
+	}
+	if t == nil {
+		return nil, nil, errNoTransport
+	}
+	tlsConn := tls.Server(peeked, t.server)
+	if err = tlsConn.Handshake(); err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": conn.RemoteAddr()}).Debug("tls handshake")
+		return nil, nil, err
+	}
+	pub, err := certPublicKey(tlsConn.ConnectionState().PeerCertificates[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return tlsConn, pub, nil
+}
+
+// Dial makes the client TLS handshake on the established connection to the host. The key of
+// the peer is verified only if fullNode is true, it must be the key of the full node with the
+// address of the host.
+func (t *Transport) Dial(conn net.Conn, host string, fullNode bool) (net.Conn, error) {
+	if t == nil {
+		return nil, errNoTransport
+	}
+	config := t.peer
+	if fullNode {
+		config = t.client.Clone()
+		var verify func([]byte) error
+		if t.verify != nil {
+			verify = func(publicKey []byte) error {
+				return t.verify(host, publicKey)
+			}
+		}
+		config.VerifyPeerCertificate = peerVerifier(verify)
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		log.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "address": conn.RemoteAddr()}).Debug("tls handshake")
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// peekedConn is the connection whose first bytes have been read to the buffer
+type peekedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// InitTransport creates the node transport with the node private key. The nodes which the
+// full node requests are sent to must be the full nodes with the dialed addresses.
+func InitTransport(privateKey []byte) (err error) {
+	nodeTransport, err = NewTransport(privateKey, verifyFullNode)
+	return
+}
+
+// GetTransport returns the node transport, it is nil if the transport hasn't been initialized
+func GetTransport() *Transport {
+	return nodeTransport
+}
+
+// IsPrivilegedRequest returns true if the request can be accepted only from the full nodes
+// when conf.Config.TCPServer.Strict is on
+func IsPrivilegedRequest(requestType uint16) bool {
+	return privilegedTypes[requestType]
+}
+
+// IsFullNodeRequest returns true if the request can be sent only to the full nodes
+func IsFullNodeRequest(requestType uint16) bool {
+	return fullNodeTypes[requestType]
+}
+
+// verifyFullNode checks that the public key is the key of the full node with the address of the host
+func verifyFullNode(host string, publicKey []byte) error {
+	nodes := syspar.GetNodes()
+	if len(nodes) == 0 {
+		// the full nodes haven't been loaded from the first block yet
+		return nil
+	}
+	host = hostWithPort(host)
+	for _, node := range nodes {
+		if hostWithPort(node.TCPAddress) != host {
+			continue
+		}
+		if !bytes.Equal(crypto.CutPub(node.PublicKey), crypto.CutPub(publicKey)) {
+			return ErrWrongNodeKey
+		}
+		return nil
+	}
+	return ErrUnknownNode
+}
+
+// hostWithPort appends the default port to the address without the port
+func hostWithPort(address string) string {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Sprintf("%s:%d", address, consts.DEFAULT_TCP_PORT)
+	}
+	return address
+}
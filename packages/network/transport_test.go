@@ -0,0 +1,124 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package network
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/crypto"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	serverPriv, serverPub, err := crypto.GenBytesKeys()
+	require.NoError(t, err)
+	clientPriv, clientPub, err := crypto.GenBytesKeys()
+	require.NoError(t, err)
+
+	server, err := NewTransport(serverPriv, nil)
+	require.NoError(t, err)
+	client, err := NewTransport(clientPriv, func(host string, publicKey []byte) error {
+		require.Equal(t, "127.0.0.1:7078", host)
+		require.Equal(t, serverPub, publicKey)
+		return nil
+	})
+	require.NoError(t, err)
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		conn, err := client.Dial(clientConn, "127.0.0.1:7078", true)
+		if err == nil {
+			_, err = conn.Write([]byte("request"))
+			conn.Close()
+		}
+		done <- err
+	}()
+
+	conn, peerKey, err := server.Accept(serverConn)
+	require.NoError(t, err)
+	require.Equal(t, clientPub, peerKey)
+	data, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, "request", string(data))
+	require.NoError(t, <-done)
+}
+
+func TestTransportUnknownNode(t *testing.T) {
+	serverPriv, _, err := crypto.GenBytesKeys()
+	require.NoError(t, err)
+	clientPriv, _, err := crypto.GenBytesKeys()
+	require.NoError(t, err)
+
+	server, err := NewTransport(serverPriv, nil)
+	require.NoError(t, err)
+	client, err := NewTransport(clientPriv, func(string, []byte) error {
+		return ErrUnknownNode
+	})
+	require.NoError(t, err)
+
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		server.Accept(serverConn)
+		serverConn.Close()
+	}()
+	_, err = client.Dial(clientConn, "127.0.0.1:7078", true)
+	require.Error(t, err)
+
+	// the key isn't checked for the requests which can be sent to any node
+	serverConn, clientConn = net.Pipe()
+	go func() {
+		server.Accept(serverConn)
+		serverConn.Close()
+	}()
+	conn, err := client.Dial(clientConn, "127.0.0.1:7078", false)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestTransportPlain(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		clientConn.Write([]byte{RequestTypeFullNode, 0})
+		clientConn.Close()
+	}()
+
+	var transport *Transport
+	conn, peerKey, err := transport.Accept(serverConn)
+	require.NoError(t, err)
+	require.Nil(t, peerKey)
+	data, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, []byte{RequestTypeFullNode, 0}, data)
+
+	_, err = transport.Dial(clientConn, "127.0.0.1:7078", true)
+	require.Equal(t, errNoTransport, err)
+}
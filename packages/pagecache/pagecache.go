@@ -0,0 +1,213 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+// Package pagecache keeps the rendered pages with the tables and rows which have been read
+// while rendering. The block invalidates the pages which depend on the rows it has changed.
+package pagecache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+)
+
+// Deps is the set of the tables and rows which the page has read. The methods can be called
+// for nil Deps so the renderer doesn't have to check if the dependencies are collected
+type Deps struct {
+	keys     map[string]bool
+	volatile bool
+}
+
+// NewDeps returns the empty set of dependencies
+func NewDeps() *Deps {
+	return &Deps{keys: make(map[string]bool)}
+}
+
+func rowKey(table, id string) string {
+	return table + `/` + id
+}
+
+// AddTable marks the whole table as read, any change of the table invalidates the page
+func (d *Deps) AddTable(table string) {
+	if d != nil {
+		d.keys[table] = true
+	}
+}
+
+// AddRow marks the row of the table as read
+func (d *Deps) AddRow(table string, id int64) {
+	if d != nil {
+		d.keys[rowKey(table, strconv.FormatInt(id, 10))] = true
+	}
+}
+
+// SetVolatile marks the page as depending on the data which can't be tracked, such page is not cached
+func (d *Deps) SetVolatile() {
+	if d != nil {
+		d.volatile = true
+	}
+}
+
+// IsVolatile returns true if the page can't be cached
+func (d *Deps) IsVolatile() bool {
+	return d != nil && d.volatile
+}
+
+// Entry is the cached page
+type Entry struct {
+	Value interface{}
+	Hash  string
+}
+
+type item struct {
+	key   string
+	entry Entry
+	deps  []string
+}
+
+// Cache is the LRU cache of the pages
+type Cache struct {
+	mutex      sync.Mutex
+	generation uint64
+	order      *list.List
+	items      map[string]*list.Element
+	depends    map[string]map[string]bool
+}
+
+// NewCache returns the empty cache
+func NewCache() *Cache {
+	return &Cache{
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+		depends: make(map[string]map[string]bool),
+	}
+}
+
+// Generation returns the counter of the invalidations, it must be taken before rendering
+// and passed to Set
+func (c *Cache) Generation() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.generation
+}
+
+// Get returns the cached page
+func (c *Cache) Get(key string) (Entry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*item).entry, true
+	}
+	return Entry{}, false
+}
+
+// Set stores the page if it is not volatile and no block has changed the data since
+// the rendering has started
+func (c *Cache) Set(key string, entry Entry, deps *Deps, generation uint64, size int) {
+	if size <= 0 || deps == nil || deps.IsVolatile() {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	it := &item{key: key, entry: entry, deps: make([]string, 0, len(deps.keys))}
+	for dep := range deps.keys {
+		if c.depends[dep] == nil {
+			c.depends[dep] = make(map[string]bool)
+		}
+		c.depends[dep][key] = true
+		it.deps = append(it.deps, dep)
+	}
+	c.items[key] = c.order.PushFront(it)
+	for c.order.Len() > size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	it := c.order.Remove(el).(*item)
+	delete(c.items, it.key)
+	for _, dep := range it.deps {
+		if keys := c.depends[dep]; keys != nil {
+			delete(keys, it.key)
+			if len(keys) == 0 {
+				delete(c.depends, dep)
+			}
+		}
+	}
+}
+
+// Invalidate removes the pages which have read the table or the row with the specified id
+func (c *Cache) Invalidate(table, id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	for _, dep := range []string{table, rowKey(table, id)} {
+		for key := range c.depends[dep] {
+			if el, ok := c.items[key]; ok {
+				c.remove(el)
+			}
+		}
+	}
+}
+
+// Len returns the count of the cached pages
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+var pages = NewCache()
+
+// Generation returns the counter of the invalidations of the page cache
+func Generation() uint64 {
+	return pages.Generation()
+}
+
+// Get returns the page from the page cache
+func Get(key string) (Entry, bool) {
+	return pages.Get(key)
+}
+
+// Set stores the page in the page cache, the size of the cache is limited by PageCacheSize
+func Set(key string, entry Entry, deps *Deps, generation uint64) {
+	pages.Set(key, entry, deps, generation, conf.Config.PageCacheSize)
+}
+
+// Invalidate removes the pages which depend on the changed row from the page cache
+func Invalidate(table, id string) {
+	pages.Invalidate(table, id)
+}
@@ -0,0 +1,94 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package pagecache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidate(t *testing.T) {
+	c := NewCache()
+	page := NewDeps()
+	page.AddRow("1_pages", 5)
+	page.AddTable("1_keys")
+	c.Set("page", Entry{Value: "tree", Hash: "hash"}, page, c.Generation(), 10)
+	param := NewDeps()
+	param.AddRow("1_parameters", 2)
+	c.Set("param", Entry{Value: "value"}, param, c.Generation(), 10)
+	assert.Equal(t, 2, c.Len())
+
+	entry, ok := c.Get("page")
+	assert.True(t, ok)
+	assert.Equal(t, "hash", entry.Hash)
+
+	c.Invalidate("1_pages", "6")
+	c.Invalidate("1_parameters", "3")
+	assert.Equal(t, 2, c.Len())
+
+	c.Invalidate("1_keys", "100")
+	_, ok = c.Get("page")
+	assert.False(t, ok)
+
+	c.Invalidate("1_parameters", "2")
+	assert.Equal(t, 0, c.Len())
+	assert.Empty(t, c.depends)
+}
+
+func TestSet(t *testing.T) {
+	c := NewCache()
+	deps := NewDeps()
+	deps.AddTable("1_menu")
+
+	generation := c.Generation()
+	c.Invalidate("1_pages", "1")
+	c.Set("stale", Entry{}, deps, generation, 10)
+	_, ok := c.Get("stale")
+	assert.False(t, ok, "the page has been rendered while the block was played")
+
+	volatile := NewDeps()
+	volatile.SetVolatile()
+	c.Set("volatile", Entry{}, volatile, c.Generation(), 10)
+	c.Set("disabled", Entry{}, deps, c.Generation(), 0)
+	assert.Equal(t, 0, c.Len())
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, Entry{}, deps, c.Generation(), 2)
+	}
+	c.Get("b")
+	c.Set("d", Entry{}, deps, c.Generation(), 2)
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.False(t, ok)
+
+	var none *Deps
+	none.AddTable("1_keys")
+	assert.False(t, none.IsVolatile())
+}
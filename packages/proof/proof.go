@@ -0,0 +1,350 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+// Package proof builds and verifies Merkle inclusion proofs of transactions. The proofs can be
+// checked offline with the history of the validator sets only. The rows aren't proven: the state
+// root of the block is the hash of all rows changed by the block rather than the Merkle tree, and
+// the values of the rows at the past blocks aren't kept, so the proof of one row can't be built.
+// The package depends only on crypto and converter so the light clients can use it without the node.
+package proof
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/crypto"
+)
+
+var (
+	// ErrTxHash is returned when the data of the transaction doesn't match its hash
+	ErrTxHash = errors.New("Transaction hash doesn't match the data")
+	// ErrMerkleRoot is returned when the Merkle path doesn't lead to the root of the block
+	ErrMerkleRoot = errors.New("Merkle root doesn't match")
+	// ErrBlockHash is returned when the hash of the header is wrong
+	ErrBlockHash = errors.New("Block hash doesn't match")
+	// ErrSign is returned when the header isn't signed by the node
+	ErrSign = errors.New("Block signature is incorrect")
+	// ErrFirstBlock is returned for the first block which doesn't have the signature
+	ErrFirstBlock = errors.New("First block isn't signed")
+	// ErrValidatorSet is returned when none of the validator sets is active at the block
+	ErrValidatorSet = errors.New("Validator set of the block is unknown")
+)
+
+// Header is the signed block header, all binary values are hex encoded
+type Header struct {
+	BlockID           int64  `json:"block_id"`
+	Time              int64  `json:"time"`
+	EcosystemID       int64  `json:"ecosystem_id"`
+	KeyID             int64  `json:"key_id"`
+	NodePosition      int64  `json:"node_position"`
+	Version           int    `json:"version"`
+	MrklRoot          string `json:"mrkl_root"`
+	StateRoot         string `json:"state_root,omitempty"`
+	Commit            string `json:"commit,omitempty"`
+	SnapshotHash      string `json:"snapshot_hash,omitempty"`
+	Sign              string `json:"sign"`
+	Hash              string `json:"hash"`
+	PrevHash          string `json:"prev_hash"`
+	PrevRollbacksHash string `json:"prev_rollbacks_hash,omitempty"`
+}
+
+// Step is the sibling hash on the Merkle path
+type Step struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left,omitempty"`
+}
+
+// TxProof is the proof of the inclusion of the transaction in the block
+type TxProof struct {
+	Hash   string `json:"hash"`
+	Data   string `json:"data"`
+	Path   []Step `json:"path"`
+	Header Header `json:"header"`
+}
+
+// ValidatorSet contains the hex encoded public keys of the full nodes which are active from the
+// block Activation. The keys are in the order of the positions, the stopped nodes are excluded.
+type ValidatorSet struct {
+	Activation int64    `json:"activation"`
+	Keys       []string `json:"keys"`
+}
+
+// NewTxProof returns the proof of the transaction with index from the list of the full data
+// of the block transactions
+func NewTxProof(header Header, txs [][]byte, index int) (*TxProof, error) {
+	if index < 0 || index >= len(txs) {
+		return nil, fmt.Errorf("transaction %d is out of range", index)
+	}
+	hash, err := txHash(txs[index])
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([][]byte, 0, len(txs))
+	for _, data := range txs {
+		leaf, err := merkleHash(data)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	path, err := merkleTreeProof(leaves, index)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProof{
+		Hash:   hex.EncodeToString(hash),
+		Data:   hex.EncodeToString(txs[index]),
+		Path:   path,
+		Header: header,
+	}, nil
+}
+
+// Verify checks that the transaction belongs to the block and the header of the block
+// is signed by the full node of the validator set which is active at the block
+func (p *TxProof) Verify(sets []ValidatorSet) error {
+	data, err := hex.DecodeString(p.Data)
+	if err != nil {
+		return err
+	}
+	hash, err := txHash(data)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash) != p.Hash {
+		return ErrTxHash
+	}
+	leaf, err := merkleHash(data)
+	if err != nil {
+		return err
+	}
+	root, err := merkleProofRoot(leaf, p.Path)
+	if err != nil {
+		return err
+	}
+	if string(root) != p.Header.MrklRoot {
+		return ErrMerkleRoot
+	}
+	return p.Header.Verify(sets)
+}
+
+// Verify checks the hash of the header and its signature, sets are the validator sets in the
+// order of activation
+func (h *Header) Verify(sets []ValidatorSet) error {
+	if h.BlockID == 1 {
+		return ErrFirstBlock
+	}
+	keys, err := KeysAt(sets, h.BlockID)
+	if err != nil {
+		return err
+	}
+	if h.NodePosition < 0 || h.NodePosition >= int64(len(keys)) {
+		return fmt.Errorf("unknown node position %d", h.NodePosition)
+	}
+	data, err := h.decode()
+	if err != nil {
+		return err
+	}
+	hash, err := crypto.DoubleHash([]byte(h.forSha(data)))
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash) != h.Hash {
+		return ErrBlockHash
+	}
+	ok, err := crypto.CheckSign(keys[h.NodePosition], []byte(h.forSign(data)), data.sign)
+	if err != nil || !ok {
+		return ErrSign
+	}
+	return nil
+}
+
+// headerData contains the decoded binary values of the header
+type headerData struct {
+	stateRoot, commit, snapshotHash, sign, prevHash, prevRollbacksHash []byte
+}
+
+func (h *Header) decode() (data headerData, err error) {
+	for _, item := range []struct {
+		dest  *[]byte
+		value string
+	}{
+		{&data.stateRoot, h.StateRoot},
+		{&data.commit, h.Commit},
+		{&data.snapshotHash, h.SnapshotHash},
+		{&data.sign, h.Sign},
+		{&data.prevHash, h.PrevHash},
+		{&data.prevRollbacksHash, h.PrevRollbacksHash},
+	} {
+		if *item.dest, err = hex.DecodeString(item.value); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// versionData returns the fields of the header which depend on the version of the block,
+// it must match utils.BlockData
+func (h *Header) versionData(data headerData) (ret string) {
+	if h.Version >= consts.BV_ROLLBACK_HASH {
+		ret = fmt.Sprintf(",%x", data.prevRollbacksHash)
+	}
+	if h.Version >= consts.BV_STATE_ROOT {
+		ret += fmt.Sprintf(",%x", data.stateRoot)
+	}
+	if h.Version >= consts.BV_COMMIT {
+		ret += fmt.Sprintf(",%x", data.commit)
+	}
+	if h.Version >= consts.BV_SNAPSHOT_HASH {
+		ret += fmt.Sprintf(",%x", data.snapshotHash)
+	}
+	return
+}
+
+// forSha returns the data of the block hash like utils.BlockData.ForSha
+func (h *Header) forSha(data headerData) string {
+	return fmt.Sprintf("%d,%x,%s,%d,%d,%d,%d",
+		h.BlockID, data.prevHash, h.MrklRoot, h.Time, h.EcosystemID, h.KeyID, h.NodePosition) +
+		h.versionData(data)
+}
+
+// forSign returns the signed data of the block like utils.BlockData.ForSign
+func (h *Header) forSign(data headerData) string {
+	return fmt.Sprintf("0,%v,%x,%v,%v,%v,%v,%s",
+		h.BlockID, data.prevHash, h.Time, h.EcosystemID, h.KeyID, h.NodePosition, h.MrklRoot) +
+		h.versionData(data)
+}
+
+// KeysAt returns the public keys of the full nodes in the order of their positions at the block.
+// The validator set with the latest activation which isn't after the block is used. The first set
+// must be the value of full_nodes at the first block.
+func KeysAt(sets []ValidatorSet, blockID int64) ([][]byte, error) {
+	for i := len(sets) - 1; i >= 0; i-- {
+		if sets[i].Activation > blockID {
+			continue
+		}
+		keys := make([][]byte, 0, len(sets[i].Keys))
+		for _, item := range sets[i].Keys {
+			key, err := hex.DecodeString(item)
+			if err != nil {
+				return nil, err
+			}
+			// the key can be in the uncompressed form like crypto.PubToHex returns
+			if len(key) == consts.PubkeySizeLength+1 && key[0] == 4 {
+				key = key[1:]
+			}
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+	return nil, ErrValidatorSet
+}
+
+// txHash returns the hash of the transaction like transaction.RawTransaction. The hash of the
+// contract transaction is calculated from its payload without the signature.
+func txHash(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrTxHash
+	}
+	if data[0] <= 127 {
+		return crypto.DoubleHash(data)
+	}
+	var payload []byte
+	if err := converter.BinUnmarshalBuff(bytes.NewBuffer(data[1:]), &payload); err != nil {
+		return nil, err
+	}
+	return crypto.DoubleHash(payload)
+}
+
+// merkleHash returns the value of the data in the Merkle tree of the block
+func merkleHash(data []byte) ([]byte, error) {
+	hash, err := crypto.DoubleHash(data)
+	if err != nil {
+		return nil, err
+	}
+	return converter.BinToHex(hash), nil
+}
+
+// merkleTreeProof returns the path from the leaf index to the root which is calculated
+// like utils.MerkleTreeRoot
+func merkleTreeProof(leaves [][]byte, index int) ([]Step, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("merkle leaf %d is out of range", index)
+	}
+	level := make([][]byte, 0, len(leaves))
+	for _, v := range leaves {
+		hash, err := merkleHash(v)
+		if err != nil {
+			return nil, err
+		}
+		level = append(level, hash)
+	}
+	path := make([]Step, 0)
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			// the last odd element goes to the next level as is
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			hash, err := merkleHash(append(append([]byte{}, level[i]...), level[i+1]...))
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, hash)
+		}
+		if sibling := index ^ 1; sibling < len(level) {
+			path = append(path, Step{Hash: string(level[sibling]), Left: sibling < index})
+		}
+		index /= 2
+		level = next
+	}
+	return path, nil
+}
+
+// merkleProofRoot returns the Merkle root which is calculated from the leaf and its path
+func merkleProofRoot(leaf []byte, path []Step) ([]byte, error) {
+	hash, err := merkleHash(leaf)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range path {
+		if step.Left {
+			hash, err = merkleHash(append([]byte(step.Hash), hash...))
+		} else {
+			hash, err = merkleHash(append(hash, step.Hash...))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hash, nil
+}
@@ -0,0 +1,215 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package proof
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/transaction"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nodeKey struct {
+	*ecdsa.PrivateKey
+}
+
+func newNodeKey(t *testing.T) nodeKey {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return nodeKey{private}
+}
+
+func (key nodeKey) public() []byte {
+	return append(converter.FillLeft(key.X.Bytes()), converter.FillLeft(key.Y.Bytes())...)
+}
+
+func (key nodeKey) sign(t *testing.T, data string) []byte {
+	hash, err := crypto.Hash([]byte(data))
+	require.NoError(t, err)
+	r, s, err := ecdsa.Sign(rand.Reader, key.PrivateKey, hash)
+	require.NoError(t, err)
+	return append(converter.FillLeft(r.Bytes()), converter.FillLeft(s.Bytes())...)
+}
+
+func newHeader(header, prev *utils.BlockData, mrklRoot, blockHash []byte) Header {
+	return Header{
+		BlockID:           header.BlockID,
+		Time:              header.Time,
+		EcosystemID:       header.EcosystemID,
+		KeyID:             header.KeyID,
+		NodePosition:      header.NodePosition,
+		Version:           header.Version,
+		MrklRoot:          string(mrklRoot),
+		StateRoot:         hex.EncodeToString(header.StateRoot),
+		Commit:            hex.EncodeToString(header.Commit),
+		SnapshotHash:      hex.EncodeToString(header.SnapshotHash),
+		Sign:              hex.EncodeToString(header.Sign),
+		Hash:              hex.EncodeToString(blockHash),
+		PrevHash:          hex.EncodeToString(prev.Hash),
+		PrevRollbacksHash: hex.EncodeToString(prev.RollbacksHash),
+	}
+}
+
+func TestTxProof(t *testing.T) {
+	key := newNodeKey(t)
+	public, otherPublic := key.public(), newNodeKey(t).public()
+
+	txs := make([][]byte, 0)
+	leaves := make([][]byte, 0)
+	for i := 0; i < 5; i++ {
+		data := []byte(fmt.Sprintf("\x01transaction %d", i))
+		leaf, err := merkleHash(data)
+		require.NoError(t, err)
+		txs = append(txs, data)
+		leaves = append(leaves, leaf)
+	}
+	mrklRoot := utils.MerkleTreeRoot(leaves)
+
+	prev := &utils.BlockData{Hash: []byte("prev hash"), RollbacksHash: []byte("rollbacks")}
+	header := &utils.BlockData{
+		BlockID:      20,
+		Time:         1520000000,
+		KeyID:        -1234567,
+		NodePosition: 1,
+		StateRoot:    []byte("state root"),
+		Version:      consts.BV_STATE_ROOT,
+	}
+	header.Sign = key.sign(t, header.ForSign(prev, mrklRoot))
+	blockHash, err := crypto.DoubleHash([]byte(header.ForSha(prev, mrklRoot)))
+	require.NoError(t, err)
+
+	keys := func(items ...[]byte) []string {
+		list := make([]string, 0, len(items))
+		for _, key := range items {
+			list = append(list, hex.EncodeToString(key))
+		}
+		return list
+	}
+	sets := []ValidatorSet{
+		{Activation: 1, Keys: keys(public, otherPublic)},
+		{Activation: 11, Keys: keys(otherPublic, public)},
+		// the set which becomes active after the block isn't used
+		{Activation: 21, Keys: keys(public, otherPublic)},
+	}
+	for i := range txs {
+		p, err := NewTxProof(newHeader(header, prev, mrklRoot, blockHash), txs, i)
+		require.NoError(t, err)
+		require.NoError(t, p.Verify(sets))
+	}
+
+	p, err := NewTxProof(newHeader(header, prev, mrklRoot, blockHash), txs, 3)
+	require.NoError(t, err)
+
+	wrong := *p
+	wrong.Data = hex.EncodeToString([]byte("\x01transaction 4"))
+	require.Equal(t, ErrTxHash, wrong.Verify(sets))
+
+	wrong = *p
+	wrong.Path = wrong.Path[1:]
+	require.Equal(t, ErrMerkleRoot, wrong.Verify(sets))
+
+	wrong = *p
+	wrong.Header.StateRoot = hex.EncodeToString([]byte("other root"))
+	require.Equal(t, ErrBlockHash, wrong.Verify(sets))
+
+	require.Equal(t, ErrSign, p.Verify(sets[:1]))
+	require.Equal(t, ErrValidatorSet, p.Verify(sets[2:]))
+	require.Error(t, p.Verify([]ValidatorSet{{Activation: 1, Keys: keys(otherPublic)}}))
+}
+
+func TestHeaderData(t *testing.T) {
+	prev := &utils.BlockData{Hash: []byte("prev hash"), RollbacksHash: []byte("rollbacks")}
+	mrklRoot := []byte("mrkl root")
+	for version := 0; version <= consts.BLOCK_VERSION; version++ {
+		header := &utils.BlockData{
+			BlockID:      20,
+			Time:         1520000000,
+			EcosystemID:  1,
+			KeyID:        -1234567,
+			NodePosition: 2,
+			StateRoot:    []byte("state root"),
+			Commit:       []byte("commit"),
+			SnapshotHash: []byte("snapshot hash"),
+			Sign:         []byte("sign"),
+			Version:      version,
+		}
+		h := newHeader(header, prev, mrklRoot, nil)
+		data, err := h.decode()
+		require.NoError(t, err)
+		require.Equal(t, header.ForSha(prev, mrklRoot), h.forSha(data))
+		require.Equal(t, header.ForSign(prev, mrklRoot), h.forSign(data))
+	}
+}
+
+func TestTxHash(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("\x01transaction"),
+		append([]byte{128}, converter.EncodeLengthPlusData([]byte("payload"))...),
+	} {
+		rtx := &transaction.RawTransaction{}
+		require.NoError(t, rtx.Unmarshall(bytes.NewBuffer(append([]byte{}, data...))))
+		hash, err := txHash(data)
+		require.NoError(t, err)
+		require.Equal(t, rtx.Hash(), hash)
+	}
+}
+
+func TestMerkleTreeProof(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		data := make([][]byte, 0, count)
+		for i := 0; i < count; i++ {
+			data = append(data, []byte(fmt.Sprintf("tx%d", i)))
+		}
+		root := utils.MerkleTreeRoot(data)
+		for i := range data {
+			path, err := merkleTreeProof(data, i)
+			require.NoError(t, err)
+			proofRoot, err := merkleProofRoot(data[i], path)
+			require.NoError(t, err)
+			require.Equal(t, root, proofRoot, "count %d, leaf %d", count, i)
+
+			proofRoot, err = merkleProofRoot([]byte("wrong"), path)
+			require.NoError(t, err)
+			require.NotEqual(t, root, proofRoot)
+		}
+	}
+
+	_, err := merkleTreeProof([][]byte{[]byte("tx")}, 1)
+	require.Error(t, err)
+}
@@ -150,3 +150,10 @@ func NewBlockTimeCounter() *BlockTimeCounter {
 
 	return &btc
 }
+
+// NewBlockTimeCounterAt returns BlockTimeCounter for the validator set which is active at the block
+func NewBlockTimeCounterAt(blockID int64) *BlockTimeCounter {
+	btc := NewBlockTimeCounter()
+	btc.numberNodes = int(syspar.GetCountOfActiveNodesAt(blockID))
+	return btc
+}
@@ -33,7 +33,9 @@ import (
 	"fmt"
 
 	"github.com/AplaProject/go-apla/packages/block"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
 	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/events"
 	"github.com/AplaProject/go-apla/packages/model"
 	"github.com/AplaProject/go-apla/packages/transaction"
 	"github.com/AplaProject/go-apla/packages/utils"
@@ -60,6 +62,18 @@ func RollbackBlock(data []byte, deleteBlock bool) error {
 		return err
 	}
 
+	if err = block.LoadChangedRows(dbTransaction); err != nil {
+		dbTransaction.Rollback()
+		return err
+	}
+
+	var rows []events.Event
+	if events.HasSubscribers(events.TypeRow) {
+		if rows, err = block.RowEvents(dbTransaction, true); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting changed rows of block")
+		}
+	}
+
 	err = rollbackBlock(dbTransaction, block)
 
 	if err != nil {
@@ -77,8 +91,19 @@ func RollbackBlock(data []byte, deleteBlock bool) error {
 		}
 	}
 
-	err = dbTransaction.Commit()
-	return err
+	if err = dbTransaction.Commit(); err != nil {
+		return err
+	}
+	syspar.SetBlockID(block.Header.BlockID - 1)
+	block.InvalidatePages()
+
+	events.Publish(append([]events.Event{{
+		Type: events.TypeRollback,
+		Data: events.RollbackEvent{
+			BlockID: block.Header.BlockID,
+		},
+	}}, rows...)...)
+	return nil
 }
 
 func rollbackBlock(dbTransaction *model.DbTransaction, block *block.Block) error {
@@ -133,5 +158,11 @@ func rollbackBlock(dbTransaction *model.DbTransaction, block *block.Block) error
 		}
 	}
 
+	if len(block.Header.Commit) > 0 {
+		if err := model.ResetCarriedCommits(dbTransaction, block.Header.BlockID); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("resetting carried commits")
+			return err
+		}
+	}
 	return nil
 }
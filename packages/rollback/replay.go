@@ -0,0 +1,184 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package rollback
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/block"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/script"
+	"github.com/AplaProject/go-apla/packages/smart"
+	"github.com/AplaProject/go-apla/packages/transaction"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrReplayNotFound is returned when the transaction isn't found in the blockchain
+	ErrReplayNotFound = errors.New(`transaction not found`)
+	// ErrReplayNotContract is returned when the transaction isn't the contract call
+	ErrReplayNotContract = errors.New(`transaction is not a contract call`)
+	// ErrReplayTooFar is returned when the replay requires to rewind more than MaxReplayBlocks blocks
+	ErrReplayTooFar = errors.New(`transaction is too far from the last block`)
+)
+
+const (
+	// MaxReplayBlocks is the maximum count of the blocks which can be rewound for the replay
+	MaxReplayBlocks = 100
+	// replayTimeout limits the waiting for locks, the statements and the idle time of the replay
+	replayTimeout = 10 * time.Second
+)
+
+// rewindRows reverts the changes of rows of the transactions txHashes of the block blockID and
+// of the blocks after it. The records of rollback_tx are kept and the contracts of VM aren't
+// changed, so only the tables of dbTransaction are rewound and it must be rolled back
+func rewindRows(txHashes [][]byte, blockID int64, dbTransaction *model.DbTransaction, logger *log.Entry) error {
+	rollbackTx := &model.RollbackTx{}
+	txs, err := rollbackTx.GetRollbackTransactionsFrom(dbTransaction, txHashes, blockID)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting rollback transactions")
+		return err
+	}
+	for _, tx := range txs {
+		if tx["table_name"] != smart.SysName {
+			if err := rollbackRow(tx, dbTransaction, logger); err != nil {
+				return err
+			}
+			continue
+		}
+		var sysData smart.SysRollData
+		if err := json.Unmarshal([]byte(tx["data"]), &sysData); err != nil {
+			logger.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling rollback.Data from json")
+			return err
+		}
+		switch sysData.Type {
+		case "NewTable":
+			err = smart.SysRollbackTable(dbTransaction, sysData)
+		case "NewColumn":
+			err = smart.SysRollbackColumn(dbTransaction, sysData)
+		case "DeleteColumn":
+			err = smart.SysRollbackDeleteColumn(dbTransaction, sysData)
+		case "DeleteTable":
+			err = smart.SysRollbackDeleteTable(dbTransaction, sysData)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayTransaction executes the contract transaction txHash from the blockchain again on the state
+// right before it or, if blockID is greater than zero, on the state after the block blockID.
+// The execution is never committed and works on the own snapshot of the database with the short
+// timeouts. No more than MaxReplayBlocks blocks can be rewound. The header and the random seed of the original block are
+// used but the contracts are taken from the current VM
+func ReplayTransaction(txHash []byte, blockID int64, tracer script.Tracer) (*smart.Simulation, error) {
+	logger := log.WithFields(log.Fields{"tx_hash": txHash})
+	logTx := &model.LogTransaction{}
+	found, err := logTx.GetByHash(txHash)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting log transaction")
+		return nil, err
+	}
+	if !found {
+		return nil, ErrReplayNotFound
+	}
+	blockModel := &model.Block{}
+	if found, err = blockModel.Get(logTx.Block); err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block")
+		return nil, err
+	} else if !found {
+		return nil, ErrReplayNotFound
+	}
+	blck, err := block.UnmarshallBlock(bytes.NewBuffer(blockModel.Data), blockModel.ID == 1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var replayTx *transaction.Transaction
+	// position is the order of the replayed transaction in its block
+	var position int
+	txHashes := make([][]byte, 0, len(blck.Transactions))
+	for i, t := range blck.Transactions {
+		txHashes = append(txHashes, t.TxHash)
+		if bytes.Equal(t.TxHash, txHash) {
+			replayTx, position = t, i
+		}
+	}
+	if replayTx == nil {
+		return nil, ErrReplayNotFound
+	}
+	if replayTx.TxSmart == nil {
+		return nil, ErrReplayNotContract
+	}
+	seed, err := crypto.CalcChecksum(bytes.Join(txHashes, []byte{}))
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("calculating seed")
+		return nil, err
+	}
+
+	dbTransaction, err := model.StartSnapshotTransaction(replayTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer dbTransaction.Rollback()
+	// the block of the replayed transaction is rewound partially when blockID isn't specified,
+	// the transactions are taken by their order in the block because some of them haven't changed rows
+	firstBlock := blockID + 1
+	var rewound [][]byte
+	if blockID <= 0 {
+		blockID = logTx.Block
+		firstBlock = blockID
+		rewound = txHashes[position:]
+	}
+	infoBlock := &model.InfoBlock{}
+	if _, err = infoBlock.GetTransaction(dbTransaction); err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		return nil, err
+	}
+	if infoBlock.BlockID-firstBlock+1 > MaxReplayBlocks {
+		return nil, ErrReplayTooFar
+	}
+	if err = rewindRows(rewound, blockID, dbTransaction, logger); err != nil {
+		return nil, err
+	}
+	return smart.SimulateContract(replayTx.TxSmart, int64(len(replayTx.TxBinaryData)), &smart.SimulateOptions{
+		BlockData:     &blck.Header,
+		Rand:          rand.New(rand.NewSource(int64(seed))),
+		DbTransaction: dbTransaction,
+		Tracer:        tracer,
+	})
+}
@@ -30,6 +30,7 @@ package rollback
 
 import (
 	"bytes"
+	"errors"
 	"strconv"
 
 	"github.com/AplaProject/go-apla/packages/consts"
@@ -40,8 +41,28 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// ErrFinalized is returned if the rollback crosses the finalized block
+var ErrFinalized = errors.New("Finalized blocks can't be rolled back")
+
+// CheckFinality returns ErrFinalized if the blocks after blockID can't be rolled back
+func CheckFinality(blockID int64) error {
+	finalized, err := model.GetFinalizedBlockID(nil)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting finalized block id")
+		return err
+	}
+	if blockID < finalized {
+		log.WithFields(log.Fields{"type": consts.BlockError, "block_id": blockID, "finalized": finalized}).Error("rollback crosses the finalized block")
+		return ErrFinalized
+	}
+	return nil
+}
+
 // ToBlockID rollbacks blocks till blockID
 func ToBlockID(blockID int64, dbTransaction *model.DbTransaction, logger *log.Entry) error {
+	if err := CheckFinality(blockID); err != nil {
+		return err
+	}
 	_, err := model.MarkVerifiedAndNotUsedTransactionsUnverified()
 	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("marking verified and not used transactions unverified")
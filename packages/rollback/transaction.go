@@ -73,6 +73,22 @@ func rollbackInsertedRow(tx map[string]string, where string, dbTransaction *mode
 	return nil
 }
 
+func rollbackRow(tx map[string]string, dbTransaction *model.DbTransaction, logger *log.Entry) error {
+	where := " WHERE id='" + tx["table_id"] + `'`
+	table := tx[`table_name`]
+	if under := strings.IndexByte(table, '_'); under > 0 {
+		keyName := table[under+1:]
+		if v, ok := converter.FirstEcosystemTables[keyName]; ok && !v {
+			where += fmt.Sprintf(` AND ecosystem='%d'`, converter.StrToInt64(table[:under]))
+			tx[`table_name`] = `1_` + keyName
+		}
+	}
+	if len(tx["data"]) > 0 {
+		return rollbackUpdatedRow(tx, where, dbTransaction, logger)
+	}
+	return rollbackInsertedRow(tx, where, dbTransaction, logger)
+}
+
 func rollbackTransaction(txHash []byte, dbTransaction *model.DbTransaction, logger *log.Entry) error {
 	rollbackTx := &model.RollbackTx{}
 	txs, err := rollbackTx.GetRollbackTransactions(dbTransaction, txHash)
@@ -110,23 +126,8 @@ func rollbackTransaction(txHash []byte, dbTransaction *model.DbTransaction, logg
 			}
 			continue
 		}
-		where := " WHERE id='" + tx["table_id"] + `'`
-		table := tx[`table_name`]
-		if under := strings.IndexByte(table, '_'); under > 0 {
-			keyName := table[under+1:]
-			if v, ok := converter.FirstEcosystemTables[keyName]; ok && !v {
-				where += fmt.Sprintf(` AND ecosystem='%d'`, converter.StrToInt64(table[:under]))
-				tx[`table_name`] = `1_` + keyName
-			}
-		}
-		if len(tx["data"]) > 0 {
-			if err := rollbackUpdatedRow(tx, where, dbTransaction, logger); err != nil {
-				return err
-			}
-		} else {
-			if err := rollbackInsertedRow(tx, where, dbTransaction, logger); err != nil {
-				return err
-			}
+		if err := rollbackRow(tx, dbTransaction, logger); err != nil {
+			return err
 		}
 	}
 	txForDelete := &model.RollbackTx{TxHash: txHash}
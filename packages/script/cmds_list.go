@@ -54,6 +54,9 @@ const (
 	cmdMapInit               // map initialization
 	cmdArrayInit             // array initialization
 	cmdError                 // error command
+	cmdForEach               // for item in array, map or string
+	cmdSlice                 // get slice [:]
+	cmdTry                   // try and catch blocks
 )
 
 // the commands for operations in expressions are listed below
@@ -79,3 +82,48 @@ const (
 	cmdSys          = 0xff
 	cmdUnary uint16 = 50
 )
+
+// cmdNames contains the names of commands which are used in traces
+var cmdNames = map[uint16]string{
+	cmdPush:       `push`,
+	cmdVar:        `var`,
+	cmdExtend:     `extend`,
+	cmdCallExtend: `callextend`,
+	cmdPushStr:    `pushstr`,
+	cmdCall:       `call`,
+	cmdCallVari:   `callvari`,
+	cmdReturn:     `return`,
+	cmdIf:         `if`,
+	cmdElse:       `else`,
+	cmdAssignVar:  `assignvar`,
+	cmdAssign:     `assign`,
+	cmdLabel:      `label`,
+	cmdContinue:   `continue`,
+	cmdWhile:      `while`,
+	cmdBreak:      `break`,
+	cmdIndex:      `index`,
+	cmdSetIndex:   `setindex`,
+	cmdFuncName:   `funcname`,
+	cmdUnwrapArr:  `unwraparr`,
+	cmdMapInit:    `mapinit`,
+	cmdArrayInit:  `arrayinit`,
+	cmdError:      `error`,
+	cmdForEach:    `foreach`,
+	cmdSlice:      `slice`,
+	cmdTry:        `try`,
+	cmdNot:        `not`,
+	cmdSign:       `sign`,
+	cmdAdd:        `add`,
+	cmdSub:        `sub`,
+	cmdMul:        `mul`,
+	cmdDiv:        `div`,
+	cmdAnd:        `and`,
+	cmdOr:         `or`,
+	cmdEqual:      `equal`,
+	cmdNotEq:      `noteq`,
+	cmdLess:       `less`,
+	cmdNotLess:    `notless`,
+	cmdGreat:      `great`,
+	cmdNotGreat:   `notgreat`,
+	cmdSys:        `sys`,
+}
@@ -77,6 +77,12 @@ type mapItem struct {
 	Value interface{}
 }
 
+// withStateParam returns true if the compiler passes the ecosystem of the contract
+// as the additional last parameter of the function
+func withStateParam(name string) bool {
+	return name == `CallContract`
+}
+
 // The compiler converts the sequence of lexemes into the bytecodes using a finite state machine the same as
 // it was implemented in lexical analysis. The difference lays in that we do not convert the list of
 // states and transitions to the intermediate array.
@@ -121,6 +127,10 @@ const (
 	stateConstsAssign
 	stateConstsValue
 	stateFields
+	stateFor
+	stateForIn
+	stateForEval
+	stateCatch
 	stateEval
 
 	// The list of state flags
@@ -149,6 +159,7 @@ const (
 	errVarType               // must be type
 	errAssign                // must be '='
 	errStrNum                // must be number or string
+	errMustIn                // must be 'in'
 )
 
 const (
@@ -182,6 +193,12 @@ const (
 	cfContinue
 	cfBreak
 	cfCmdError
+	cfForVar
+	cfForIn
+	cfFor
+	cfTry
+	cfCatch
+	cfCatchVar
 
 //	cfEval
 )
@@ -222,6 +239,12 @@ var (
 		fContinue,
 		fBreak,
 		fCmdError,
+		fForVar,
+		fForIn,
+		fFor,
+		fTry,
+		fCatch,
+		fCatchVar,
 	}
 
 	// 'states' describes a finite machine with states on the base of which a bytecode will be generated
@@ -240,6 +263,9 @@ var (
 			lexKeyword | (keyBreak << 8):    {stateBody, cfBreak},
 			lexKeyword | (keyIf << 8):       {stateEval | statePush | stateToBlock | stateMustEval, cfIf},
 			lexKeyword | (keyWhile << 8):    {stateEval | statePush | stateToBlock | stateLabel | stateMustEval, cfWhile},
+			lexKeyword | (keyFor << 8):      {stateFor, 0},
+			lexKeyword | (keyTry << 8):      {stateBlock | statePush, cfTry},
+			lexKeyword | (keyCatch << 8):    {stateCatch | statePush, cfCatch},
 			lexKeyword | (keyElse << 8):     {stateBlock | statePush, cfElse},
 			lexKeyword | (keyVar << 8):      {stateVar, 0},
 			lexKeyword | (keyTX << 8):       {stateTX, cfTX},
@@ -365,6 +391,25 @@ var (
 			isRCurly:   {stateToBody, cfFields},
 			0:          {errMustRCurly, cfError},
 		},
+		{ // stateFor
+			lexIdent: {stateForIn, cfForVar},
+			0:        {errVars, cfError},
+		},
+		{ // stateForIn
+			isComma:  {stateFor, 0},
+			lexIdent: {stateForEval, cfForIn},
+			0:        {errMustIn, cfError},
+		},
+		{ // stateForEval
+			lexNewLine: {stateForEval, 0},
+			0:          {stateEval | statePush | stateToBlock | stateMustEval, cfFor},
+		},
+		{ // stateCatch
+			lexNewLine: {stateCatch, 0},
+			lexIdent:   {stateBlock, cfCatchVar},
+			isLCurly:   {stateBody, 0},
+			0:          {errMustLCurly, cfError},
+		},
 	}
 )
 
@@ -379,6 +424,7 @@ func fError(buf *[]*Block, state int, lexem *Lexem) error {
 		`must be type`,             // errVarType
 		`must be '='`,              // errAssign
 		`must be number or string`, // errStrNum
+		`must be 'in'`,             // errMustIn
 	}
 	fmt.Printf("%s %x %v [Ln:%d Col:%d]\r\n", errors[state], lexem.Type, lexem.Value, lexem.Line, lexem.Column)
 	logger := lexem.GetLogger()
@@ -429,6 +475,7 @@ func fFparam(buf *[]*Block, state int, lexem *Lexem) error {
 	}
 	block.Objects[lexem.Value.(string)] = &ObjInfo{Type: ObjVar, Value: len(block.Vars)}
 	block.Vars = append(block.Vars, reflect.TypeOf(nil))
+	block.VarDecls = append(block.VarDecls, lexem)
 	return nil
 }
 
@@ -536,6 +583,101 @@ func fWhile(buf *[]*Block, state int, lexem *Lexem) error {
 	return nil
 }
 
+// declareVar returns the visible variable with the name of the lexem. If there is not such variable
+// then the untyped variable is declared in the specified block
+func declareVar(buf *[]*Block, block *Block, lexem *Lexem) (*VarInfo, error) {
+	objInfo, tobj := findVar(lexem.Value.(string), buf)
+	if objInfo == nil {
+		if block.Objects == nil {
+			block.Objects = make(map[string]*ObjInfo)
+		}
+		objInfo = &ObjInfo{Type: ObjVar, Value: len(block.Vars)}
+		tobj = block
+		block.Objects[lexem.Value.(string)] = objInfo
+		block.Vars = append(block.Vars, reflect.TypeOf((*interface{})(nil)).Elem())
+		block.VarDecls = append(block.VarDecls, lexem)
+	} else if objInfo.Type != ObjVar {
+		lexem.GetLogger().WithFields(log.Fields{"type": consts.ParseError, "lex_value": lexem.Value.(string)}).Error("unknown variable")
+		return nil, fmt.Errorf(`unknown variable %s`, lexem.Value.(string))
+	}
+	return &VarInfo{objInfo, tobj}, nil
+}
+
+// fForVar declares the variable of for loop if it hasn't been declared yet
+// and appends it to the list of the assigned variables
+func fForVar(buf *[]*Block, state int, lexem *Lexem) error {
+	block := (*buf)[len(*buf)-1]
+	var vars []*VarInfo
+	if len(block.Code) > 0 && block.Code[len(block.Code)-1].Cmd == cmdAssignVar {
+		vars = block.Code[len(block.Code)-1].Value.([]*VarInfo)
+	}
+	if len(vars) == 2 {
+		return fError(buf, errVars, lexem)
+	}
+	ivar, err := declareVar(buf, block, lexem)
+	if err != nil {
+		return err
+	}
+	vars = append(vars, ivar)
+	if len(vars) == 1 {
+		block.Code = append(block.Code, &ByteCode{cmdAssignVar, vars})
+	} else {
+		block.Code[len(block.Code)-1] = &ByteCode{cmdAssignVar, vars}
+	}
+	return nil
+}
+
+func fForIn(buf *[]*Block, state int, lexem *Lexem) error {
+	if lexem.Value.(string) != `in` {
+		return fError(buf, errMustIn, lexem)
+	}
+	return nil
+}
+
+// fFor appends the for loop to the parent block. The variables of the loop are taken
+// from the last cmdAssignVar which has been added by fForVar before the expression.
+func fFor(buf *[]*Block, state int, lexem *Lexem) error {
+	parent := (*buf)[len(*buf)-2]
+	var vars []*VarInfo
+	for i := len(parent.Code) - 1; i >= 0; i-- {
+		if parent.Code[i].Cmd == cmdAssignVar {
+			vars = parent.Code[i].Value.([]*VarInfo)
+			break
+		}
+	}
+	parent.Code = append(parent.Code, &ByteCode{cmdForEach, &ForEachInfo{Vars: vars, Block: (*buf)[len(*buf)-1]}})
+	return nil
+}
+
+func fTry(buf *[]*Block, state int, lexem *Lexem) error {
+	(*(*buf)[len(*buf)-2]).Code = append((*(*buf)[len(*buf)-2]).Code, &ByteCode{cmdTry, &TryInfo{Try: (*buf)[len(*buf)-1]}})
+	return nil
+}
+
+// fCatch attaches the catch block to try block which must be the previous statement
+func fCatch(buf *[]*Block, state int, lexem *Lexem) error {
+	parent := (*buf)[len(*buf)-2]
+	if len(parent.Code) == 0 || parent.Code[len(parent.Code)-1].Cmd != cmdTry ||
+		parent.Code[len(parent.Code)-1].Value.(*TryInfo).Catch != nil {
+		lexem.GetLogger().WithFields(log.Fields{"type": consts.ParseError}).Error("catch without try")
+		return errCatch
+	}
+	parent.Code[len(parent.Code)-1].Value.(*TryInfo).Catch = (*buf)[len(*buf)-1]
+	return nil
+}
+
+// fCatchVar declares the variable of the caught error in the block of try statement
+// so it can be assigned before the catch block is started
+func fCatchVar(buf *[]*Block, state int, lexem *Lexem) error {
+	parent := (*buf)[len(*buf)-2]
+	ivar, err := declareVar(buf, parent, lexem)
+	if err != nil {
+		return err
+	}
+	parent.Code[len(parent.Code)-1].Value.(*TryInfo).Var = ivar
+	return nil
+}
+
 func fContinue(buf *[]*Block, state int, lexem *Lexem) error {
 	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{cmdContinue, 0})
 	return nil
@@ -729,22 +871,52 @@ func fNameBlock(buf *[]*Block, state int, lexem *Lexem) error {
 			Owner: (*buf)[0].Owner}
 	default:
 		itype = ObjFunc
-		fblock.Info = &FuncInfo{}
+		fblock.Info = &FuncInfo{Name: name}
 	}
 	fblock.Type = itype
 	prev.Objects[name] = &ObjInfo{Type: itype, Value: fblock}
 	return nil
 }
 
+// setLines binds the line of the source code to the new byte-codes of the blocks
+func setLines(blockstack []*Block, line uint32) {
+	for _, block := range blockstack {
+		if len(block.Lines) > len(block.Code) {
+			block.Lines = block.Lines[:len(block.Code)]
+		}
+		for len(block.Lines) < len(block.Code) {
+			block.Lines = append(block.Lines, line)
+		}
+	}
+}
+
 // CompileBlock compile the source code into the Block structure with a byte-code
 func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*Block, error) {
+	root, _, err := vm.compileBlock(input, owner)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range root.Objects {
+		if item.Type == ObjContract {
+			if cond, ok := item.Value.(*Block).Objects[`conditions`]; ok {
+				if cond.Type == ObjFunc && cond.Value.(*Block).Info.(*FuncInfo).CanWrite {
+					return nil, errCondWrite
+				}
+			}
+		}
+	}
+	return root, nil
+}
+
+// compileBlock compiles the source code and returns the lexem where the compilation has failed
+func (vm *VM) compileBlock(input []rune, owner *OwnerInfo) (*Block, *Lexem, error) {
 	root := &Block{Info: owner.StateID, Owner: owner}
 	lexems, err := lexParser(input)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if len(lexems) == 0 {
-		return root, nil
+		return root, nil, nil
 	}
 	curState := 0
 	stack := make([]int, 0, 64)
@@ -782,15 +954,19 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*Block, error) {
 			}
 			curlen := len((*blockstack[len(blockstack)-1]).Code)
 			if err := vm.compileEval(&lexems, &i, &blockstack); err != nil {
-				return nil, err
+				return nil, lexem, err
 			}
 			if (newState.NewState&stateMustEval) > 0 && curlen == len((*blockstack[len(blockstack)-1]).Code) {
 				log.WithFields(log.Fields{"type": consts.ParseError}).Error("there is not eval expression")
-				return nil, fmt.Errorf("there is not eval expression")
+				return nil, lexem, fmt.Errorf("there is not eval expression")
 			}
 			nextState = curState
 		}
 		if (newState.NewState & statePush) > 0 {
+			if curState == stateForEval {
+				// the body of for loop is followed by the next statement
+				curState = stateBody
+			}
 			stack = append(stack, curState)
 			top := blockstack[len(blockstack)-1]
 			if top.Objects == nil {
@@ -800,9 +976,10 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*Block, error) {
 			top.Children = append(top.Children, block)
 			blockstack = append(blockstack, block)
 		}
+		setLines(blockstack, lexem.Line)
 		if (newState.NewState & statePop) > 0 {
 			if len(stack) == 0 {
-				return nil, fError(&blockstack, errMustLCurly, lexem)
+				return nil, lexem, fError(&blockstack, errMustLCurly, lexem)
 			}
 			nextState = stack[len(stack)-1]
 			stack = stack[:len(stack)-1]
@@ -825,24 +1002,16 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*Block, error) {
 		}
 		if newState.Func > 0 {
 			if err := funcs[newState.Func](&blockstack, nextState, lexem); err != nil {
-				return nil, err
+				return nil, lexem, err
 			}
 		}
+		setLines(blockstack, lexem.Line)
 		curState = nextState
 	}
 	if len(stack) > 0 {
-		return nil, fError(&blockstack, errMustRCurly, lexems[len(lexems)-1])
+		return nil, lexems[len(lexems)-1], fError(&blockstack, errMustRCurly, lexems[len(lexems)-1])
 	}
-	for _, item := range root.Objects {
-		if item.Type == ObjContract {
-			if cond, ok := item.Value.(*Block).Objects[`conditions`]; ok {
-				if cond.Type == ObjFunc && cond.Value.(*Block).Info.(*FuncInfo).CanWrite {
-					return nil, errCondWrite
-				}
-			}
-		}
-	}
-	return root, nil
+	return root, nil, nil
 }
 
 // FlushBlock loads the compiled Block into the virtual machine
@@ -1259,8 +1428,28 @@ main:
 					}
 				}
 			}
+		case isColon:
+			for len(buffer) > 0 {
+				prev := buffer[len(buffer)-1]
+				if prev.Cmd == cmdSys && prev.Value.(uint16) == 0xff {
+					break
+				}
+				bytecode = append(bytecode, prev)
+				buffer = buffer[:len(buffer)-1]
+			}
+			if len(buffer) < 2 || buffer[len(buffer)-2].Cmd != cmdIndex {
+				logger.WithFields(log.Fields{"type": consts.ParseError}).Error("unexpected colon")
+				return errSliceColon
+			}
+			if prevLex == isLBrack {
+				bytecode = append(bytecode, &ByteCode{cmdPush, nil})
+			}
+			buffer[len(buffer)-2] = &ByteCode{cmdSlice, buffer[len(buffer)-2].Value}
 		case isRBrack:
 			noMap = true
+			if prevLex == isColon {
+				bytecode = append(bytecode, &ByteCode{cmdPush, nil})
+			}
 			for {
 				if len(buffer) == 0 {
 					logger.WithFields(log.Fields{"lex_value": lexem.Value.(string), "type": consts.ParseError}).Error("there is not pair")
@@ -1275,9 +1464,12 @@ main:
 				}
 			}
 			if len(buffer) > 0 {
-				if prev := buffer[len(buffer)-1]; prev.Cmd == cmdIndex {
+				if prev := buffer[len(buffer)-1]; prev.Cmd == cmdIndex || prev.Cmd == cmdSlice {
 					buffer = buffer[:len(buffer)-1]
 					if i < len(*lexems)-1 && (*lexems)[i+1].Type == isEq {
+						if prev.Cmd == cmdSlice {
+							return errSliceAssign
+						}
 						i++
 						setIndex = true
 						indexInfo = prev.Value.(*IndexInfo)
@@ -1417,7 +1609,7 @@ main:
 						}
 						count++
 					}
-					if lexem.Value.(string) == `CallContract` {
+					if withStateParam(lexem.Value.(string)) {
 						count++
 						bytecode = append(bytecode, &ByteCode{cmdPush, (*block)[0].Info.(uint32)})
 					}
@@ -683,6 +683,140 @@ func TestVMCompile(t *testing.T) {
 		{`func result() {
 				error "test"*
 				}`, `result`, `unexpected end of the expression`},
+		{`func result() string {
+			var list array
+			var m map
+			var out string
+			list = [10, 20, 30, 40]
+			for v in list {
+				if v == 30 {
+					continue
+				}
+				out = out + Sprintf("%d,", v)
+			}
+			m = {"a": 1, "b": "two"}
+			for k, v in m {
+				out = out + k + "=" + Sprintf("%v;", v)
+			}
+			for i, c in "абв" {
+				if i == 2 {
+					break
+				}
+				out = out + c
+			}
+			for v in list[4:] {
+				out = out + "?"
+			}
+			return out
+		}`, `result`, `10,20,40,a=1;b=two;аб`},
+		{`func sum(list array) int {
+			var total int
+			for v in list {
+				if v > 2 {
+					return total
+				}
+				total = total + v
+			}
+			return total
+		}
+		func result() string {
+			var list array
+			var i int
+			var s, w string
+			list = [1, 2, 3, 4]
+			s = "hello"
+			w = "мир"
+			while i < 2 {
+				i = i + 1
+				for j, v in list[i:] {
+					if j == 1 {
+						break
+					}
+				}
+			}
+			return Sprintf("%d %d %v %v %v %s %s", sum(list), i, list[1:3], list[:1], list[4:], s[1:3], w[:2])
+		}`, `result`, `3 2 [2 3] [1] [] el ми`},
+		{`func result() string {
+			var list array
+			var v money
+			var total money
+			list = ["15", 2, "300"]
+			for v in list {
+				total = total + v
+			}
+			return Sprintf("%v %v", v, total)
+		}`, `result`, `300 317`},
+		{`func result() string {
+			var list array
+			list = [1, 2]
+			return Sprintf("%v", list[1:3])
+		}`, `result`, `slice bounds out of range`},
+		{`func result() string {
+			var list array
+			list[0:1] = 1
+			return "ok"
+		}`, `result`, `slice expression cannot be assigned`},
+		{`func result() string {
+			for k, v, e in "abc" {
+			}
+			return "ok"
+		}`, `result`, `wrong variables 4 e [Ln:2 Col:15]`},
+		{`func result() string {
+			for v on "abc" {
+			}
+			return "ok"
+		}`, `result`, `must be 'in' 4 on [Ln:2 Col:11]`},
+		{`func result() string {
+			var i int
+			for v in i {
+			}
+			return "ok"
+		}`, `result`, `type int64 doesn't support for loop`},
+		{`func fail(s string) string {
+			if s == "warn" {
+				warning "Warning text"
+			}
+			error Sprintf("Error %s", s)
+			return s
+		}
+		func result() string {
+			var out string
+			try {
+				out = "first"
+				fail("one")
+				out = "unreachable"
+			} catch err {
+				out = out + " " + err["type"] + ":" + err["error"]
+			}
+			try {
+				try {
+					fail("warn")
+				} catch e {
+					fail(e["type"])
+				}
+			} catch err {
+				out = out + " " + err["error"]
+			}
+			try {
+				fail("three")
+			}
+			var i int
+			while i < 3 {
+				i = i + 1
+				try {
+					if i == 2 {
+						break
+					}
+				} catch {
+				}
+			}
+			return Sprintf("%s %d", out, i)
+		}`, `result`, `first error:Error one Error warning 2`},
+		{`func result() string {
+			catch err {
+			}
+			return "ok"
+		}`, `result`, `catch without try`},
 	}
 	vm := NewVM()
 	vm.Extern = true
@@ -44,6 +44,9 @@ const (
 	eDataType        = `expecting type of the data field [Ln:%d Col:%d]`
 	eDataName        = `expecting name of the data field [Ln:%d Col:%d]`
 	eDataTag         = `unexpected tag [Ln:%d Col:%d]`
+	eForType         = `type %T doesn't support for loop`
+	eSliceType       = `type %T doesn't support slicing`
+	eSliceIndex      = `index of slice cannot be type %T`
 )
 
 var (
@@ -65,4 +68,8 @@ var (
 	errSelfAssignment  = errors.New(`self assignment`)
 	errEndExp          = errors.New(`unexpected end of the expression`)
 	errOper            = errors.New(`unexpected operator; expecting operand`)
+	errSliceColon      = errors.New(`unexpected colon; expecting slice expression`)
+	errSliceAssign     = errors.New(`slice expression cannot be assigned`)
+	errSliceRange      = errors.New(`slice bounds out of range`)
+	errCatch           = errors.New(`catch without try`)
 )
@@ -121,6 +121,9 @@ const (
 	keyCond
 	keyTail
 	keyError
+	keyFor
+	keyTry
+	keyCatch
 )
 
 const (
@@ -151,7 +154,7 @@ var (
 	// The list of key words
 	keywords = map[string]uint32{`contract`: keyContract, `func`: keyFunc, `return`: keyReturn,
 		`if`: keyIf, `elif`: keyElif, `else`: keyElse, msgError: keyError, msgWarning: keyWarning,
-		msgInfo: keyInfo, `while`: keyWhile, `data`: keyTX, `settings`: keySettings, `nil`: keyNil,
+		msgInfo: keyInfo, `while`: keyWhile, `for`: keyFor, `try`: keyTry, `catch`: keyCatch, `data`: keyTX, `settings`: keySettings, `nil`: keyNil,
 		`action`: keyAction, `conditions`: keyCond,
 		`true`: keyTrue, `false`: keyFalse, `break`: keyBreak, `continue`: keyContinue,
 		`var`: keyVar, `...`: keyTail}
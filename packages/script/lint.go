@@ -0,0 +1,424 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package script
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/AplaProject/go-apla/packages/types"
+)
+
+const (
+	// LintError is the level of the code which fails at runtime
+	LintError = `error`
+	// LintWarning is the level of the suspicious code
+	LintWarning = `warning`
+)
+
+// The rules of the linter
+const (
+	LintRuleCompile    = `compile`          // the code can't be compiled
+	LintRuleExtern     = `extern`           // the unknown extern function is called
+	LintRuleUnknown    = `unknown`          // the unknown function or contract is called
+	LintRuleParams     = `params`           // the function is called with the wrong count of parameters
+	LintRuleUnused     = `unused`           // the variable is never used
+	LintRuleConditions = `conditions-write` // the conditions modify the database
+)
+
+// LintRuleSets are the frozen sets of the rules whose errors reject the contracts when
+// strict_contracts equals the version of the set. The result of the contract check is the part
+// of the consensus, so the checks of the published set must never be changed. The new or changed
+// checks get new rule names and are added to the new version of the set. The rules depending on
+// the settings of the node, as the list of extern functions, aren't included
+var LintRuleSets = map[int64]map[string]bool{
+	1: {LintRuleParams: true, LintRuleConditions: true},
+}
+
+// LintRejected returns the errors of the messages whose rules belong to the set of the version
+func LintRejected(messages []*LintMessage, version int64) []*LintMessage {
+	rules := LintRuleSets[version]
+	ret := make([]*LintMessage, 0)
+	for _, msg := range messages {
+		if msg.Level == LintError && rules[msg.Rule] {
+			ret = append(ret, msg)
+		}
+	}
+	return ret
+}
+
+var lintPosition = regexp.MustCompile(`\[Ln:(\d+) Col:(\d+)\]`)
+
+// LintMessage is the error or the warning found by the linter
+type LintMessage struct {
+	Level  string `json:"level"`
+	Rule   string `json:"rule"`
+	Line   uint32 `json:"line"`
+	Column uint32 `json:"column"`
+	Text   string `json:"text"`
+}
+
+func (msg *LintMessage) String() string {
+	return fmt.Sprintf(`%d:%d: %s: %s`, msg.Line, msg.Column, msg.Level, msg.Text)
+}
+
+// LintOptions contains the settings of the linter
+type LintOptions struct {
+	Externs map[string]bool // the functions which can be called as $name()
+}
+
+// LintHasErrors returns true if there is at least one error in the messages
+func LintHasErrors(messages []*LintMessage) bool {
+	for _, msg := range messages {
+		if msg.Level == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+type linter struct {
+	vm       *VM
+	owner    *OwnerInfo
+	options  *LintOptions
+	lexems   Lexems
+	objects  map[string]*ObjInfo
+	messages []*LintMessage
+}
+
+type lintVar struct {
+	owner  *Block
+	offset int
+}
+
+// Lint compiles the source code and checks it for the errors which the compiler accepts but
+// the execution fails with, and for the suspicious code. The source code isn't loaded into VM
+func (vm *VM) Lint(input []rune, owner *OwnerInfo, options *LintOptions) []*LintMessage {
+	if options == nil {
+		options = &LintOptions{}
+	}
+	l := &linter{
+		vm:       vm,
+		owner:    owner,
+		options:  options,
+		objects:  make(map[string]*ObjInfo),
+		messages: make([]*LintMessage, 0),
+	}
+	root, lexem, err := vm.compileBlock(input, owner)
+	if err != nil {
+		msg := &LintMessage{Level: LintError, Rule: LintRuleCompile, Text: err.Error()}
+		if pos := lintPosition.FindStringSubmatch(msg.Text); len(pos) == 3 {
+			line, _ := strconv.ParseUint(pos[1], 10, 32)
+			column, _ := strconv.ParseUint(pos[2], 10, 32)
+			msg.Line, msg.Column = uint32(line), uint32(column)
+		} else if lexem != nil {
+			msg.Line, msg.Column = lexem.Line, lexem.Column
+		}
+		return append(l.messages, msg)
+	}
+	if l.lexems, err = lexParser(input); err != nil {
+		return append(l.messages, &LintMessage{Level: LintError, Rule: LintRuleCompile, Text: err.Error()})
+	}
+	l.collectObjects(root)
+	l.checkCalls()
+	l.checkVars(root)
+	for _, item := range root.Objects {
+		if item.Type != ObjContract {
+			continue
+		}
+		if cond, ok := item.Value.(*Block).Objects[`conditions`]; ok && cond.Type == ObjFunc {
+			l.checkConditions(cond.Value.(*Block))
+		}
+	}
+	sort.SliceStable(l.messages, func(i, j int) bool {
+		if l.messages[i].Line == l.messages[j].Line {
+			return l.messages[i].Column < l.messages[j].Column
+		}
+		return l.messages[i].Line < l.messages[j].Line
+	})
+	return l.messages
+}
+
+func (l *linter) add(level, rule string, line, column uint32, format string, args ...interface{}) {
+	l.messages = append(l.messages, &LintMessage{Level: level, Rule: rule, Line: line, Column: column,
+		Text: fmt.Sprintf(format, args...)})
+}
+
+// column returns the column of the identifier name in the line
+func (l *linter) column(line uint32, name string) uint32 {
+	for _, lexem := range l.lexems {
+		if lexem.Line == line && lexem.Type == lexIdent && lexem.Value.(string) == name {
+			return lexem.Column
+		}
+	}
+	return 0
+}
+
+func (l *linter) collectObjects(block *Block) {
+	for name, item := range block.Objects {
+		if item.Type != ObjContract && item.Type != ObjFunc {
+			continue
+		}
+		if _, ok := l.objects[name]; !ok {
+			l.objects[name] = item
+		}
+		if item.Type == ObjContract {
+			l.collectObjects(item.Value.(*Block))
+		}
+	}
+}
+
+func (l *linter) findObj(name string) *ObjInfo {
+	if item, ok := l.objects[name]; ok {
+		return item
+	}
+	if item, ok := l.objects[StateName(l.owner.StateID, name)]; ok {
+		return item
+	}
+	return l.vm.getObjByNameExt(name, l.owner.StateID)
+}
+
+// countParams returns the count of parameters of the call which starts with the left parenthesis
+func (l *linter) countParams(start int) int {
+	var (
+		depth, count int
+		empty        = true
+	)
+	for i := start; i < len(l.lexems); i++ {
+		switch l.lexems[i].Type {
+		case isLPar, isLBrack, isLCurly:
+			depth++
+		case isRPar, isRBrack, isRCurly:
+			depth--
+			if depth == 0 {
+				if empty {
+					return 0
+				}
+				return count + 1
+			}
+		case isComma:
+			if depth == 1 {
+				count++
+			}
+		}
+		if i > start && l.lexems[i].Type != lexNewLine {
+			empty = false
+		}
+	}
+	return count + 1
+}
+
+func (l *linter) checkCalls() {
+	for i, lexem := range l.lexems {
+		if i+1 >= len(l.lexems) || l.lexems[i+1].Type != isLPar {
+			continue
+		}
+		name, ok := lexem.Value.(string)
+		if !ok {
+			continue
+		}
+		switch lexem.Type {
+		case lexExtend:
+			if !l.options.Externs[name] {
+				l.add(LintError, LintRuleExtern, lexem.Line, lexem.Column, `unknown extern function $%s`, name)
+			}
+		case lexIdent:
+			if i > 0 {
+				prev := l.lexems[i-1]
+				if prev.Type == isDot || prev.Type == lexKeyword|(keyFunc<<8) ||
+					prev.Type == lexKeyword|(keyContract<<8) {
+					continue
+				}
+			}
+			obj := l.findObj(name)
+			if obj == nil {
+				l.add(LintWarning, LintRuleUnknown, lexem.Line, lexem.Column, `unknown function or contract %s`, name)
+				continue
+			}
+			var (
+				want     int
+				variadic bool
+			)
+			switch obj.Type {
+			case ObjFunc:
+				info := obj.Value.(*Block).Info.(*FuncInfo)
+				want, variadic = len(info.Params), info.Variadic
+			case ObjExtFunc:
+				info := obj.Value.(ExtFuncInfo)
+				want, variadic = len(info.Params), info.Variadic
+				for _, auto := range info.Auto {
+					if len(auto) > 0 {
+						want--
+					}
+				}
+			default:
+				continue
+			}
+			count := l.countParams(i + 1)
+			if withStateParam(name) {
+				count++
+			}
+			if (variadic && count < want-1) || (!variadic && count != want) {
+				l.add(LintError, LintRuleParams, lexem.Line, lexem.Column, `function %s requires %d parameters, got %d`,
+					name, want, count)
+			}
+		}
+	}
+}
+
+func (l *linter) collectUsage(block *Block, read, write map[lintVar]bool) {
+	for _, code := range block.Code {
+		switch code.Cmd {
+		case cmdVar:
+			info := code.Value.(*VarInfo)
+			read[lintVar{info.Owner, info.Obj.Value.(int)}] = true
+		case cmdAssignVar:
+			for _, info := range code.Value.([]*VarInfo) {
+				if info.Obj.Type == ObjVar {
+					write[lintVar{info.Owner, info.Obj.Value.(int)}] = true
+				}
+			}
+		case cmdSetIndex:
+			info := code.Value.(*IndexInfo)
+			if len(info.Extend) == 0 {
+				write[lintVar{info.Owner, info.VarOffset}] = true
+			}
+		case cmdMapInit:
+			collectMapUsage(mapItem{Type: mapMap, Value: code.Value}, read)
+		case cmdArrayInit:
+			collectMapUsage(mapItem{Type: mapArray, Value: code.Value}, read)
+		}
+	}
+	for _, child := range block.Children {
+		l.collectUsage(child, read, write)
+	}
+}
+
+// collectMapUsage marks the variables which are the values of the map or array initialization as read
+func collectMapUsage(item mapItem, read map[lintVar]bool) {
+	switch item.Type {
+	case mapVar:
+		info := item.Value.(*VarInfo)
+		if info.Obj.Type == ObjVar {
+			read[lintVar{info.Owner, info.Obj.Value.(int)}] = true
+		}
+	case mapMap:
+		value := item.Value.(*types.Map)
+		for _, key := range value.Keys() {
+			val, _ := value.Get(key)
+			collectMapUsage(val.(mapItem), read)
+		}
+	case mapArray:
+		for _, val := range item.Value.([]mapItem) {
+			collectMapUsage(val, read)
+		}
+	}
+}
+
+func (l *linter) checkVars(root *Block) {
+	read := make(map[lintVar]bool)
+	write := make(map[lintVar]bool)
+	l.collectUsage(root, read, write)
+
+	var check func(block *Block)
+	check = func(block *Block) {
+		params := make(map[int]bool)
+		if info, ok := block.Info.(*FuncInfo); ok {
+			for i := range info.Params {
+				params[i] = true
+			}
+			if info.Names != nil {
+				for _, name := range *info.Names {
+					for _, offset := range name.Offset {
+						params[offset] = true
+					}
+				}
+			}
+		}
+		for i, decl := range block.VarDecls {
+			if params[i] || read[lintVar{block, i}] {
+				continue
+			}
+			if write[lintVar{block, i}] {
+				l.add(LintWarning, LintRuleUnused, decl.Line, decl.Column, `variable %s is assigned but never used`, decl.Value)
+			} else {
+				l.add(LintWarning, LintRuleUnused, decl.Line, decl.Column, `variable %s is declared but not used`, decl.Value)
+			}
+		}
+		for _, child := range block.Children {
+			check(child)
+		}
+	}
+	check(root)
+}
+
+// checkConditions finds the calls which modify the database in the conditions of the contract
+func (l *linter) checkConditions(cond *Block) {
+	var found bool
+	var check func(block *Block)
+	check = func(block *Block) {
+		for ci, code := range block.Code {
+			if code.Cmd != cmdCall && code.Cmd != cmdCallVari {
+				continue
+			}
+			var (
+				name     string
+				canWrite bool
+			)
+			switch obj := code.Value.(*ObjInfo); obj.Type {
+			case ObjExtFunc:
+				name, canWrite = obj.Value.(ExtFuncInfo).Name, obj.Value.(ExtFuncInfo).CanWrite
+			case ObjFunc:
+				info := obj.Value.(*Block).Info.(*FuncInfo)
+				name, canWrite = info.Name, info.CanWrite
+			}
+			if !canWrite {
+				continue
+			}
+			var line uint32
+			if ci < len(block.Lines) {
+				line = block.Lines[ci]
+			}
+			found = true
+			l.add(LintError, LintRuleConditions, line, l.column(line, name), `'conditions' cannot call %s which modifies the database`, name)
+		}
+		for _, child := range block.Children {
+			check(child)
+		}
+	}
+	check(cond)
+	if !found && cond.Info.(*FuncInfo).CanWrite {
+		var line uint32
+		if len(cond.Lines) > 0 {
+			line = cond.Lines[0]
+		}
+		l.add(LintError, LintRuleConditions, line, 0, `%s`, errCondWrite.Error())
+	}
+}
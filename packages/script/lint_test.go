@@ -0,0 +1,130 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package script
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	vm := NewVM()
+	vm.Extern = true
+	vm.Extend(&ExtendData{map[string]interface{}{"lenArray": lenArray, "str": str,
+		"DBUpdate": func(table string, id int64) error { return nil }}, nil,
+		map[string]struct{}{"DBUpdate": {}}})
+
+	cases := []struct {
+		source   string
+		messages []string
+	}{
+		{`func sum(a int, b int) int {
+			return a + b
+		}
+		func result() int {
+			var i int
+			i = sum(1, 2)
+			return i
+		}`, []string{}},
+		{`func sum(a int, b int) int {
+			return a + b
+		}
+		func result() int {
+			var i, j int
+			var k int
+			k = 10
+			i = sum(1)
+			$unknown(i)
+			return i
+		}`, []string{
+			`5:12: warning: variable j is declared but not used`,
+			`6:9: warning: variable k is assigned but never used`,
+			`8:9: error: function sum requires 2 parameters, got 1`,
+			`9:5: error: unknown extern function $unknown`,
+		}},
+		{`contract Test {
+			conditions {
+				if true {
+					DBUpdate("keys", 1)
+				}
+			}
+			action {
+				Unknown()
+			}
+		}`, []string{
+			`4:7: error: 'conditions' cannot call DBUpdate which modifies the database`,
+			`8:6: warning: unknown function or contract Unknown`,
+		}},
+		{`func result() int {
+			return lenArray(1, 2)
+		}`, []string{
+			`2:5: error: function lenArray must have 1 parameters`,
+		}},
+		{`func result() array {
+			var a, b int
+			var c string
+			a = 1
+			b = 2
+			c = "3"
+			return [{"a": a, "list": [b]}, c]
+		}`, []string{}},
+	}
+	for _, item := range cases {
+		messages := make([]string, 0)
+		for _, msg := range vm.Lint([]rune(item.source), &OwnerInfo{StateID: 1}, nil) {
+			messages = append(messages, msg.String())
+		}
+		assert.Equal(t, item.messages, messages)
+	}
+}
+
+func TestLintRejected(t *testing.T) {
+	vm := NewVM()
+	vm.Extern = true
+	vm.Extend(&ExtendData{map[string]interface{}{"lenArray": lenArray}, nil, nil})
+
+	messages := vm.Lint([]rune(`func sum(a int, b int) int {
+			return a + b
+		}
+		func result() int {
+			var i int
+			i = sum(1)
+			$unknown(i)
+			return i
+		}`), &OwnerInfo{StateID: 1}, nil)
+	assert.Len(t, messages, 2)
+
+	// the unknown extern function depends on the node so it doesn't reject the contract
+	rejected := LintRejected(messages, 1)
+	if assert.Len(t, rejected, 1) {
+		assert.Equal(t, LintRuleParams, rejected[0].Rule)
+	}
+	assert.Empty(t, LintRejected(messages, 0))
+	assert.Empty(t, LintRejected(messages, 100))
+}
@@ -0,0 +1,275 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package script
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// traceStackSize is the maximum count of the top stack values in the trace step
+	traceStackSize = 16
+	// traceValueSize is the maximum length of the value in the trace step
+	traceValueSize = 128
+)
+
+var (
+	// ErrDebugStopped is returned when the debugging has been stopped
+	ErrDebugStopped = errors.New(`debugging has been stopped`)
+	// ErrDebugTimeout is returned when the debugger has not received the command in time
+	ErrDebugTimeout = errors.New(`debugging timeout`)
+)
+
+// TraceStep contains the state of the virtual machine before the execution of the byte-code
+type TraceStep struct {
+	Depth    int      `json:"depth"`
+	Contract string   `json:"contract,omitempty"`
+	Func     string   `json:"func,omitempty"`
+	Line     uint32   `json:"line"`
+	Cmd      string   `json:"cmd"`
+	Stack    []string `json:"stack"`
+	Cost     int64    `json:"cost"` // including the cost of the nested calls
+}
+
+// Tracer is called before the execution of every byte-code. The execution is aborted
+// if Trace returns an error
+type Tracer interface {
+	Trace(step *TraceStep) error
+}
+
+func (rt *RunTime) tracer() Tracer {
+	if rt.extend == nil {
+		return nil
+	}
+	if traceable, ok := (*rt.extend)[`sc`].(Traceable); ok {
+		return traceable.GetTracer()
+	}
+	return nil
+}
+
+func traceValue(value interface{}) string {
+	ret := fmt.Sprint(value)
+	if len(ret) > traceValueSize {
+		ret = ret[:traceValueSize] + `...`
+	}
+	return ret
+}
+
+func (rt *RunTime) traceStep(block *Block, ci int) *TraceStep {
+	cmd := block.Code[ci].Cmd
+	step := &TraceStep{
+		Depth: len(rt.blocks),
+		Cmd:   cmdNames[cmd],
+	}
+	if len(step.Cmd) == 0 {
+		step.Cmd = fmt.Sprintf(`0x%04x`, cmd)
+	}
+	if ci < len(block.Lines) {
+		step.Line = block.Lines[ci]
+	}
+	for cur := block; cur != nil; cur = cur.Parent {
+		if info, ok := cur.Info.(*FuncInfo); ok && len(step.Func) == 0 {
+			step.Func = info.Name
+		}
+		if info, ok := cur.Info.(*ContractInfo); ok {
+			step.Contract = info.Name
+			break
+		}
+	}
+	stack := rt.stack
+	if len(stack) > traceStackSize {
+		stack = stack[len(stack)-traceStackSize:]
+	}
+	step.Stack = make([]string, len(stack))
+	for i, value := range stack {
+		step.Stack[i] = traceValue(value)
+	}
+	return step
+}
+
+// TraceRecorder collects the trace steps
+type TraceRecorder struct {
+	Steps     []*TraceStep `json:"steps"`
+	Limit     int          `json:"-"` // zero means no limit
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+// Trace implements Tracer
+func (r *TraceRecorder) Trace(step *TraceStep) error {
+	if r.Limit > 0 && len(r.Steps) >= r.Limit {
+		r.Truncated = true
+		return nil
+	}
+	r.Steps = append(r.Steps, step)
+	return nil
+}
+
+// DebugCommand is the command which resumes the paused execution
+type DebugCommand int
+
+const (
+	// DebugStep pauses the execution on the next line
+	DebugStep DebugCommand = iota
+	// DebugContinue pauses the execution on the next breakpoint
+	DebugContinue
+	// DebugStop aborts the execution
+	DebugStop
+)
+
+// Breakpoint is the line of the contract where the execution is paused
+type Breakpoint struct {
+	Contract string `json:"contract"`
+	Line     uint32 `json:"line"`
+}
+
+// Debugger is the tracer which pauses the execution on breakpoints and step by step.
+// The execution runs in the own goroutine and is controlled with Wait and Resume.
+type Debugger struct {
+	mutex       sync.Mutex
+	breakpoints map[Breakpoint]bool
+	stepping    bool
+	last        Breakpoint
+	timeout     time.Duration
+	deadline    time.Time
+	pause       chan *TraceStep
+	resume      chan DebugCommand
+	done        chan struct{}
+}
+
+// NewDebugger returns the debugger. The paused execution is stopped if there is not
+// any command during timeout
+func NewDebugger(timeout time.Duration, stepping bool, breakpoints []Breakpoint) *Debugger {
+	d := &Debugger{
+		breakpoints: make(map[Breakpoint]bool),
+		stepping:    stepping,
+		timeout:     timeout,
+		pause:       make(chan *TraceStep),
+		resume:      make(chan DebugCommand),
+		done:        make(chan struct{}),
+	}
+	d.SetBreakpoints(breakpoints)
+	return d
+}
+
+// SetBreakpoints replaces the list of breakpoints
+func (d *Debugger) SetBreakpoints(breakpoints []Breakpoint) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.breakpoints = make(map[Breakpoint]bool)
+	for _, item := range breakpoints {
+		d.breakpoints[item] = true
+	}
+}
+
+// SetDeadline sets the time after which the paused execution is stopped regardless of commands
+func (d *Debugger) SetDeadline(deadline time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.deadline = deadline
+}
+
+// wait returns the maximum time of the waiting for the next command
+func (d *Debugger) wait() time.Duration {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.deadline.IsZero() {
+		return d.timeout
+	}
+	if left := time.Until(d.deadline); left < d.timeout {
+		return left
+	}
+	return d.timeout
+}
+
+func (d *Debugger) isPause(step *TraceStep) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	cur := Breakpoint{Contract: step.Contract, Line: step.Line}
+	if cur == d.last {
+		return false
+	}
+	d.last = cur
+	return d.stepping || d.breakpoints[cur]
+}
+
+// Trace implements Tracer
+func (d *Debugger) Trace(step *TraceStep) error {
+	if !d.isPause(step) {
+		return nil
+	}
+	timeout := time.After(d.wait())
+	select {
+	case d.pause <- step:
+	case <-timeout:
+		return ErrDebugTimeout
+	}
+	select {
+	case cmd := <-d.resume:
+		switch cmd {
+		case DebugStop:
+			return ErrDebugStopped
+		case DebugStep:
+			d.mutex.Lock()
+			d.stepping = true
+			d.mutex.Unlock()
+		default:
+			d.mutex.Lock()
+			d.stepping = false
+			d.mutex.Unlock()
+		}
+	case <-timeout:
+		return ErrDebugTimeout
+	}
+	return nil
+}
+
+// Wait waits for the pause of the execution. It returns nil if the execution has been finished
+func (d *Debugger) Wait() *TraceStep {
+	select {
+	case step := <-d.pause:
+		return step
+	case <-d.done:
+		return nil
+	}
+}
+
+// Resume resumes the paused execution
+func (d *Debugger) Resume(cmd DebugCommand) {
+	select {
+	case d.resume <- cmd:
+	case <-d.done:
+	}
+}
+
+// Finish must be called when the execution has been finished
+func (d *Debugger) Finish() {
+	close(d.done)
+}
@@ -0,0 +1,135 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package script
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTraceable struct {
+	tracer Tracer
+}
+
+func (t *testTraceable) GetTracer() Tracer {
+	return t.tracer
+}
+
+const traceSource = `func sum(a int, b int) int {
+	var c int
+	c = a + b
+	return c
+}
+func result() int {
+	var i int
+	i = sum(1, 2)
+	return i * 10
+}`
+
+func traceVM(t *testing.T) *VM {
+	vm := NewVM()
+	vm.Extern = true
+	if err := vm.Compile([]rune(traceSource), &OwnerInfo{StateID: 1, Active: true, TableID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+func TestTraceRecorder(t *testing.T) {
+	vm := traceVM(t)
+	recorder := &TraceRecorder{}
+	out, err := vm.Call(`result`, nil, &map[string]interface{}{`rt_state`: uint32(1), `txcost`: int64(1000),
+		`sc`: &testTraceable{recorder}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30), out[0])
+
+	lines := make(map[string]map[uint32]bool)
+	for _, step := range recorder.Steps {
+		if lines[step.Func] == nil {
+			lines[step.Func] = make(map[uint32]bool)
+		}
+		lines[step.Func][step.Line] = true
+		assert.True(t, step.Cost > 0)
+	}
+	assert.Equal(t, map[uint32]bool{3: true, 4: true}, lines[`sum`])
+	assert.Equal(t, map[uint32]bool{8: true, 9: true}, lines[`result`])
+
+	limited := &TraceRecorder{Limit: 2}
+	_, err = vm.Call(`result`, nil, &map[string]interface{}{`rt_state`: uint32(1), `txcost`: int64(1000),
+		`sc`: &testTraceable{limited}})
+	assert.NoError(t, err)
+	assert.Len(t, limited.Steps, 2)
+	assert.True(t, limited.Truncated)
+}
+
+func TestDebugger(t *testing.T) {
+	vm := traceVM(t)
+	run := func(d *Debugger) chan error {
+		result := make(chan error, 1)
+		go func() {
+			_, err := vm.Call(`result`, nil, &map[string]interface{}{`rt_state`: uint32(1),
+				`txcost`: int64(1000), `sc`: &testTraceable{d}})
+			d.Finish()
+			result <- err
+		}()
+		return result
+	}
+
+	d := NewDebugger(time.Second, false, []Breakpoint{{Line: 4}})
+	result := run(d)
+	step := d.Wait()
+	if assert.NotNil(t, step) {
+		assert.Equal(t, `sum`, step.Func)
+		assert.Equal(t, uint32(4), step.Line)
+	}
+	d.Resume(DebugStep)
+	step = d.Wait()
+	if assert.NotNil(t, step) {
+		assert.Equal(t, `result`, step.Func)
+		assert.Equal(t, uint32(8), step.Line)
+	}
+	d.Resume(DebugContinue)
+	assert.Nil(t, d.Wait())
+	assert.NoError(t, <-result)
+
+	d = NewDebugger(time.Second, true, nil)
+	result = run(d)
+	assert.NotNil(t, d.Wait())
+	d.Resume(DebugStop)
+	assert.Nil(t, d.Wait())
+	assert.Equal(t, ErrDebugStopped, <-result)
+
+	d = NewDebugger(time.Minute, true, nil)
+	d.SetDeadline(time.Now().Add(100 * time.Millisecond))
+	result = run(d)
+	assert.NotNil(t, d.Wait())
+	assert.Nil(t, d.Wait())
+	assert.Equal(t, ErrDebugTimeout, <-result)
+}
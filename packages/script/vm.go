@@ -95,6 +95,13 @@ var (
 	ErrVMTimeLimit = errors.New(`time limit exceeded`)
 )
 
+// throwInfo is the description of the error which is passed to catch block
+type throwInfo struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
 // VMError represents error of VM
 type VMError struct {
 	Type  string `json:"type"`
@@ -384,6 +391,220 @@ func (rt *RunTime) recalcMemVar(k int) {
 	rt.memVars[k] = mem
 }
 
+// charge decreases the remain cost of the execution
+func (rt *RunTime) charge(cost int64) error {
+	if cost > rt.cost {
+		rt.cost = 0
+		rt.vm.logger.WithFields(log.Fields{"type": consts.VMError}).Warning("paid CPU resource is over")
+		return fmt.Errorf(`paid CPU resource is over`)
+	}
+	rt.cost -= cost
+	return nil
+}
+
+// assignVar sets the value of the local variable, the value of money variable is converted to Decimal
+func (rt *RunTime) assignVar(item *VarInfo, value interface{}) error {
+	for i := len(rt.blocks) - 1; i >= 0; i-- {
+		if item.Owner == rt.blocks[i].Block {
+			k := rt.blocks[i].Offset + item.Obj.Value.(int)
+			switch rt.blocks[i].Block.Vars[item.Obj.Value.(int)].String() {
+			case Decimal:
+				v, err := ValueToDecimal(value)
+				if err != nil {
+					return err
+				}
+				rt.setVar(k, v)
+			default:
+				rt.setVar(k, value)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// forEach runs the body of for loop for every item of the array, the map or the string.
+// Maps are iterated in the order of their keys, strings are iterated by characters.
+func (rt *RunTime) forEach(info *ForEachInfo, collection interface{}) (status int, err error) {
+	var (
+		count int
+		item  func(int) (interface{}, interface{})
+	)
+	switch val := collection.(type) {
+	case nil:
+	case *types.Map:
+		keys := val.Keys()
+		count = len(keys)
+		item = func(i int) (interface{}, interface{}) {
+			v, _ := val.Get(keys[i])
+			return keys[i], v
+		}
+	case string:
+		chars := []rune(val)
+		count = len(chars)
+		item = func(i int) (interface{}, interface{}) {
+			return int64(i), string(chars[i])
+		}
+	default:
+		rv := reflect.ValueOf(collection)
+		if rv.Kind() != reflect.Slice {
+			rt.vm.logger.WithFields(log.Fields{"type": consts.VMError, "vm_type": fmt.Sprintf(`%T`, collection)}).Error("type does not support for loop")
+			return 0, fmt.Errorf(eForType, collection)
+		}
+		count = rv.Len()
+		item = func(i int) (interface{}, interface{}) {
+			return int64(i), rv.Index(i).Interface()
+		}
+	}
+	for i := 0; i < count; i++ {
+		if err = rt.charge(CostIteration); err != nil {
+			return 0, err
+		}
+		key, value := item(i)
+		if len(info.Vars) == 1 {
+			err = rt.assignVar(info.Vars[0], value)
+		} else if err = rt.assignVar(info.Vars[0], key); err == nil {
+			err = rt.assignVar(info.Vars[1], value)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if status, err = rt.RunCode(info.Block); err != nil {
+			return status, err
+		}
+		switch status {
+		case statusBreak:
+			return statusNormal, nil
+		case statusReturn:
+			return status, nil
+		}
+		status = statusNormal
+	}
+	return
+}
+
+// savepointer returns the extended object which rolls back the changes of try block
+func (rt *RunTime) savepointer() Savepointer {
+	if rt.extend == nil {
+		return nil
+	}
+	if sp, ok := (*rt.extend)[`sc`].(Savepointer); ok {
+		return sp
+	}
+	return nil
+}
+
+// isCatchable returns false if the error is caused by the exhausted resources
+// or by the debugger. Such errors can't be caught by try block.
+func (rt *RunTime) isCatchable(err error) bool {
+	switch err {
+	case ErrVMTimeLimit, ErrMemoryLimit, ErrDebugStopped, ErrDebugTimeout:
+		return false
+	}
+	return rt.cost > 0 && !rt.timeLimit && rt.mem <= memoryLimit &&
+		!strings.Contains(err.Error(), `paid CPU resource is over`)
+}
+
+// errorToMap converts the caught error to the map with type, id and error keys
+func errorToMap(err error) *types.Map {
+	info := throwInfo{Type: `panic`, Error: err.Error()}
+	if strings.HasPrefix(info.Error, `{`) {
+		json.Unmarshal([]byte(info.Error), &info)
+	} else if out, errJSON := json.Marshal(err); errJSON == nil {
+		json.Unmarshal(out, &info)
+	}
+	ret := types.NewMap()
+	ret.Set(`type`, info.Type)
+	ret.Set(`id`, info.ID)
+	ret.Set(`error`, info.Error)
+	return ret
+}
+
+// tryCatch runs try block. If it fails then the changes made in the block are rolled back
+// and catch block is run with the error.
+func (rt *RunTime) tryCatch(info *TryInfo) (status int, err error) {
+	sp := rt.savepointer()
+	if sp != nil {
+		if err = sp.Savepoint(); err != nil {
+			return 0, err
+		}
+	}
+	size := len(rt.stack)
+	status, err = rt.RunCode(info.Try)
+	if err == nil {
+		if sp != nil {
+			err = sp.ReleaseSavepoint()
+		}
+		return
+	}
+	if sp != nil {
+		if errRoll := sp.RollbackSavepoint(); errRoll != nil {
+			return 0, errRoll
+		}
+	}
+	if !rt.isCatchable(err) {
+		return 0, err
+	}
+	rt.vm.logger.WithFields(log.Fields{"type": consts.VMError, "error": err}).Debug("error is caught")
+	rt.stack = rt.stack[:size]
+	rt.err = nil
+	rt.unwrap = false
+	if info.Catch == nil {
+		return statusNormal, nil
+	}
+	if info.Var != nil {
+		if err = rt.assignVar(info.Var, errorToMap(err)); err != nil {
+			return 0, err
+		}
+	}
+	return rt.RunCode(info.Catch)
+}
+
+// slice returns the copy of the part of the array or the string. The parts of the strings
+// are taken by characters. The omitted bounds are nil.
+func (rt *RunTime) slice(collection, low, high interface{}) (interface{}, error) {
+	var (
+		chars []rune
+		rv    reflect.Value
+		size  int
+	)
+	str, isStr := collection.(string)
+	if isStr {
+		chars = []rune(str)
+		size = len(chars)
+	} else if rv = reflect.ValueOf(collection); rv.Kind() == reflect.Slice {
+		size = rv.Len()
+	} else {
+		rt.vm.logger.WithFields(log.Fields{"type": consts.VMError, "vm_type": fmt.Sprintf(`%T`, collection)}).Error("type does not support slicing")
+		return nil, fmt.Errorf(eSliceType, collection)
+	}
+	bounds := []int{0, size}
+	for i, bound := range []interface{}{low, high} {
+		if bound == nil {
+			continue
+		}
+		index, ok := bound.(int64)
+		if !ok {
+			return nil, fmt.Errorf(eSliceIndex, bound)
+		}
+		bounds[i] = int(index)
+	}
+	if bounds[0] < 0 || bounds[1] > size || bounds[0] > bounds[1] {
+		return nil, errSliceRange
+	}
+	if err := rt.charge(int64(bounds[1] - bounds[0])); err != nil {
+		return nil, err
+	}
+	if isStr {
+		return string(chars[bounds[0]:bounds[1]]), nil
+	}
+	ret := make([]interface{}, 0, bounds[1]-bounds[0])
+	for i := bounds[0]; i < bounds[1]; i++ {
+		ret = append(ret, rv.Index(i).Interface())
+	}
+	return ret, nil
+}
+
 func valueToBool(v interface{}) bool {
 	switch val := v.(type) {
 	case int:
@@ -619,7 +840,28 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 		tmpDec decimal.Decimal
 	)
 	labels := make([]int, 0)
+	var (
+		step     *TraceStep
+		stepCost int64
+	)
+	tracer := rt.tracer()
+	if tracer != nil {
+		defer func() {
+			if step != nil {
+				step.Cost = stepCost - rt.cost
+			}
+		}()
+	}
 	for ci := 0; ci < len(block.Code); ci++ {
+		if tracer != nil {
+			if step != nil {
+				step.Cost = stepCost - rt.cost
+			}
+			step, stepCost = rt.traceStep(block, ci), rt.cost
+			if err = tracer.Trace(step); err != nil {
+				return 0, err
+			}
+		}
 		rt.cost--
 		if rt.cost <= 0 {
 			rt.vm.logger.WithFields(log.Fields{"type": consts.VMError}).Warn("paid CPU resource is over")
@@ -674,6 +916,12 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 					break
 				}
 			}
+		case cmdForEach:
+			val := rt.stack[len(rt.stack)-1]
+			rt.stack = rt.stack[:len(rt.stack)-1]
+			status, err = rt.forEach(cmd.Value.(*ForEachInfo), val)
+		case cmdTry:
+			status, err = rt.tryCatch(cmd.Value.(*TryInfo))
 		case cmdLabel:
 			labels = append(labels, ci)
 		case cmdContinue:
@@ -694,24 +942,8 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 						}
 						rt.setExtendVar((*item).Obj.Value.(string), rt.stack[len(rt.stack)-count+ivar])
 					}
-				} else {
-					var i int
-					for i = len(rt.blocks) - 1; i >= 0; i-- {
-						if item.Owner == rt.blocks[i].Block {
-							k := rt.blocks[i].Offset + item.Obj.Value.(int)
-							switch rt.blocks[i].Block.Vars[item.Obj.Value.(int)].String() {
-							case Decimal:
-								v, err := ValueToDecimal(rt.stack[len(rt.stack)-count+ivar])
-								if err != nil {
-									return 0, err
-								}
-								rt.setVar(k, v)
-							default:
-								rt.setVar(k, rt.stack[len(rt.stack)-count+ivar])
-							}
-							break
-						}
-					}
+				} else if err := rt.assignVar(item, rt.stack[len(rt.stack)-count+ivar]); err != nil {
+					return 0, err
 				}
 			}
 		case cmdReturn:
@@ -745,6 +977,7 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 			rt.stack = rt.stack[:mapoff+1]
 			continue
 		case cmdCallVari, cmdCall:
+			costBefore := rt.cost
 			if cmd.Value.(*ObjInfo).Type == ObjExtFunc {
 				finfo := cmd.Value.(*ObjInfo).Value.(ExtFuncInfo)
 				if rt.vm.ExtCost != nil {
@@ -763,6 +996,11 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 				rt.cost -= CostCall
 			}
 			err = rt.callFunc(cmd.Cmd, cmd.Value.(*ObjInfo))
+			if cmd.Value.(*ObjInfo).Type == ObjExtFunc {
+				if counter, ok := (*rt.extend)[`sc`].(FuelCounter); ok {
+					counter.AddExtFuel(cmd.Value.(*ObjInfo).Value.(ExtFuncInfo).Name, costBefore-rt.cost)
+				}
+			}
 
 		case cmdVar:
 			ivar := cmd.Value.(*VarInfo)
@@ -831,6 +1069,9 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 				rt.vm.logger.WithFields(log.Fields{"type": consts.VMError, "vm_type": itype}).Error("type does not support indexing")
 				err = fmt.Errorf(`Type %s doesn't support indexing`, itype)
 			}
+		case cmdSlice:
+			rt.stack[size-3], err = rt.slice(rt.stack[size-3], rt.stack[size-2], rt.stack[size-1])
+			rt.stack = rt.stack[:size-2]
 		case cmdSetIndex:
 			itype := reflect.TypeOf(rt.stack[size-3]).String()
 			indexInfo := cmd.Value.(*IndexInfo)
@@ -75,6 +75,8 @@ const (
 	CostContract = 100
 	// CostExtend is the cost of the extend function calling
 	CostExtend = 10
+	// CostIteration is the cost of the every iteration of for loop
+	CostIteration = 2
 
 	// VMTypeSmart is smart vm type
 	VMTypeSmart VMType = 1
@@ -139,6 +141,7 @@ type FuncName struct {
 
 // FuncInfo contains the function information
 type FuncInfo struct {
+	Name     string
 	Params   []reflect.Type
 	Results  []reflect.Type
 	Names    *map[string]FuncName
@@ -153,6 +156,19 @@ type VarInfo struct {
 	Owner *Block
 }
 
+// ForEachInfo contains the information for the for loop
+type ForEachInfo struct {
+	Vars  []*VarInfo // the key and value variables or only the value variable
+	Block *Block     // the body of the loop
+}
+
+// TryInfo contains the information for try and catch blocks
+type TryInfo struct {
+	Try   *Block
+	Catch *Block   // nil if there is not catch block
+	Var   *VarInfo // the variable which gets the caught error
+}
+
 // IndexInfo contains the information for SetIndex
 type IndexInfo struct {
 	VarOffset int
@@ -183,7 +199,9 @@ type Block struct {
 	Info     interface{}
 	Parent   *Block
 	Vars     []reflect.Type
+	VarDecls []*Lexem // the declarations of variables, they are used by the linter
 	Code     ByteCodes
+	Lines    []uint32 // the lines of the source code for byte-codes
 	Children Blocks
 }
 
@@ -213,6 +231,24 @@ type Stacker interface {
 	PopStack(fn string)
 }
 
+// FuelCounter represents interface for accounting the fuel spent by extended functions
+type FuelCounter interface {
+	AddExtFuel(name string, fuel int64)
+}
+
+// Traceable represents interface of the extended object which traces the execution
+type Traceable interface {
+	GetTracer() Tracer
+}
+
+// Savepointer represents interface of the extended object which can roll back
+// the changes made in try block
+type Savepointer interface {
+	Savepoint() error
+	RollbackSavepoint() error
+	ReleaseSavepoint() error
+}
+
 // ExecContract runs the name contract where txs contains the list of parameters and
 // params are the values of parameters
 func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interface{}, error) {
@@ -248,6 +284,23 @@ func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interfa
 		prevExtend[key] = item
 		delete(*rt.extend, key)
 	}
+	prevthis := (*rt.extend)[`this_contract`]
+	prevparent := (*rt.extend)[`parent`]
+	// the variables of the caller are restored even if the contract fails because
+	// the error can be caught by try block of the caller
+	defer func() {
+		(*rt.extend)[`parent`] = prevparent
+		(*rt.extend)[`this_contract`] = prevthis
+		for key := range *rt.extend {
+			if isSysVar(key) {
+				continue
+			}
+			delete(*rt.extend, key)
+		}
+		for key, item := range prevExtend {
+			(*rt.extend)[key] = item
+		}
+	}()
 
 	var isSignature bool
 	if cblock.Info.(*ContractInfo).Tx != nil {
@@ -267,11 +320,9 @@ func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interfa
 	for i, ipar := range pars {
 		(*rt.extend)[ipar] = params[i]
 	}
-	prevthis := (*rt.extend)[`this_contract`]
 	_, nameContract := converter.ParseName(name)
 	(*rt.extend)[`this_contract`] = nameContract
 
-	prevparent := (*rt.extend)[`parent`]
 	parent := ``
 	for i := len(rt.blocks) - 1; i >= 0; i-- {
 		if rt.blocks[i].Block.Type == ObjFunc && rt.blocks[i].Block.Parent != nil &&
@@ -296,6 +347,7 @@ func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interfa
 		if err := stack.AppendStack(name); err != nil {
 			return nil, err
 		}
+		defer stack.PopStack(name)
 	}
 	if (*rt.extend)[`sc`] != nil && isSignature {
 		obj := rt.vm.Objects[`check_signature`]
@@ -317,25 +369,7 @@ func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interfa
 			}
 		}
 	}
-	if stack != nil {
-		stack.PopStack(name)
-	}
-	(*rt.extend)[`parent`] = prevparent
-	(*rt.extend)[`this_contract`] = prevthis
-
-	result := (*rt.extend)[`result`]
-	for key := range *rt.extend {
-		if isSysVar(key) {
-			continue
-		}
-		delete(*rt.extend, key)
-	}
-
-	for key, item := range prevExtend {
-		(*rt.extend)[key] = item
-	}
-
-	return result, nil
+	return (*rt.extend)[`result`], nil
 }
 
 // NewVM creates a new virtual machine
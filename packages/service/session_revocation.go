@@ -0,0 +1,210 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SessionLifetime is the max lifetime of api session which can be prolonged by refresh token
+const SessionLifetime = time.Hour * 30 * 24
+
+// pruneInterval is the minimal interval between the deletions of expired revocations
+const pruneInterval = time.Minute
+
+type keyRevocation struct {
+	revokedAt int64 // nanoseconds
+	expiresAt int64
+}
+
+// SessionRevocationService keeps revoked api sessions in memory and stores them to the node database
+type SessionRevocationService struct {
+	sessions map[string]int64        // session id -> expiration time
+	keys     map[int64]keyRevocation // key id -> time of revocation all sessions of the key
+
+	lastPrune time.Time
+	m         *sync.RWMutex
+}
+
+var srs = &SessionRevocationService{
+	sessions: make(map[string]int64),
+	keys:     make(map[int64]keyRevocation),
+	m:        &sync.RWMutex{},
+}
+
+// GetSessionRevocationService is returning session revocation service
+func GetSessionRevocationService() *SessionRevocationService {
+	return srs
+}
+
+// InitSessionRevocationService loads revoked sessions from the database
+func InitSessionRevocationService() error {
+	now := time.Now().Unix()
+	rs := &model.RevokedSession{}
+	if err := rs.DeleteExpired(now); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting expired revoked sessions")
+		return err
+	}
+
+	list, err := rs.GetActive(now)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting revoked sessions")
+		return err
+	}
+
+	srs.m.Lock()
+	defer srs.m.Unlock()
+	for _, item := range list {
+		srs.add(item)
+	}
+	return nil
+}
+
+func (s *SessionRevocationService) add(item model.RevokedSession) {
+	if len(item.SessionID) > 0 {
+		s.sessions[item.SessionID] = item.ExpiresAt
+		return
+	}
+	if item.RevokedAt > s.keys[item.KeyID].revokedAt {
+		s.keys[item.KeyID] = keyRevocation{revokedAt: item.RevokedAt, expiresAt: item.ExpiresAt}
+	}
+}
+
+// prune deletes the revocations of the sessions which have expired at the time,
+// the tokens of such sessions are rejected because of their expiration time
+func (s *SessionRevocationService) prune(now int64) {
+	for id, expiresAt := range s.sessions {
+		if expiresAt <= now {
+			delete(s.sessions, id)
+		}
+	}
+	for keyID, item := range s.keys {
+		if item.expiresAt <= now {
+			delete(s.keys, keyID)
+		}
+	}
+}
+
+func (s *SessionRevocationService) save(item model.RevokedSession) error {
+	now := time.Now()
+	s.m.Lock()
+	s.add(item)
+	prune := now.Sub(s.lastPrune) >= pruneInterval
+	if prune {
+		s.lastPrune = now
+		s.prune(now.Unix())
+	}
+	s.m.Unlock()
+
+	if model.DBConn == nil {
+		return nil
+	}
+	if prune {
+		if err := item.DeleteExpired(now.Unix()); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting expired revoked sessions")
+		}
+	}
+	if err := item.Create(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "key_id": item.KeyID}).Error("saving revoked session")
+		return err
+	}
+	return nil
+}
+
+// RevokeSession revokes the session till its expiration time
+func (s *SessionRevocationService) RevokeSession(sessionID string, keyID int64, expiresAt time.Time) error {
+	return s.save(model.RevokedSession{
+		SessionID: sessionID,
+		KeyID:     keyID,
+		RevokedAt: time.Now().UnixNano(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+}
+
+// ConsumeSession revokes the session of the refresh token atomically, it returns false if
+// the session has already been revoked by another request so the token can be used only once
+func (s *SessionRevocationService) ConsumeSession(sessionID string, keyID int64, expiresAt time.Time) (bool, error) {
+	item := model.RevokedSession{
+		SessionID: sessionID,
+		KeyID:     keyID,
+		RevokedAt: time.Now().UnixNano(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+	s.m.Lock()
+	_, revoked := s.sessions[sessionID]
+	if !revoked {
+		s.add(item)
+	}
+	s.m.Unlock()
+	if revoked {
+		return false, nil
+	}
+
+	if model.DBConn == nil {
+		return true, nil
+	}
+	created, err := item.CreateOnce()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "key_id": keyID}).Error("saving revoked session")
+		return false, err
+	}
+	return created, nil
+}
+
+// RevokeKey revokes all sessions of the key which have been issued before now
+func (s *SessionRevocationService) RevokeKey(keyID int64) error {
+	now := time.Now()
+	return s.save(model.RevokedSession{
+		KeyID:     keyID,
+		RevokedAt: now.UnixNano(),
+		ExpiresAt: now.Add(SessionLifetime).Unix(),
+	})
+}
+
+// IsRevoked returns true if the session or all sessions of the key have been revoked,
+// issuedAt is the time of issuing the session in nanoseconds
+func (s *SessionRevocationService) IsRevoked(sessionID string, keyID, issuedAt int64) bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	if item, ok := s.keys[keyID]; ok && issuedAt <= item.revokedAt {
+		return true
+	}
+	if len(sessionID) == 0 {
+		return false
+	}
+	_, ok := s.sessions[sessionID]
+	return ok
+}
@@ -0,0 +1,85 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRevocationPrune(t *testing.T) {
+	s := &SessionRevocationService{
+		sessions: make(map[string]int64),
+		keys:     make(map[int64]keyRevocation),
+		m:        &sync.RWMutex{},
+	}
+	now := time.Now()
+	assert.NoError(t, s.RevokeSession("expired", 1, now.Add(-time.Second)))
+	assert.NoError(t, s.RevokeSession("active", 1, now.Add(time.Hour)))
+	assert.NoError(t, s.RevokeKey(2))
+	s.keys[3] = keyRevocation{revokedAt: now.Add(-SessionLifetime).UnixNano(), expiresAt: now.Unix() - 1}
+
+	s.prune(now.Unix())
+	assert.Len(t, s.sessions, 1)
+	assert.True(t, s.IsRevoked("active", 1, now.UnixNano()))
+	assert.Len(t, s.keys, 1)
+	assert.True(t, s.IsRevoked("", 2, now.UnixNano()))
+	assert.False(t, s.IsRevoked("", 2, time.Now().UnixNano()))
+}
+
+func TestConsumeSession(t *testing.T) {
+	s := &SessionRevocationService{
+		sessions: make(map[string]int64),
+		keys:     make(map[int64]keyRevocation),
+		m:        &sync.RWMutex{},
+	}
+	expires := time.Now().Add(time.Hour)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	consumed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.ConsumeSession("refresh", 1, expires)
+			assert.NoError(t, err)
+			if ok {
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, consumed)
+	assert.True(t, s.IsRevoked("refresh", 1, time.Now().UnixNano()))
+}
@@ -37,6 +37,7 @@ const (
 	eContractLoop        = `There is loop in %s contract`
 	eContractExist       = `Contract %s already exists`
 	eContractNotFound    = `Contract %s has not been found`
+	eContractLint        = `Contract has lint errors: %s`
 	eLatin               = `Name %s must only contain latin, digit and '_', '-' characters`
 	eAccessContract      = `%s can be only called from %s`
 	eColumnExist         = `column %s exists`
@@ -94,6 +95,7 @@ var (
 	errSameColumns        = errors.New(`There are the same columns`)
 	errTableName          = errors.New(`The name of the table cannot begin with @`)
 	errTableEmptyName     = errors.New(`The table name cannot be empty`)
+	errTryEcosystem       = errors.New(`New ecosystem cannot be created inside try block`)
 	errUndefBlock         = errors.New(`It is impossible to write to DB when Block is undefined`)
 	errUndefColumns       = errors.New(`Columns are undefined`)
 	errUnknownNodeID      = errors.New(`Unknown node id`)
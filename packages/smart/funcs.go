@@ -149,6 +149,10 @@ type SmartContract struct {
 	Notifications []NotifyInfo
 	GenBlock      bool
 	TimeLimit     int64
+	Simulation    *Simulation // it isn't nil if the contract is executed by SimulateContract
+	savepoints    []savepointInfo
+	langs         []langInfo
+	sysUpdates    int
 }
 
 var (
@@ -468,7 +472,21 @@ func CompileContract(sc *SmartContract, code string, state, id, token int64) (in
 	if err := validateAccess(`CompileContract`, sc, nNewContract, nEditContract, nImport); err != nil {
 		return nil, err
 	}
-	return VMCompileBlock(sc.VM, code, &script.OwnerInfo{StateID: uint32(state), WalletID: id, TokenID: token})
+	owner := &script.OwnerInfo{StateID: uint32(state), WalletID: id, TokenID: token}
+	root, err := VMCompileBlock(sc.VM, code, owner)
+	version := syspar.GetStrictContracts()
+	if err != nil || version == 0 {
+		return root, err
+	}
+	// only the frozen rules of the version are checked so the result doesn't depend on the node
+	if rejected := script.LintRejected(VMLint(sc.VM, code, owner), version); len(rejected) > 0 {
+		list := make([]string, 0, len(rejected))
+		for _, msg := range rejected {
+			list = append(list, msg.String())
+		}
+		return nil, logErrorf(eContractLint, strings.Join(list, `; `), consts.ParseError, "linting contract")
+	}
+	return root, nil
 }
 
 // ContractAccess checks whether the name of the executable contract matches one of the names listed in the parameters.
@@ -970,6 +988,7 @@ func DBSelect(sc *SmartContract, tblname string, inColumns interface{}, id int64
 		return 0, nil, err
 	}
 	tblname = GetTableName(sc, tblname)
+	sc.touchTable(tblname, false)
 	order, err = GetOrder(tblname, inOrder)
 	if err != nil {
 		return 0, nil, err
@@ -1127,6 +1146,9 @@ func FlushContract(sc *SmartContract, iroot interface{}, id int64) error {
 	if err := validateAccess(`FlushContract`, sc, nNewContract, nEditContract, nImport); err != nil {
 		return err
 	}
+	if sc.Simulation != nil {
+		return nil
+	}
 	root := iroot.(*script.Block)
 	if id != 0 {
 		if len(root.Children) != 1 || root.Children[0].Type != script.ObjContract {
@@ -1732,7 +1754,7 @@ func UpdateNodesBan(smartContract *SmartContract, timestamp int64) error {
 		return err
 	}
 
-	fullNodes := syspar.GetNodes()
+	fullNodes := syspar.GetProposedNodes()
 	var updFullNodes bool
 	for i, fullNode := range fullNodes {
 		// Removing ban in case ban time has already passed
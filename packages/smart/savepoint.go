@@ -0,0 +1,154 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package smart
+
+import (
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/language"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// savepointInfo keeps the state of the contract at the beginning of try block
+type savepointInfo struct {
+	flush         int
+	notifications int
+	langs         int
+	sysUpdates    int
+}
+
+// langInfo keeps the language sources which have been replaced inside try block
+type langInfo struct {
+	ecosystem int
+	name      string
+	prev      *map[string]string
+}
+
+// inTry returns true if the contract is executed inside try block
+func (sc *SmartContract) inTry() bool {
+	return len(sc.savepoints) > 0
+}
+
+// updateLang updates the language sources and keeps the previous ones if it is called inside try block
+func (sc *SmartContract) updateLang(name, trans string) {
+	ecosystem := int(sc.TxSmart.EcosystemID)
+	if sc.inTry() {
+		sc.langs = append(sc.langs, langInfo{
+			ecosystem: ecosystem,
+			name:      name,
+			prev:      language.LangSource(ecosystem, name),
+		})
+	}
+	language.UpdateLang(ecosystem, name, trans)
+}
+
+// Savepoint creates the nested savepoint of the database transaction at the beginning of try block
+func (sc *SmartContract) Savepoint() error {
+	if sc.DbTransaction != nil {
+		if err := sc.DbTransaction.NestedSavepoint(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating nested savepoint")
+			return err
+		}
+	}
+	sc.savepoints = append(sc.savepoints, savepointInfo{
+		flush:         len(sc.FlushRollback),
+		notifications: len(sc.Notifications),
+		langs:         len(sc.langs),
+		sysUpdates:    sc.sysUpdates,
+	})
+	return nil
+}
+
+// ReleaseSavepoint releases the last nested savepoint when try block has been completed successfully
+func (sc *SmartContract) ReleaseSavepoint() error {
+	if len(sc.savepoints) == 0 {
+		return nil
+	}
+	sc.savepoints = sc.savepoints[:len(sc.savepoints)-1]
+	if sc.DbTransaction != nil {
+		if err := sc.DbTransaction.ReleaseNestedSavepoint(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("releasing nested savepoint")
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackSavepoint rollbacks the changes of the database, the virtual machine, the languages,
+// the system parameters and the notifications which have been made in the failed try block
+func (sc *SmartContract) RollbackSavepoint() error {
+	if len(sc.savepoints) == 0 {
+		return nil
+	}
+	savepoint := sc.savepoints[len(sc.savepoints)-1]
+	sc.savepoints = sc.savepoints[:len(sc.savepoints)-1]
+	if sc.DbTransaction != nil {
+		if err := sc.DbTransaction.RollbackNestedSavepoint(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("rolling back nested savepoint")
+			return err
+		}
+	}
+	RollbackFlush(sc.FlushRollback[savepoint.flush:])
+	sc.FlushRollback = sc.FlushRollback[:savepoint.flush]
+	sc.Notifications = sc.Notifications[:savepoint.notifications]
+	for i := len(sc.langs) - 1; i >= savepoint.langs; i-- {
+		language.RestoreLang(sc.langs[i].ecosystem, sc.langs[i].name, sc.langs[i].prev)
+	}
+	sc.langs = sc.langs[:savepoint.langs]
+	if sc.sysUpdates > savepoint.sysUpdates {
+		sc.sysUpdates = savepoint.sysUpdates
+		// the parameters are reloaded from the rolled back database transaction
+		if err := syspar.SysUpdate(sc.DbTransaction); err != nil {
+			return logErrorDB(err, "updating syspar")
+		}
+	}
+	return nil
+}
+
+// RollbackFlush restores the contracts and the functions of the virtual machine
+// which have been changed by the rolled back transaction
+func RollbackFlush(flush []FlushInfo) {
+	vm := GetVM()
+	for i := len(flush) - 1; i >= 0; i-- {
+		finfo := flush[i]
+		if finfo.Prev == nil {
+			if finfo.ID != uint32(len(vm.Children)-1) {
+				log.WithFields(log.Fields{"type": consts.ContractError, "value": finfo.ID,
+					"len": len(vm.Children) - 1}).Error("flush rollback")
+			} else {
+				vm.Children = vm.Children[:len(vm.Children)-1]
+				delete(vm.Objects, finfo.Name)
+			}
+		} else {
+			vm.Children[finfo.ID] = finfo.Prev
+			vm.Objects[finfo.Name] = finfo.Info
+		}
+	}
+}
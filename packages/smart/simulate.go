@@ -0,0 +1,187 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package smart
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/script"
+	"github.com/AplaProject/go-apla/packages/utils"
+	"github.com/AplaProject/go-apla/packages/utils/tx"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// simulateTimeout limits the waiting for locks and the statements of the simulation,
+// so the public dry run can't hold the locks of rows needed by the playing of blocks
+const simulateTimeout = 5 * time.Second
+
+// ExtFuncFuel is the fuel spent by calls of the extended function
+type ExtFuncFuel struct {
+	Name  string
+	Calls int64
+	Fuel  int64
+}
+
+// Simulation collects the details of the contract execution without committing it
+type Simulation struct {
+	Result  string
+	Error   error
+	Fuel    int64
+	ExtFunc map[string]*ExtFuncFuel
+	Tables  map[string]bool // the name of table and true if the table has been changed
+	Tracer  script.Tracer
+}
+
+// SimulateOptions overrides the environment of the simulation
+type SimulateOptions struct {
+	BlockData     *utils.BlockData     // the next block is used if it is nil
+	Rand          *rand.Rand           // it is seeded by the current time if it is nil
+	DbTransaction *model.DbTransaction // it must be the snapshot transaction rolled back by the caller if it isn't nil
+	Tracer        script.Tracer
+}
+
+// ExtFuncs returns the fuel of extended functions sorted by name
+func (s *Simulation) ExtFuncs() []*ExtFuncFuel {
+	list := make([]*ExtFuncFuel, 0, len(s.ExtFunc))
+	for _, item := range s.ExtFunc {
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// AddExtFuel implements script.FuelCounter
+func (sc *SmartContract) AddExtFuel(name string, fuel int64) {
+	if sc.Simulation == nil {
+		return
+	}
+	item, ok := sc.Simulation.ExtFunc[name]
+	if !ok {
+		item = &ExtFuncFuel{Name: name}
+		sc.Simulation.ExtFunc[name] = item
+	}
+	item.Calls++
+	item.Fuel += fuel
+}
+
+// GetTracer implements script.Traceable
+func (sc *SmartContract) GetTracer() script.Tracer {
+	if sc.Simulation == nil {
+		return nil
+	}
+	return sc.Simulation.Tracer
+}
+
+func (sc *SmartContract) touchTable(table string, write bool) {
+	if sc.Simulation == nil {
+		return
+	}
+	sc.Simulation.Tables[table] = sc.Simulation.Tables[table] || write
+}
+
+// SimulateContract executes the unsigned contract call in the snapshot database transaction which is
+// always rolled back. The signature isn't checked but the rest checks and payment are processed
+// the same way as for the real transaction. The shared VM isn't changed by the simulation, so
+// contracts created or bound in the call are not available till the real transaction
+func SimulateContract(smartTx *tx.SmartContract, txSize int64, options *SimulateOptions) (*Simulation, error) {
+	if options == nil {
+		options = &SimulateOptions{}
+	}
+	contract := GetContractByID(int32(smartTx.ID))
+	if contract == nil {
+		log.WithFields(log.Fields{"contract_id": smartTx.ID, "type": consts.NotFound}).Error("unknown contract")
+		return nil, fmt.Errorf(`unknown contract %d`, smartTx.ID)
+	}
+
+	txData := make(map[string]interface{})
+	if txInfo := contract.Info().Tx; txInfo != nil {
+		var err error
+		if txData, err = FillTxData(*txInfo, smartTx.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	blockData := options.BlockData
+	if blockData == nil {
+		infoBlock := &model.InfoBlock{}
+		if _, err := infoBlock.Get(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+			return nil, err
+		}
+		nodePosition, err := syspar.GetNodePositionByKeyID(conf.Config.KeyID)
+		if err != nil {
+			nodePosition = 0
+		}
+		blockData = &utils.BlockData{
+			BlockID:      infoBlock.BlockID + 1,
+			Time:         time.Now().Unix(),
+			KeyID:        conf.Config.KeyID,
+			NodePosition: nodePosition,
+		}
+	}
+	randSim := options.Rand
+	if randSim == nil {
+		randSim = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	dbTransaction := options.DbTransaction
+	if dbTransaction == nil {
+		var err error
+		if dbTransaction, err = model.StartSnapshotTransaction(simulateTimeout); err != nil {
+			return nil, err
+		}
+		defer dbTransaction.Rollback()
+	}
+
+	simulation := &Simulation{
+		ExtFunc: make(map[string]*ExtFuncFuel),
+		Tables:  make(map[string]bool),
+		Tracer:  options.Tracer,
+	}
+	sc := SmartContract{
+		VM:            GetVM(),
+		TxSmart:       *smartTx,
+		TxData:        txData,
+		TxContract:    &Contract{Name: contract.Name, Block: contract.Block},
+		BlockData:     blockData,
+		TxSize:        txSize,
+		DbTransaction: dbTransaction,
+		Rand:          randSim,
+		Simulation:    simulation,
+	}
+	simulation.Result, simulation.Error = sc.CallContract()
+	simulation.Fuel = sc.TxFuel
+	return simulation, nil
+}
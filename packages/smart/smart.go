@@ -33,6 +33,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
 	"unicode/utf8"
@@ -144,6 +145,22 @@ func VMCompileBlock(vm *script.VM, src string, owner *script.OwnerInfo) (*script
 	return vm.CompileBlock([]rune(src), owner)
 }
 
+// VMLint checks the source code with the linter
+func VMLint(vm *script.VM, src string, owner *script.OwnerInfo) []*script.LintMessage {
+	return vm.Lint([]rune(src), owner, &script.LintOptions{Externs: externFuncs()})
+}
+
+// externFuncs returns the functions of the extend map of contracts which can be called as $name()
+func externFuncs() map[string]bool {
+	externs := make(map[string]bool)
+	for name, val := range *(&SmartContract{}).getExtend() {
+		if reflect.ValueOf(val).Kind() == reflect.Func {
+			externs[name] = true
+		}
+	}
+	return externs
+}
+
 func getContractList(src string) (list []string) {
 	for _, funcCond := range []string{`ContractConditions`, `ContractAccess`} {
 		if strings.Contains(src, funcCond) {
@@ -352,6 +369,9 @@ func SetContractWallet(sc *SmartContract, tblid, state int64, wallet int64) erro
 	if err := validateAccess(`SetContractWallet`, sc, nBindWallet, nUnbindWallet); err != nil {
 		return err
 	}
+	if sc.Simulation != nil {
+		return nil
+	}
 	for i, item := range smartVM.Block.Children {
 		if item != nil && item.Type == script.ObjContract {
 			cinfo := item.Info.(*script.ContractInfo)
@@ -1038,15 +1058,18 @@ func (sc *SmartContract) CallContract() (string, error) {
 	}
 	sc.PublicKeys = append(sc.PublicKeys, public)
 
-	var CheckSignResult bool
-	CheckSignResult, err = utils.CheckSign(sc.PublicKeys, sc.TxHash, sc.TxSignature, false)
-	if err != nil {
-		logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("checking tx data sign")
-		return retError(err)
-	}
-	if !CheckSignResult {
-		logger.WithFields(log.Fields{"type": consts.InvalidObject}).Error("incorrect sign")
-		return retError(errIncorrectSign)
+	// the simulated call isn't signed
+	if sc.Simulation == nil {
+		var CheckSignResult bool
+		CheckSignResult, err = utils.CheckSign(sc.PublicKeys, sc.TxHash, sc.TxSignature, false)
+		if err != nil {
+			logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("checking tx data sign")
+			return retError(err)
+		}
+		if !CheckSignResult {
+			logger.WithFields(log.Fields{"type": consts.InvalidObject}).Error("incorrect sign")
+			return retError(errIncorrectSign)
+		}
 	}
 
 	needPayment := sc.TxSmart.EcosystemID > 0 && !sc.OBS && !syspar.IsPrivateBlockchain()
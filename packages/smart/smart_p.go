@@ -149,6 +149,7 @@ func UpdateSysParam(sc *SmartContract, name, value, conditions string) (int64, e
 	var (
 		fields []string
 		values []interface{}
+		fnodes []syspar.FullNode
 	)
 	par := &model.SystemParameter{}
 	found, err := par.Get(name)
@@ -180,8 +181,15 @@ func UpdateSysParam(sc *SmartContract, name, value, conditions string) (int64, e
 			ok = ival > 0 && ival < 86400
 		case syspar.RbBlocks1, syspar.NumberNodes:
 			ok = ival > 0 && ival < 1000
-		case syspar.CommissionSize:
+		case syspar.CommissionSize, syspar.EpochLength, syspar.MaxDBFindCost:
 			ok = ival >= 0
+		case syspar.StrictContracts:
+			_, ok = script.LintRuleSets[ival]
+			ok = ok || ival == 0
+		case syspar.BFTFinality:
+			ok = ival == 0 || ival == 1
+		case syspar.MinBlockVersion:
+			ok = ival >= 0 && ival <= consts.BLOCK_VERSION
 		case syspar.MaxBlockSize, syspar.MaxTxSize, syspar.MaxTxCount, syspar.MaxColumns,
 			syspar.MaxIndexes, syspar.MaxBlockUserTx, syspar.MaxTxFuel, syspar.MaxBlockFuel, syspar.MaxForsignSize:
 			ok = ival > 0
@@ -201,7 +209,6 @@ func UpdateSysParam(sc *SmartContract, name, value, conditions string) (int64, e
 			}
 			checked = true
 		case syspar.FullNodes:
-			fnodes := []syspar.FullNode{}
 			if err := json.Unmarshal([]byte(value), &fnodes); err != nil {
 				break check
 			}
@@ -234,14 +241,56 @@ func UpdateSysParam(sc *SmartContract, name, value, conditions string) (int64, e
 	if err != nil {
 		return 0, err
 	}
+	if len(fnodes) > 0 {
+		if err = addValidatorSet(sc, value, fnodes); err != nil {
+			return 0, err
+		}
+	}
+	// the simulation is rolled back so the parameters of the node must not be changed
+	if sc.Simulation != nil {
+		return 0, nil
+	}
 	err = syspar.SysUpdate(sc.DbTransaction)
 	if err != nil {
 		return 0, logErrorDB(err, "updating syspar")
 	}
 	sc.SysUpdate = true
+	sc.sysUpdates++
 	return 0, nil
 }
 
+// addValidatorSet records the new value of full_nodes with the block of its activation.
+// The changes of the positions of nodes are postponed till the next epoch so the generation
+// queue isn't shifted in the middle of the round
+func addValidatorSet(sc *SmartContract, value string, fnodes []syspar.FullNode) error {
+	if sc.OBS || sc.BlockData == nil {
+		return nil
+	}
+	blockID := sc.BlockData.BlockID
+	activation := blockID + 1
+	if syspar.GetEpochLength() > 0 && (syspar.HasPendingValidatorSets(activation) ||
+		!syspar.IsSameValidators(fnodes, activation)) {
+		activation = syspar.EpochActivation(blockID)
+	}
+	fields := []string{"value", "activation", "block_id"}
+	if !syspar.HasValidatorSets() {
+		// the nodes which were active before the first recorded change
+		prev := syspar.SysString(syspar.FullNodes)
+		if len(prev) == 0 {
+			data, err := marshalJSON(syspar.GetNodes(), `full nodes`)
+			if err != nil {
+				return err
+			}
+			prev = string(data)
+		}
+		if _, _, err := sc.insert(fields, []interface{}{prev, 0, blockID}, "1_validator_sets"); err != nil {
+			return err
+		}
+	}
+	_, _, err := sc.insert(fields, []interface{}{value, activation, blockID}, "1_validator_sets")
+	return err
+}
+
 // SysParamString returns the value of the system parameter
 func SysParamString(name string) string {
 	return syspar.SysString(name)
@@ -350,7 +399,9 @@ func CreateLanguage(sc *SmartContract, name, trans string) (id int64, err error)
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("inserting new language")
 		return 0, err
 	}
-	language.UpdateLang(int(sc.TxSmart.EcosystemID), name, trans)
+	if sc.Simulation == nil {
+		sc.updateLang(name, trans)
+	}
 	return id, nil
 }
 
@@ -364,7 +415,9 @@ func EditLanguage(sc *SmartContract, id int64, name, trans string) error {
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("inserting new language")
 		return err
 	}
-	language.UpdateLang(int(sc.TxSmart.EcosystemID), name, trans)
+	if sc.Simulation == nil {
+		sc.updateLang(name, trans)
+	}
 	return nil
 }
 
@@ -425,6 +478,11 @@ func CreateEcosystem(sc *SmartContract, wallet int64, name string) (int64, error
 	if err := validateAccess(`CreateEcosystem`, sc, nNewEcosystem); err != nil {
 		return 0, err
 	}
+	// the contracts of the new ecosystem are loaded to the virtual machine and they
+	// can't be unloaded by the rollback of try block
+	if sc.inTry() {
+		return 0, errTryEcosystem
+	}
 
 	var sp model.StateParameter
 	sp.SetTablePrefix(`1`)
@@ -452,8 +510,10 @@ func CreateEcosystem(sc *SmartContract, wallet int64, name string) (int64, error
 	}
 
 	idStr := converter.Int64ToStr(id)
-	if err := LoadContract(sc.DbTransaction, id); err != nil {
-		return 0, err
+	if sc.Simulation == nil {
+		if err := LoadContract(sc.DbTransaction, id); err != nil {
+			return 0, err
+		}
 	}
 	if !sc.OBS {
 		if err := SysRollback(sc, SysRollData{Type: "NewEcosystem", ID: id}); err != nil {
@@ -0,0 +1,356 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package snapshot
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+	"regexp"
+
+	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/model"
+)
+
+const (
+	formatVersion = 2
+	// maxValueSize is the max size of the single value in the snapshot
+	maxValueSize = 256 << 20
+)
+
+var (
+	magic = []byte("APLASNAP")
+
+	errFormat    = errors.New("Wrong format of the snapshot")
+	errValueSize = errors.New("Size of the snapshot value is too large")
+	errSchema    = errors.New("Wrong schema of the snapshot table")
+
+	// the schema is received from other nodes, so only these names, types and defaults
+	// can be used in the statements which create the tables
+	tableName     = regexp.MustCompile(`^[0-9]+_[0-9A-Za-z_]+$`)
+	columnName    = regexp.MustCompile(`^[0-9A-Za-z_]+$`)
+	columnType    = regexp.MustCompile(`^(bigint|integer|smallint|boolean|text|bytea|jsonb|uuid|double precision|timestamp without time zone|character varying(\(\d+\))?|character\(\d+\)|numeric(\(\d+(,\d+)?\))?)$`)
+	columnDefault = regexp.MustCompile(`^('([^']|'')*'|-?\d+(\.\d+)?|true|false)(::[a-z ]+(\(\d+(,\d+)?\))?)?$`)
+)
+
+// Table is the schema of the state table. The whole schema is the part of the state hash.
+type Table struct {
+	Name    string
+	Columns []model.SnapshotColumn
+	Indexes []model.SnapshotIndex
+}
+
+// check returns errSchema if the table has the names, the types or the defaults which
+// are not allowed
+func (t *Table) check() error {
+	if !tableName.MatchString(t.Name) || len(t.Columns) == 0 {
+		return errSchema
+	}
+	columns := make(map[string]bool)
+	for _, col := range t.Columns {
+		if !columnName.MatchString(col.Name) || !columnType.MatchString(col.Type) || columns[col.Name] ||
+			(len(col.Default) > 0 && !columnDefault.MatchString(col.Default)) {
+			return errSchema
+		}
+		columns[col.Name] = true
+	}
+	for _, index := range t.Indexes {
+		if !columnName.MatchString(index.Name) || len(index.Columns) == 0 {
+			return errSchema
+		}
+		for _, name := range index.Columns {
+			if !columns[name] {
+				return errSchema
+			}
+		}
+	}
+	return nil
+}
+
+// ColumnNames returns the names of the columns
+func (t *Table) ColumnNames() []string {
+	names := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// encoder writes the snapshot and calculates the state hash of all written data
+type encoder struct {
+	state io.Writer
+	hash  hash.Hash
+}
+
+func newEncoder(w io.Writer) (*encoder, error) {
+	h := crypto.NewHash()
+	e := &encoder{state: io.MultiWriter(w, h), hash: h}
+	if _, err := e.state.Write(magic); err != nil {
+		return nil, err
+	}
+	return e, writeUint32(e.state, formatVersion)
+}
+
+func (e *encoder) writeTable(t *Table) error {
+	if err := writeBytes(e.state, []byte(t.Name)); err != nil {
+		return err
+	}
+	if err := writeUint32(e.state, uint32(len(t.Columns))); err != nil {
+		return err
+	}
+	for _, col := range t.Columns {
+		if err := writeBytes(e.state, []byte(col.Name)); err != nil {
+			return err
+		}
+		if err := writeBytes(e.state, []byte(col.Type)); err != nil {
+			return err
+		}
+		if err := writeBool(e.state, col.NotNull); err != nil {
+			return err
+		}
+		if err := writeBytes(e.state, []byte(col.Default)); err != nil {
+			return err
+		}
+	}
+	if err := writeUint32(e.state, uint32(len(t.Indexes))); err != nil {
+		return err
+	}
+	for _, index := range t.Indexes {
+		if err := writeBytes(e.state, []byte(index.Name)); err != nil {
+			return err
+		}
+		if err := writeBool(e.state, index.Unique); err != nil {
+			return err
+		}
+		if err := writeBool(e.state, index.Primary); err != nil {
+			return err
+		}
+		if err := writeUint32(e.state, uint32(len(index.Columns))); err != nil {
+			return err
+		}
+		for _, name := range index.Columns {
+			if err := writeBytes(e.state, []byte(name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *encoder) writeRow(values []sql.NullString) error {
+	if err := writeBool(e.state, true); err != nil {
+		return err
+	}
+	for _, val := range values {
+		if err := writeBool(e.state, val.Valid); err != nil {
+			return err
+		}
+		if !val.Valid {
+			continue
+		}
+		if err := writeBytes(e.state, []byte(val.String)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) endTable() error {
+	return writeBool(e.state, false)
+}
+
+// close writes the end of the snapshot and returns the state hash
+func (e *encoder) close() ([]byte, error) {
+	if err := writeBytes(e.state, nil); err != nil {
+		return nil, err
+	}
+	return e.hash.Sum(nil), nil
+}
+
+// decoder reads the snapshot and calculates the state hash
+type decoder struct {
+	state io.Reader
+	hash  hash.Hash
+}
+
+func newDecoder(r io.Reader) (*decoder, error) {
+	h := crypto.NewHash()
+	d := &decoder{state: io.TeeReader(r, h), hash: h}
+	buf := make([]byte, len(magic))
+	if _, err := io.ReadFull(d.state, buf); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buf, magic) {
+		return nil, errFormat
+	}
+	version, err := readUint32(d.state)
+	if err != nil {
+		return nil, err
+	}
+	if version != formatVersion {
+		return nil, errFormat
+	}
+	return d, nil
+}
+
+// readTable returns the next table or nil at the end of the snapshot
+func (d *decoder) readTable() (*Table, error) {
+	name, err := readBytes(d.state)
+	if err != nil || len(name) == 0 {
+		return nil, err
+	}
+	t := &Table{Name: string(name)}
+	count, err := readUint32(d.state)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < count; i++ {
+		var col model.SnapshotColumn
+		if col.Name, err = readString(d.state); err != nil {
+			return nil, err
+		}
+		if col.Type, err = readString(d.state); err != nil {
+			return nil, err
+		}
+		if col.NotNull, err = readBool(d.state); err != nil {
+			return nil, err
+		}
+		if col.Default, err = readString(d.state); err != nil {
+			return nil, err
+		}
+		t.Columns = append(t.Columns, col)
+	}
+	if count, err = readUint32(d.state); err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < count; i++ {
+		var index model.SnapshotIndex
+		if index.Name, err = readString(d.state); err != nil {
+			return nil, err
+		}
+		if index.Unique, err = readBool(d.state); err != nil {
+			return nil, err
+		}
+		if index.Primary, err = readBool(d.state); err != nil {
+			return nil, err
+		}
+		columns, err := readUint32(d.state)
+		if err != nil {
+			return nil, err
+		}
+		for j := uint32(0); j < columns; j++ {
+			name, err := readString(d.state)
+			if err != nil {
+				return nil, err
+			}
+			index.Columns = append(index.Columns, name)
+		}
+		t.Indexes = append(t.Indexes, index)
+	}
+	return t, t.check()
+}
+
+// readRow reads the values of the next row, it returns false at the end of the table
+func (d *decoder) readRow(values []sql.NullString) (bool, error) {
+	next, err := readBool(d.state)
+	if err != nil || !next {
+		return false, err
+	}
+	for i := range values {
+		if values[i].Valid, err = readBool(d.state); err != nil {
+			return false, err
+		}
+		values[i].String = ""
+		if !values[i].Valid {
+			continue
+		}
+		if values[i].String, err = readString(d.state); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (d *decoder) sum() []byte {
+	return d.hash.Sum(nil)
+}
+
+func writeUint32(w io.Writer, val uint32) error {
+	return binary.Write(w, binary.LittleEndian, val)
+}
+
+func readUint32(r io.Reader) (val uint32, err error) {
+	err = binary.Read(r, binary.LittleEndian, &val)
+	return
+}
+
+func writeBool(w io.Writer, val bool) error {
+	b := []byte{0}
+	if val {
+		b[0] = 1
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readBool(r io.Reader) (bool, error) {
+	b := []byte{0}
+	if _, err := io.ReadFull(r, b); err != nil {
+		return false, err
+	}
+	return b[0] != 0, nil
+}
+
+func writeBytes(w io.Writer, data []byte) error {
+	if err := writeUint32(w, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	size, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxValueSize {
+		return nil, errValueSize
+	}
+	data := make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+func readString(r io.Reader) (string, error) {
+	data, err := readBytes(r)
+	return string(data), err
+}
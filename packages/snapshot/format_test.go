@@ -0,0 +1,166 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package snapshot
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTable struct {
+	table *Table
+	rows  [][]sql.NullString
+}
+
+func testTables(def string, value string) []testTable {
+	return []testTable{
+		{
+			table: &Table{
+				Name: "1_keys",
+				Columns: []model.SnapshotColumn{
+					{Name: "amount", Type: "numeric(30,0)", NotNull: true, Default: def},
+					{Name: "id", Type: "bigint", NotNull: true},
+				},
+				Indexes: []model.SnapshotIndex{
+					{Name: "1_keys_pkey", Columns: []string{"id"}, Unique: true, Primary: true},
+					{Name: "1_keys_index_amount", Columns: []string{"amount", "id"}},
+				},
+			},
+			rows: [][]sql.NullString{
+				{{String: value, Valid: true}, {String: "1", Valid: true}},
+				{{}, {String: "2", Valid: true}},
+			},
+		},
+		{
+			table: &Table{
+				Name:    "1_empty",
+				Columns: []model.SnapshotColumn{{Name: "id", Type: "bigint"}},
+			},
+		},
+	}
+}
+
+func encodeTables(t *testing.T, tables []testTable) ([]byte, []byte) {
+	buf := &bytes.Buffer{}
+	enc, err := newEncoder(buf)
+	require.NoError(t, err)
+	for _, item := range tables {
+		require.NoError(t, enc.writeTable(item.table))
+		for _, row := range item.rows {
+			require.NoError(t, enc.writeRow(row))
+		}
+		require.NoError(t, enc.endTable())
+	}
+	hash, err := enc.close()
+	require.NoError(t, err)
+	return buf.Bytes(), hash
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tables := testTables("'0'::numeric", "100")
+	data, hash := encodeTables(t, tables)
+
+	dec, err := newDecoder(bytes.NewReader(data))
+	require.NoError(t, err)
+	for _, item := range tables {
+		table, err := dec.readTable()
+		require.NoError(t, err)
+		require.Equal(t, item.table, table)
+
+		values := make([]sql.NullString, len(table.Columns))
+		for _, row := range item.rows {
+			next, err := dec.readRow(values)
+			require.NoError(t, err)
+			require.True(t, next)
+			require.Equal(t, row, values)
+		}
+		next, err := dec.readRow(values)
+		require.NoError(t, err)
+		require.False(t, next)
+	}
+	table, err := dec.readTable()
+	require.NoError(t, err)
+	require.Nil(t, table)
+	require.Equal(t, hash, dec.sum())
+}
+
+func TestStateHash(t *testing.T) {
+	_, hash := encodeTables(t, testTables("'0'::numeric", "100"))
+
+	_, other := encodeTables(t, testTables("'0'::numeric", "100"))
+	require.Equal(t, hash, other)
+
+	_, other = encodeTables(t, testTables("'0'::numeric", "101"))
+	require.NotEqual(t, hash, other)
+
+	// the whole schema is the part of the state
+	_, other = encodeTables(t, testTables("", "100"))
+	require.NotEqual(t, hash, other)
+
+	tables := testTables("'0'::numeric", "100")
+	tables[0].table.Indexes[1].Unique = true
+	_, other = encodeTables(t, tables)
+	require.NotEqual(t, hash, other)
+}
+
+func TestCheckSchema(t *testing.T) {
+	valid := func() *Table {
+		return testTables("'0'::numeric", "100")[0].table
+	}
+	require.NoError(t, valid().check())
+
+	table := valid()
+	table.Name = `keys"; DROP TABLE "1_keys`
+	require.Equal(t, errSchema, table.check())
+
+	table = valid()
+	table.Columns[0].Type = "numeric(30,0) DEFAULT 0; DROP TABLE members; --"
+	require.Equal(t, errSchema, table.check())
+
+	table = valid()
+	table.Columns[0].Default = "nextval('seq')"
+	require.Equal(t, errSchema, table.check())
+
+	table = valid()
+	table.Columns[1].Name = table.Columns[0].Name
+	require.Equal(t, errSchema, table.check())
+
+	table = valid()
+	table.Indexes[1].Columns = []string{"unknown"}
+	require.Equal(t, errSchema, table.check())
+
+	table = valid()
+	table.Columns = nil
+	table.Indexes = nil
+	require.Equal(t, errSchema, table.check())
+}
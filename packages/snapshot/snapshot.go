@@ -0,0 +1,325 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// insertBatchValues is the max count of the values which are inserted with one query
+const insertBatchValues = 30000
+
+var (
+	// ErrStateHash is returned when the hash of the imported state doesn't match the snapshot
+	ErrStateHash = errors.New("Wrong state hash of the snapshot")
+	// ErrBlock is returned when the block of the snapshot doesn't match the snapshot info
+	ErrBlock = errors.New("Wrong block of the snapshot")
+)
+
+// Info describes the snapshot of the state at the block
+type Info struct {
+	BlockID   int64
+	BlockHash []byte
+	StateHash []byte
+	// Block is the record of block_chain which the snapshot has been made at
+	Block *model.Block
+}
+
+// Export writes all ecosystem tables and returns the state hash. The transaction must be
+// started with model.SetRepeatableRead so the tables are consistent.
+func Export(transaction *model.DbTransaction, w io.Writer) ([]byte, error) {
+	enc, err := newEncoder(w)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := model.GetStateTables(transaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting state tables")
+		return nil, err
+	}
+	for _, name := range tables {
+		if err = exportTable(transaction, enc, name); err != nil {
+			return nil, err
+		}
+	}
+	return enc.close()
+}
+
+// StateHash returns the state hash of the block transaction. The output settings are changed
+// inside the nested savepoint which is rolled back, so the next queries of the transaction use
+// the settings of the node.
+func StateHash(transaction *model.DbTransaction) ([]byte, error) {
+	if err := transaction.NestedSavepoint(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating nested savepoint")
+		return nil, err
+	}
+	var hash []byte
+	err := model.SetCanonicalOutput(transaction)
+	if err == nil {
+		hash, err = Export(transaction, ioutil.Discard)
+	}
+	if errRoll := transaction.RollbackNestedSavepoint(); errRoll != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": errRoll}).Error("rolling back nested savepoint")
+		if err == nil {
+			err = errRoll
+		}
+	}
+	return hash, err
+}
+
+func exportTable(transaction *model.DbTransaction, enc *encoder, name string) error {
+	var err error
+	t := &Table{Name: name}
+	if t.Columns, err = model.GetSnapshotColumns(transaction, name); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": name}).Error("getting table columns")
+		return err
+	}
+	if t.Indexes, err = model.GetTableIndexes(transaction, name); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": name}).Error("getting table indexes")
+		return err
+	}
+	if err = enc.writeTable(t); err != nil {
+		return err
+	}
+
+	rows, err := model.GetSnapshotRows(transaction, name, t.ColumnNames())
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": name}).Error("selecting table rows")
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(t.Columns))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": name}).Error("scanning table row")
+			return err
+		}
+		if err = enc.writeRow(values); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": name}).Error("reading table rows")
+		return err
+	}
+	return enc.endTable()
+}
+
+// Import replaces the ecosystem tables, the last block and info_block with the snapshot.
+// The tables are created from the schema of the snapshot, the tables which aren't in the
+// snapshot are dropped. info.StateHash must be taken from the header of the checkpoint block.
+// The transaction must be rolled back if the error is returned.
+func Import(transaction *model.DbTransaction, r io.Reader, info *Info) error {
+	if err := checkBlock(info); err != nil {
+		return err
+	}
+	dec, err := newDecoder(bufio.NewReader(r))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ProtocolError, "error": err}).Error("reading snapshot header")
+		return err
+	}
+	imported := make(map[string]bool)
+	for {
+		t, err := dec.readTable()
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.ProtocolError, "error": err}).Error("reading snapshot table")
+			return err
+		}
+		if t == nil {
+			break
+		}
+		if imported[t.Name] {
+			log.WithFields(log.Fields{"type": consts.DuplicateObject, "table": t.Name}).Error("duplicate snapshot table")
+			return errSchema
+		}
+		imported[t.Name] = true
+		if err = importTable(transaction, dec, t); err != nil {
+			return err
+		}
+	}
+	if hash := dec.sum(); !bytes.Equal(hash, info.StateHash) {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "hash": fmt.Sprintf("%x", hash),
+			"expected": fmt.Sprintf("%x", info.StateHash)}).Error("state hash of the snapshot doesn't match")
+		return ErrStateHash
+	}
+	if err = dropTables(transaction, imported); err != nil {
+		return err
+	}
+	return importBlock(transaction, info)
+}
+
+// dropTables drops the state tables which aren't in the snapshot
+func dropTables(transaction *model.DbTransaction, imported map[string]bool) error {
+	tables, err := model.GetStateTables(transaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting state tables")
+		return err
+	}
+	for _, name := range tables {
+		if imported[name] {
+			continue
+		}
+		if err = model.DropTable(transaction, name); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": name}).Error("dropping table")
+			return err
+		}
+	}
+	return nil
+}
+
+func checkBlock(info *Info) error {
+	if info.Block == nil || info.Block.ID != info.BlockID || !bytes.Equal(info.Block.Hash, info.BlockHash) {
+		return ErrBlock
+	}
+	header, err := utils.ParseBlockHeader(bytes.NewBuffer(info.Block.Data), false)
+	if err != nil {
+		return err
+	}
+	if header.BlockID != info.BlockID || header.Time != info.Block.Time || header.KeyID != info.Block.KeyID ||
+		header.NodePosition != info.Block.NodePosition {
+		return ErrBlock
+	}
+	return nil
+}
+
+func importTable(transaction *model.DbTransaction, dec *decoder, t *Table) error {
+	logger := log.WithFields(log.Fields{"table": t.Name})
+	exists, err := model.IsTableTx(transaction, t.Name)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("checking table")
+		return err
+	}
+	if exists {
+		if err = model.DropTable(transaction, t.Name); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("dropping table")
+			return err
+		}
+	}
+	if err = createTable(transaction, t); err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating table")
+		return err
+	}
+
+	columns := t.ColumnNames()
+	batch := insertBatchValues / len(columns)
+	rows := make([][]interface{}, 0, batch)
+	values := make([]sql.NullString, len(columns))
+	for {
+		next, err := dec.readRow(values)
+		if err != nil {
+			logger.WithFields(log.Fields{"type": consts.ProtocolError, "error": err}).Error("reading snapshot row")
+			return err
+		}
+		if next {
+			row := make([]interface{}, len(values))
+			for i, val := range values {
+				if val.Valid {
+					row[i] = val.String
+				}
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == batch || (!next && len(rows) > 0) {
+			if err = model.InsertSnapshotRows(transaction, t.Name, columns, rows); err != nil {
+				logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("inserting snapshot rows")
+				return err
+			}
+			rows = rows[:0]
+		}
+		if !next {
+			return nil
+		}
+	}
+}
+
+// createTable creates the table and its indexes, the schema must be checked before
+func createTable(transaction *model.DbTransaction, t *Table) error {
+	defs := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		defs[i] = col.Definition()
+	}
+	if err := model.CreateSnapshotTable(transaction, t.Name, defs); err != nil {
+		return err
+	}
+	for _, index := range t.Indexes {
+		if err := model.GetDB(transaction).Exec(index.Definition(t.Name)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importBlock(transaction *model.DbTransaction, info *Info) error {
+	// the state hash has been verified by the header of the checkpoint block
+	info.Block.StateHash = info.StateHash
+	if err := info.Block.DeleteById(transaction, info.BlockID); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting block by id")
+		return err
+	}
+	if err := info.Block.Create(transaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating block")
+		return err
+	}
+	// the blocks before the snapshot can't be rolled back
+	if err := model.ClearTable(transaction, model.RollbackTx{}.TableName()); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting rollback transactions")
+		return err
+	}
+	ib := &model.InfoBlock{
+		Hash:          info.Block.Hash,
+		BlockID:       info.BlockID,
+		Time:          info.Block.Time,
+		EcosystemID:   info.Block.EcosystemID,
+		KeyID:         info.Block.KeyID,
+		NodePosition:  converter.Int64ToStr(info.Block.NodePosition),
+		RollbacksHash: info.Block.RollbacksHash,
+	}
+	if err := ib.Update(transaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("updating info block")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/AplaProject/go-apla/packages/conf"
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dirName   = "snapshots"
+	dataExt   = ".snapshot"
+	infoExt   = ".json"
+	tempExt   = ".tmp"
+	keepCount = 2
+)
+
+var (
+	// ErrNotFound is returned when the snapshot doesn't exist
+	ErrNotFound = errors.New("Snapshot not found")
+	// ErrNoStateHash is returned when the state hash which the checkpoint block must contain is unknown
+	ErrNoStateHash = errors.New("State hash of the checkpoint is unknown")
+)
+
+// creating is 1 while the snapshot is being created
+var creating int32
+
+func dir() string {
+	return filepath.Join(conf.Config.DataDir, dirName)
+}
+
+func dataPath(blockID int64) string {
+	return filepath.Join(dir(), strconv.FormatInt(blockID, 10)+dataExt)
+}
+
+func infoPath(blockID int64) string {
+	return filepath.Join(dir(), strconv.FormatInt(blockID, 10)+infoExt)
+}
+
+// blocks returns the block ids of the stored snapshots in ascending order
+func blocks() ([]int64, error) {
+	return listBlocks(infoExt)
+}
+
+// listBlocks returns the block ids of the files with the extension in ascending order
+func listBlocks(ext string) ([]int64, error) {
+	files, err := ioutil.ReadDir(dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, ext) {
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimSuffix(name, ext), 10, 64)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// GetInfo returns the info of the snapshot at the block, the latest snapshot is returned if blockID is 0
+func GetInfo(blockID int64) (*Info, error) {
+	if blockID == 0 {
+		ids, err := blocks()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, ErrNotFound
+		}
+		blockID = ids[len(ids)-1]
+	}
+	data, err := ioutil.ReadFile(infoPath(blockID))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{}
+	if err = json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Latest returns the block of the latest snapshot which can be verified by the header of
+// its checkpoint block
+func Latest() (int64, error) {
+	ids, err := blocks()
+	if err != nil {
+		return 0, err
+	}
+	last := &model.Block{}
+	if _, err = last.GetMaxBlock(); err != nil {
+		return 0, err
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		if ids[i]%consts.SnapshotCheckpoint == 0 && ids[i]+consts.SnapshotCheckpoint <= last.ID {
+			return ids[i], nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+// Checkpoint contains the block_chain records of the checkpoint block of the snapshot and
+// the block before it, the hash of the checkpoint block is calculated from them
+type Checkpoint struct {
+	Prev  *model.Block
+	Block *model.Block
+}
+
+// GetCheckpoint returns the checkpoint of the snapshot at the block
+func GetCheckpoint(blockID int64) (*Checkpoint, error) {
+	cp := &Checkpoint{Prev: &model.Block{}, Block: &model.Block{}}
+	found, err := cp.Block.Get(blockID + consts.SnapshotCheckpoint)
+	if err == nil && found {
+		found, err = cp.Prev.Get(cp.Block.ID - 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return cp, nil
+}
+
+// IsCheckpoint returns true if the state hash of the block is calculated while it is played.
+// These are the blocks of BV_SNAPSHOT_HASH version at every consts.SnapshotCheckpoint blocks.
+func IsCheckpoint(header *utils.BlockData) bool {
+	return header.BlockID%consts.SnapshotCheckpoint == 0 && header.Version >= consts.BV_SNAPSHOT_HASH
+}
+
+// CheckpointHash returns the state hash which the header of the block must contain. The checkpoint
+// block contains the state hash of the block which is consts.SnapshotCheckpoint blocks before it if
+// that block is the checkpoint too, otherwise it returns nil. Every node has played or imported that
+// block, so ErrNoStateHash is returned if the hash is missing and the block must not be accepted.
+func CheckpointHash(blockID int64) ([]byte, error) {
+	if blockID%consts.SnapshotCheckpoint != 0 || blockID <= consts.SnapshotCheckpoint {
+		return nil, nil
+	}
+	block := &model.Block{}
+	found, err := block.Get(blockID - consts.SnapshotCheckpoint)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting checkpoint block")
+		return nil, err
+	}
+	if !found {
+		log.WithFields(log.Fields{"type": consts.NotFound, "block_id": blockID - consts.SnapshotCheckpoint}).Error("checkpoint block not found")
+		return nil, ErrNoStateHash
+	}
+	header, err := utils.ParseBlockHeader(bytes.NewBuffer(block.Data), false)
+	if err != nil {
+		return nil, err
+	}
+	if !IsCheckpoint(&header) {
+		return nil, nil
+	}
+	if len(block.StateHash) != consts.HashSize {
+		log.WithFields(log.Fields{"type": consts.NotFound, "block_id": block.ID}).Error("state hash of checkpoint block is missing")
+		return nil, ErrNoStateHash
+	}
+	return block.StateHash, nil
+}
+
+// Open returns the info and the data of the snapshot at the block
+func Open(blockID int64) (*Info, *os.File, error) {
+	info, err := GetInfo(blockID)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(dataPath(info.BlockID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, file, nil
+}
+
+// Schedule creates the snapshot if the block is divisible by the snapshot interval. The interval
+// is rounded up to the multiple of consts.SnapshotCheckpoint. It must be called right after the block
+// has been committed so the snapshot sees the state of this block, the tables are exported in the background.
+func Schedule(blockID int64) {
+	interval := conf.Config.Snapshot.Interval
+	interval = (interval + consts.SnapshotCheckpoint - 1) / consts.SnapshotCheckpoint * consts.SnapshotCheckpoint
+	if interval <= 0 || blockID%interval != 0 {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{"block_id": blockID})
+	if !atomic.CompareAndSwapInt32(&creating, 0, 1) {
+		logger.WithFields(log.Fields{"type": consts.BlockError}).Warn("previous snapshot is still being created")
+		return
+	}
+
+	transaction, block, err := startExport(blockID)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("starting snapshot")
+		atomic.StoreInt32(&creating, 0)
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&creating, 0)
+		defer transaction.Rollback()
+
+		st := time.Now()
+		if err := create(transaction, block); err != nil {
+			logger.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("creating snapshot")
+			return
+		}
+		logger.WithFields(log.Fields{"time": time.Since(st).String()}).Info("snapshot created")
+		if err := removeOld(); err != nil {
+			logger.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("removing old snapshots")
+		}
+	}()
+}
+
+// startExport starts the transaction which sees the state of the block
+func startExport(blockID int64) (*model.DbTransaction, *model.Block, error) {
+	transaction, err := model.StartTransaction()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = model.SetRepeatableRead(transaction); err != nil {
+		transaction.Rollback()
+		return nil, nil, err
+	}
+	block := &model.Block{}
+	found, err := isLastBlock(transaction, block, blockID)
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+	if err != nil {
+		transaction.Rollback()
+		return nil, nil, err
+	}
+	return transaction, block, nil
+}
+
+func isLastBlock(transaction *model.DbTransaction, block *model.Block, blockID int64) (bool, error) {
+	ib := &model.InfoBlock{}
+	if err := model.GetDB(transaction).Last(ib).Error; err != nil {
+		return false, err
+	}
+	if ib.BlockID != blockID {
+		return false, nil
+	}
+	err := model.GetDB(transaction).Where("id = ?", blockID).First(block).Error
+	return err == nil, err
+}
+
+func create(transaction *model.DbTransaction, block *model.Block) error {
+	if err := os.MkdirAll(dir(), os.ModePerm); err != nil {
+		return err
+	}
+	temp := dataPath(block.ID) + tempExt
+	file, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp)
+
+	w := bufio.NewWriter(file)
+	hash, err := Export(transaction, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(temp, dataPath(block.ID)); err != nil {
+		return err
+	}
+
+	info := &Info{BlockID: block.ID, BlockHash: block.Hash, StateHash: hash, Block: block}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(infoPath(block.ID), data, 0644)
+}
+
+// removeOld removes the snapshots except for the latest ones
+func removeOld() error {
+	keep := conf.Config.Snapshot.Keep
+	if keep <= 0 {
+		keep = keepCount
+	}
+	ids, err := blocks()
+	if err != nil || len(ids) <= keep {
+		return err
+	}
+	for _, id := range ids[:len(ids)-keep] {
+		if err = os.Remove(infoPath(id)); err != nil {
+			return err
+		}
+		if err = os.Remove(dataPath(id)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
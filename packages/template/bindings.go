@@ -0,0 +1,139 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+// Reactive bindings are the attributes of the nodes which the front-end evaluates on the client
+// without the rendering of the page. The page is rendered once and the front-end updates the nodes
+// when the user changes the values of the inputs or when the contract of the button has been
+// executed. The attributes have the following schema.
+//
+// "calculate" is set by the tail Calculate(Exp, Type, Prec) of Input, Span, P, Div and Label
+//
+//	{
+//		"exp": "$price * $quantity",    // the expression, $name is the value of the input name
+//		"inputs": ["price", "quantity"], // the names of the inputs which the expression depends on
+//		"type": "money",                 // int (by default), float or money as in Calculate
+//		"prec": "2"                      // the precision of float values
+//	}
+//
+// The front-end recomputes the expression when any of the inputs is changed. The result is the
+// value of Input or the text of other nodes. The empty or non-numeric input is evaluated as 0.
+//
+// "show" and "hide" are set by the tails Show(Condition) and Hide(Condition) of Div, Form, Input,
+// Label, P, Span, Button, Select, RadioGroup, LinkPage, Image and Table
+//
+//	[{"name": "value", "agree": ""}, {"name": "other"}]
+//
+// Every tail adds the object of the conditions to the list. The node is shown (hidden) if all
+// inputs of any object have the specified values, the empty value matches the empty input.
+//
+// "refresh" is set by the tail Refresh(Source) of Button
+//
+//	["mysrc", "other"]
+//
+// These are the sources of DBFind or Data of the current page. When the contract has been
+// executed the front-end requests content/data/{page}/{source} with the parameters of the page
+// and replaces only the node of the source. The server processes the page only till the node of
+// the source because it may depend on the variables set by the previous nodes, the nodes after
+// the source are skipped. The response contains only the node of the source.
+
+package template
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/pagecache"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// bindInput is the reference to the value of the input in the client-side expressions
+var bindInput = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// bindCalculate is the client-side expression which is recomputed from the values of inputs
+type bindCalculate struct {
+	Exp    string   `json:"exp"`
+	Inputs []string `json:"inputs"`
+	Type   string   `json:"type,omitempty"`
+	Prec   string   `json:"prec,omitempty"`
+}
+
+func bindCalculateTag(par parFunc) string {
+	exp := macro((*par.Pars)[`Exp`], par.Workspace.Vars)
+	etype := macro((*par.Pars)[`Type`], par.Workspace.Vars)
+	prec := macro((*par.Pars)[`Prec`], par.Workspace.Vars)
+	inputs := make([]string, 0)
+	for _, match := range bindInput.FindAllStringSubmatch(exp, -1) {
+		inputs = append(inputs, match[1])
+	}
+	// the expression is checked by the server-side evaluator with the inputs equal to 1
+	ret := calculate(bindInput.ReplaceAllString(exp, ` 1 `), etype, prec)
+	if _, err := strconv.ParseFloat(ret, 64); len(inputs) == 0 || (err != nil && ret != errDiv.Error()) {
+		log.WithFields(log.Fields{"type": consts.ParseError, "exp": exp}).Warning("wrong client-side expression")
+		return ``
+	}
+	par.Owner.Attr[`calculate`] = &bindCalculate{Exp: exp, Inputs: inputs, Type: etype, Prec: prec}
+	return ``
+}
+
+func refreshTag(par parFunc) string {
+	var sources []string
+	if par.Owner.Attr[`refresh`] != nil {
+		sources = par.Owner.Attr[`refresh`].([]string)
+	}
+	for _, source := range strings.Split(macro((*par.Pars)[`Source`], par.Workspace.Vars), `,`) {
+		if source = strings.TrimSpace(source); len(source) > 0 {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) > 0 {
+		par.Owner.Attr[`refresh`] = sources
+	}
+	return ``
+}
+
+// Template2Source processes the page till the node of DBFind or Data which defines the source
+// and returns only that node. The source can depend only on the variables which have been set
+// before it so the rest of the page isn't processed. It returns nil if the source isn't found
+func Template2Source(input string, timeout *bool, vars *map[string]string, deps *pagecache.Deps,
+	source string) []byte {
+	target := &sourceTarget{name: source}
+	buildTree(input, timeout, vars, deps, target)
+	if target.node == nil || *timeout {
+		return nil
+	}
+	out, err := json.Marshal([]*node{target.node})
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling source node")
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+
+package template
+
+import (
+	"testing"
+)
+
+func TestBindings(t *testing.T) {
+	var timeout bool
+	vars := map[string]string{`_full`: `0`, `src`: `mysrc`}
+	for _, item := range (tplList{
+		{`Input(Name: total).Calculate("$price * $quantity", money)`,
+			`[{"tag":"input","attr":{"calculate":{"exp":"$price * $quantity","inputs":["price","quantity"],"type":"money"},"name":"total"}}]`},
+		{`Span(0).Calculate(Exp: ($a + 1.5) / $b, Type: float, Prec: 2).Show("mode=calc")`,
+			`[{"tag":"span","attr":{"calculate":{"exp":"($a + 1.5) / $b","inputs":["a","b"],"type":"float","prec":"2"},"show":[{"mode":"calc"}]},"children":[{"tag":"text","text":"0"}]}]`},
+		{`Span(0).Calculate("$a $b")Span(1).Calculate(10 + 2)`,
+			`[{"tag":"span","children":[{"tag":"text","text":"0"}]},{"tag":"span","children":[{"tag":"text","text":"1"}]}]`},
+		{`Input(Name: agree, Type: checkbox).Hide("name=").Hide("name=admin, agree")`,
+			`[{"tag":"input","attr":{"hide":[{"name":""},{"agree":"","name":"admin"}],"name":"agree","type":"checkbox"}}]`},
+		{`Button(Body: Save, Contract: NewItem).Refresh("#src#, other").Refresh(third)`,
+			`[{"tag":"button","attr":{"contract":"NewItem","refresh":["mysrc","other","third"]},"children":[{"tag":"text","text":"Save"}]}]`},
+	}) {
+		if templ := Template2JSON(item.input, &timeout, &vars); string(templ) != item.want {
+			t.Errorf("wrong json \r\n%s != \r\n%s", templ, item.want)
+		}
+	}
+}
+
+func TestTemplate2Source(t *testing.T) {
+	var timeout bool
+	vars := map[string]string{`_full`: `0`, `src`: `mysrc`}
+	input := `Div(){Data(Source: first, Columns: "a"){1}Data(Source: #src#, Columns: "a"){2}}
+		Data(Source: #src#, Columns: "a"){3}`
+	want := `[{"tag":"data","attr":{"columns":["a"],"data":[["2"]],"source":"mysrc","types":["text"]}}]`
+	if out := Template2Source(input, &timeout, &vars, nil, `mysrc`); string(out) != want {
+		t.Errorf("wrong source \r\n%s != \r\n%s", out, want)
+	}
+	if out := Template2Source(input, &timeout, &vars, nil, `unknown`); out != nil {
+		t.Errorf(`unknown source has been found %s`, out)
+	}
+}
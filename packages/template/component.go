@@ -0,0 +1,233 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const componentDecl = `Component`
+
+// componentVars are the variables of the caller which are visible inside components
+var componentVars = []string{`_full`, `_include`, `ecosystem_id`, `ecosystem_name`, `key_id`,
+	`role_id`, `isMobile`, `guest_key`, `lang`, `app_id`, `obs`}
+
+// component is a block which declares its parameters with Component(...) and
+// is called like a function
+type component struct {
+	Name   string
+	Source string
+	Params []string
+}
+
+// components caches the blocks of the template and tracks the chain of component calls
+type components struct {
+	blocks map[string]*component
+	stack  []string
+}
+
+// getBlock returns the block from the ecosystem
+var getBlock = func(ecosystem, name string) (*model.BlockInterface, bool, error) {
+	bi := &model.BlockInterface{}
+	if model.DBConn == nil {
+		return bi, false, nil
+	}
+	bi.SetTablePrefix(ecosystem)
+	found, err := bi.Get(name)
+	return bi, found, err
+}
+
+// parseComponent returns the component if the block starts with the declaration of parameters
+func parseComponent(name, value string) *component {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, componentDecl+`(`) {
+		return nil
+	}
+	params, shift, _ := getFunc(value[len(componentDecl):], tplFunc{Params: `*`})
+	comp := &component{Name: name, Source: value}
+	for _, par := range *params {
+		parName := strings.TrimSpace(string(par))
+		if off := strings.IndexByte(parName, ':'); off != -1 {
+			parName = strings.TrimSpace(parName[:off])
+		}
+		if len(parName) > 0 {
+			comp.Params = append(comp.Params, parName)
+		}
+	}
+	if shift == 0 {
+		return nil
+	}
+	return comp
+}
+
+// getComponent returns the function of the component with the specified name
+func (w *Workspace) getComponent(name string) (tplFunc, bool) {
+	if len(name) == 0 || name[0] < 'A' || name[0] > 'Z' {
+		return tplFunc{}, false
+	}
+	if w.components == nil {
+		w.components = &components{blocks: make(map[string]*component)}
+	}
+	comp, ok := w.components.blocks[name]
+	if !ok {
+		bi, found, err := getBlock(getVar(w, `ecosystem_id`), name)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block by name")
+		} else if found {
+			w.deps.AddRow(bi.TableName(), bi.ID)
+			comp = parseComponent(name, bi.Value)
+		} else {
+			w.deps.AddTable(bi.TableName())
+		}
+		w.components.blocks[name] = comp
+	}
+	if comp == nil {
+		return tplFunc{}, false
+	}
+	return tplFunc{
+		Func: func(par parFunc) string {
+			return componentTag(par, comp)
+		},
+		Full: func(par parFunc) string {
+			return componentFull(par, comp)
+		},
+		Tag:    `component`,
+		Params: strings.Join(comp.Params, `,`),
+	}, true
+}
+
+func componentFull(par parFunc, comp *component) string {
+	setAllAttr(par)
+	par.Node.Attr[`name`] = comp.Name
+	par.Owner.Children = append(par.Owner.Children, par.Node)
+	return ``
+}
+
+// componentTag processes the component in the own workspace. The values of the parameters
+// are processed in the workspace of the caller, the component sees only them and
+// the system variables.
+func componentTag(par parFunc, comp *component) string {
+	calls := par.Workspace.components
+	loop := len(calls.stack) >= maxDeep
+	for _, item := range calls.stack {
+		if item == comp.Name {
+			loop = true
+			break
+		}
+	}
+	if loop {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "name": comp.Name}).Error("component recursion")
+		return fmt.Sprintf("Component %s calls itself recursively", comp.Name)
+	}
+	vars := make(map[string]Var)
+	for _, name := range componentVars {
+		if v, ok := (*par.Workspace.Vars)[name]; ok {
+			vars[name] = v
+		}
+	}
+	workspace := &Workspace{
+		Vars:          &vars,
+		SmartContract: par.Workspace.SmartContract,
+		Timeout:       par.Workspace.Timeout,
+		components:    calls,
+		slots:         make(map[string][]*node),
+		deps:          par.Workspace.deps,
+		source:        par.Workspace.source,
+	}
+	for _, name := range comp.Params {
+		value := (*par.Pars)[name]
+		if len(value) == 0 {
+			continue
+		}
+		var children []*node
+		if name == `Body` {
+			children = par.Node.Children
+		} else if strings.ContainsAny(value, `({`) {
+			root := node{}
+			process(value, &root, par.Workspace)
+			children = root.Children
+		}
+		if children != nil {
+			var text string
+			for _, child := range children {
+				if child.Tag == tagText {
+					child.Text = macro(child.Text, par.Workspace.Vars)
+					text += child.Text
+				}
+			}
+			workspace.slots[name] = children
+			value = text
+		} else {
+			value = macro(value, par.Workspace.Vars)
+		}
+		vars[name] = Var{Value: value, AsIs: true}
+	}
+	root := node{}
+	calls.stack = append(calls.stack, comp.Name)
+	process(comp.Source, &root, workspace)
+	calls.stack = calls.stack[:len(calls.stack)-1]
+	for _, child := range root.Children {
+		if child.Tag == tagText {
+			child.Text = macro(child.Text, workspace.Vars)
+		}
+		par.Owner.Children = append(par.Owner.Children, child)
+	}
+	return ``
+}
+
+// componentDeclTag assigns the default values to the parameters which have not been passed
+func componentDeclTag(par parFunc) string {
+	for key, value := range *par.Pars {
+		if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+			key, value = value, ``
+		}
+		if _, ok := (*par.Workspace.Vars)[key]; !ok && len(key) > 0 {
+			setVar(par.Workspace, key, value)
+		}
+	}
+	return ``
+}
+
+// paramTag inserts the markup passed to the parameter of the component or the text of the parameter
+func paramTag(par parFunc) string {
+	name := (*par.Pars)[`Name`]
+	if children, ok := par.Workspace.slots[name]; ok {
+		par.Owner.Children = append(par.Owner.Children, children...)
+		return ``
+	}
+	if v, ok := (*par.Workspace.Vars)[name]; ok {
+		return v.Value
+	}
+	return ``
+}
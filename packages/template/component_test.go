@@ -0,0 +1,68 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package template
+
+import (
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/model"
+)
+
+func TestComponent(t *testing.T) {
+	blocks := map[string]string{
+		`Card`: `Component(Title: Default title, Body, Footer)
+			Div(card){Div(card-title, #Title#)Div(card-body){Param(Body)}Param(Footer)}#secret#`,
+		`Badge`: `Component(Text)SetVar(secret, inner)Span(#Text#, badge)`,
+		`Loop`:  `Component(Text)Div(){Loop(#Text#)}`,
+		`Plain`: `Span(not a component)`,
+	}
+	dbBlock := getBlock
+	defer func() {
+		getBlock = dbBlock
+	}()
+	getBlock = func(ecosystem, name string) (*model.BlockInterface, bool, error) {
+		value, ok := blocks[name]
+		return &model.BlockInterface{Name: name, Value: value}, ok, nil
+	}
+	var timeout bool
+	for _, item := range (tplList{
+		{`SetVar(secret, outer)Card(Title: My #secret#){Badge(#secret#)}#secret#`,
+			`[{"tag":"div","attr":{"class":"card"},"children":[{"tag":"div","attr":{"class":"card-title"},"children":[{"tag":"text","text":"My outer"}]},{"tag":"div","attr":{"class":"card-body"},"children":[{"tag":"span","attr":{"class":"badge"},"children":[{"tag":"text","text":"outer"}]}]}]},{"tag":"text"},{"tag":"text","text":"outer"}]`},
+		{`Card(Footer: Badge(Text: Total))`,
+			`[{"tag":"div","attr":{"class":"card"},"children":[{"tag":"div","attr":{"class":"card-title"},"children":[{"tag":"text","text":"Default title"}]},{"tag":"div","attr":{"class":"card-body"}},{"tag":"span","attr":{"class":"badge"},"children":[{"tag":"text","text":"Total"}]}]},{"tag":"text"}]`},
+		{`Loop(test)`,
+			`[{"tag":"div","children":[{"tag":"text","text":"Component Loop calls itself recursively"}]}]`},
+		{`Plain(text)`, `[{"tag":"text","text":"Plain(text)"}]`},
+	}) {
+		vars := map[string]string{`_full`: `0`}
+		templ := Template2JSON(item.input, &timeout, &vars)
+		if string(templ) != item.want {
+			t.Errorf("wrong json \r\n%s != \r\n%s", templ, item.want)
+		}
+	}
+}
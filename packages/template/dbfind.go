@@ -30,10 +30,18 @@ package template
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/AplaProject/go-apla/packages/conf/syspar"
 	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+	"github.com/AplaProject/go-apla/packages/model"
+	"github.com/AplaProject/go-apla/packages/model/querycost"
+	"github.com/AplaProject/go-apla/packages/pagecache"
+	"github.com/AplaProject/go-apla/packages/smart"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -43,6 +51,17 @@ const (
 	columnTypeBlob     = "blob"
 
 	substringLength = 32
+
+	// maxJoins is the maximum count of the tables joined to DBFind
+	maxJoins = 3
+)
+
+var (
+	errAccessDenied   = errors.New(`Access denied`)
+	errJoinLimit      = fmt.Errorf(`DBFind can join no more than %d tables`, maxJoins)
+	errEmptyAggregate = errors.New(`GroupBy or Aggregate must have columns`)
+
+	aggregateFuncs = map[string]bool{`count`: true, `sum`: true, `avg`: true, `min`: true, `max`: true}
 )
 
 func dbfindExpressionBlob(column string) string {
@@ -203,3 +222,297 @@ main:
 	}
 	return ret, i
 }
+
+// dbfindJoin is the table joined to DBFind by the column of the main table which keeps
+// the id of the row of the joined table
+type dbfindJoin struct {
+	Table   string
+	Column  string
+	Columns []string
+}
+
+type joinedTable struct {
+	alias string
+	table string
+	types map[string]string
+}
+
+// dbfindQuery builds the queries of DBFind with GroupBy, Aggregate or Join. The columns are
+// checked against the tables and the read permissions of the columns
+type dbfindQuery struct {
+	sc     *smart.SmartContract
+	table  string
+	types  map[string]string
+	joins  []dbfindJoin
+	tables map[string]*joinedTable
+	refs   map[string][]string
+}
+
+// dbfindList returns the list of the values which are separated by commas or listed in brackets
+func dbfindList(value string) []string {
+	var list []string
+	if strings.HasPrefix(value, `[`) {
+		obj, _ := parseObject([]rune(value))
+		if items, ok := obj.([]interface{}); ok {
+			for _, item := range items {
+				if val, ok := item.(string); ok {
+					list = append(list, val)
+				}
+			}
+			return list
+		}
+	}
+	for _, item := range strings.Split(value, `,`) {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func newDBFindQuery(sc *smart.SmartContract, table string, types map[string]string, joins []dbfindJoin,
+	deps *pagecache.Deps) (*dbfindQuery, error) {
+	if len(joins) > maxJoins {
+		return nil, errJoinLimit
+	}
+	q := &dbfindQuery{sc: sc, table: table, types: types, joins: joins,
+		tables: make(map[string]*joinedTable), refs: make(map[string][]string)}
+	prefix := table[:strings.IndexByte(table, '_')+1]
+	for i, join := range joins {
+		tblname := converter.ParseTable(join.Table, sc.TxSmart.EcosystemID)
+		if !strings.HasPrefix(tblname, prefix) {
+			return nil, fmt.Errorf(`Table %s must belong to the ecosystem of %s`, tblname, table)
+		}
+		alias := tblname[len(prefix):]
+		if _, ok := q.tables[alias]; ok {
+			return nil, fmt.Errorf(`Table %s has been already joined`, alias)
+		}
+		column := strings.ToLower(join.Column)
+		if itype := types[column]; itype != `bigint` && itype != `integer` {
+			return nil, fmt.Errorf(`Column %s must keep the id of %s`, column, alias)
+		}
+		q.refs[table] = append(q.refs[table], column)
+		rows, err := model.GetAllColumnTypes(tblname)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting column types from db")
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, fmt.Errorf(`Table %s has not been found`, tblname)
+		}
+		perm, err := sc.AccessTablePerm(tblname, `read`)
+		if err != nil || (perm != nil && len(perm[`filter`]) > 0) {
+			log.WithFields(log.Fields{"type": consts.AccessDenied, "table": tblname}).Error("joining table")
+			return nil, errAccessDenied
+		}
+		deps.AddTable(tblname)
+		joined := &joinedTable{alias: fmt.Sprintf(`j%d`, i+1), table: tblname,
+			types: make(map[string]string, len(rows))}
+		for _, row := range rows {
+			joined.types[row[columnNameKey]] = row[dataTypeKey]
+		}
+		q.tables[alias] = joined
+	}
+	return q, nil
+}
+
+// column returns the expression of the column of the main table or of the joined table
+// if the name is prefixed by the name of the table
+func (q *dbfindQuery) column(name, mainAlias string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if off := strings.IndexByte(name, '.'); off > 0 {
+		joined := q.tables[name[:off]]
+		column := name[off+1:]
+		if joined == nil || len(joined.types[column]) == 0 {
+			return ``, fmt.Errorf(`Column %s has not been found`, name)
+		}
+		q.refs[joined.table] = append(q.refs[joined.table], column)
+		return fmt.Sprintf(`"%s"."%s"`, joined.alias, column), nil
+	}
+	if len(q.types[name]) == 0 {
+		return ``, fmt.Errorf(`Column %s has not been found`, name)
+	}
+	q.refs[q.table] = append(q.refs[q.table], name)
+	return fmt.Sprintf(`"%s"."%s"`, mainAlias, name), nil
+}
+
+// checkAccess checks that all used columns can be read
+func (q *dbfindQuery) checkAccess() error {
+	for table, columns := range q.refs {
+		list := make([]string, len(columns))
+		copy(list, columns)
+		if err := q.sc.AccessColumns(table, &list, false); err != nil || len(list) != len(columns) {
+			log.WithFields(log.Fields{"type": consts.AccessDenied, "table": table, "columns": columns}).Error("reading columns")
+			return errAccessDenied
+		}
+	}
+	return nil
+}
+
+// joinColumns returns the expressions and the names of the columns of the joined tables. The rows
+// are selected by subqueries so the conditions and the order of the main table are not changed
+func (q *dbfindQuery) joinColumns() (exprs []string, names []string, err error) {
+	for _, join := range q.joins {
+		tblname := converter.ParseTable(join.Table, q.sc.TxSmart.EcosystemID)
+		alias := tblname[strings.IndexByte(tblname, '_')+1:]
+		joined := q.tables[alias]
+		for _, column := range join.Columns {
+			column = strings.ToLower(column)
+			if len(joined.types[column]) == 0 {
+				return nil, nil, fmt.Errorf(`Column %s has not been found`, alias+`.`+column)
+			}
+			q.refs[joined.table] = append(q.refs[joined.table], column)
+			exprs = append(exprs, fmt.Sprintf(`(select "%s" from "%s" where "%[2]s"."id" = "%s"."%s") as "%s.%[1]s"`,
+				column, joined.table, q.table, strings.ToLower(join.Column), alias))
+			names = append(names, alias+`.`+column)
+		}
+	}
+	return exprs, names, q.checkAccess()
+}
+
+// aggregate returns the names of the columns and the query which groups the rows of the main
+// table filtered by where. The order can refer to the grouped columns and the aggregates only
+func (q *dbfindQuery) aggregate(groupBy, aggregates []string, where string, inOrder interface{}) ([]string, string, error) {
+	const mainAlias = `t`
+	var (
+		names, exprs, groups []string
+	)
+	output := make(map[string]bool)
+	addColumn := func(name, expr string) error {
+		if output[name] {
+			return fmt.Errorf(`Duplicate column %s`, name)
+		}
+		output[name] = true
+		names = append(names, name)
+		exprs = append(exprs, fmt.Sprintf(`%s as "%s"`, expr, name))
+		return nil
+	}
+	for _, name := range groupBy {
+		expr, err := q.column(name, mainAlias)
+		if err != nil {
+			return nil, ``, err
+		}
+		if err = addColumn(strings.ToLower(strings.TrimSpace(name)), expr); err != nil {
+			return nil, ``, err
+		}
+		groups = append(groups, expr)
+	}
+	for _, item := range aggregates {
+		off := strings.IndexByte(item, '=')
+		lpar := strings.IndexByte(item, '(')
+		if off <= 0 || lpar < off || !strings.HasSuffix(item, `)`) {
+			return nil, ``, fmt.Errorf(`Wrong aggregate %s`, item)
+		}
+		name := converter.Sanitize(strings.ToLower(strings.TrimSpace(item[:off])), ``)
+		fn := strings.ToLower(strings.TrimSpace(item[off+1 : lpar]))
+		arg := strings.TrimSpace(item[lpar+1 : len(item)-1])
+		if len(name) == 0 || !aggregateFuncs[fn] {
+			return nil, ``, fmt.Errorf(`Wrong aggregate %s`, item)
+		}
+		expr := `*`
+		if arg != `*` || fn != `count` {
+			var err error
+			if expr, err = q.column(arg, mainAlias); err != nil {
+				return nil, ``, err
+			}
+		}
+		if err := addColumn(name, fmt.Sprintf(`%s(%s)`, fn, expr)); err != nil {
+			return nil, ``, err
+		}
+	}
+	if len(names) == 0 {
+		return nil, ``, errEmptyAggregate
+	}
+	if err := q.checkAccess(); err != nil {
+		return nil, ``, err
+	}
+	order, err := outputOrder(inOrder, output)
+	if err != nil {
+		return nil, ``, err
+	}
+	if len(order) == 0 && len(groupBy) > 0 {
+		order = `"` + strings.Join(names[:len(groupBy)], `","`) + `"`
+	}
+	query := fmt.Sprintf(`select %s from (select * from "%s"%s) as "%s"`, strings.Join(exprs, `, `),
+		q.table, where, mainAlias)
+	for _, join := range q.joins {
+		tblname := converter.ParseTable(join.Table, q.sc.TxSmart.EcosystemID)
+		joined := q.tables[tblname[strings.IndexByte(tblname, '_')+1:]]
+		query += fmt.Sprintf(` left join "%s" as "%s" on "%[2]s"."id" = "%s"."%s"`, joined.table,
+			joined.alias, mainAlias, strings.ToLower(join.Column))
+	}
+	if len(groups) > 0 {
+		query += ` group by ` + strings.Join(groups, `, `)
+	}
+	if len(order) > 0 {
+		query += ` order by ` + order
+	}
+	return names, query, nil
+}
+
+// outputOrder returns the order by the columns of the result
+func outputOrder(inOrder interface{}, output map[string]bool) (string, error) {
+	var orders []string
+	add := func(name string, value interface{}) error {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			return nil
+		}
+		if !output[name] {
+			return fmt.Errorf(`Column %s has not been found`, name)
+		}
+		name = `"` + name + `"`
+		if fmt.Sprint(value) == `-1` {
+			name += ` desc`
+		}
+		orders = append(orders, name)
+		return nil
+	}
+	switch v := inOrder.(type) {
+	case string:
+		for _, name := range strings.Split(v, `,`) {
+			if err := add(name, nil); err != nil {
+				return ``, err
+			}
+		}
+	case map[string]interface{}:
+		for name, value := range v {
+			if err := add(name, value); err != nil {
+				return ``, err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			switch param := item.(type) {
+			case string:
+				if err := add(param, nil); err != nil {
+					return ``, err
+				}
+			case map[string]interface{}:
+				for name, value := range param {
+					if err := add(name, value); err != nil {
+						return ``, err
+					}
+				}
+			}
+		}
+	}
+	return strings.Join(orders, `, `), nil
+}
+
+// checkQueryCost returns an error if the cost of the query exceeds max_dbfind_cost
+func checkQueryCost(query string) error {
+	maxCost := syspar.GetMaxDBFindCost()
+	if maxCost <= 0 {
+		return nil
+	}
+	cost, err := querycost.GetQueryCoster(querycost.ExplainQueryCosterType).QueryCost(nil, query)
+	if err != nil {
+		return err
+	}
+	if cost > maxCost {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "cost": cost, "query": query}).Error("dbfind query cost")
+		return fmt.Errorf(`Query cost %d exceeds max_dbfind_cost %d`, cost, maxCost)
+	}
+	return nil
+}
@@ -67,6 +67,10 @@ var (
 const (
 	columnNameKey = "column_name"
 	dataTypeKey   = "data_type"
+
+	// the tables which keep language resources and the descriptions of tables
+	langTable   = `1_languages`
+	tablesTable = `1_tables`
 )
 
 func init() {
@@ -105,6 +109,7 @@ func init() {
 	funcs[`Form`] = tplFunc{defaultTailTag, defaultTailTag, `form`, `Class,Body`}
 	funcs[`If`] = tplFunc{ifTag, ifFull, `if`, `Condition,Body`}
 	funcs[`Image`] = tplFunc{imageTag, defaultTailTag, `image`, `Src,Alt,Class`}
+	funcs[`Component`] = tplFunc{componentDeclTag, defaultTag, `params`, `*`}
 	funcs[`Include`] = tplFunc{includeTag, defaultTag, `include`, `Name`}
 	funcs[`Input`] = tplFunc{defaultTailTag, defaultTailTag, `input`, `Name,Class,Placeholder,Type,Value,Disabled`}
 	funcs[`Label`] = tplFunc{defaultTailTag, defaultTailTag, `label`, `Body,Class,For`}
@@ -113,6 +118,7 @@ func init() {
 	funcs[`DBFind`] = tplFunc{dbfindTag, defaultTailTag, `dbfind`, `Name,Source`}
 	funcs[`And`] = tplFunc{andTag, defaultTag, `and`, `*`}
 	funcs[`Or`] = tplFunc{orTag, defaultTag, `or`, `*`}
+	funcs[`Param`] = tplFunc{paramTag, defaultTag, `param`, `Name`}
 	funcs[`P`] = tplFunc{defaultTailTag, defaultTailTag, `p`, `Body,Class`}
 	funcs[`RadioGroup`] = tplFunc{defaultTailTag, defaultTailTag, `radiogroup`, `Name,Source,NameColumn,ValueColumn,Value,Class`}
 	funcs[`Span`] = tplFunc{defaultTailTag, defaultTailTag, `span`, `Body,Class`}
@@ -137,14 +143,20 @@ func init() {
 		`CompositeContract`: {tplFunc{compositeTag, defaultTailFull, `composite`, `Name,Data`}, false},
 		`ErrorRedirect`: {tplFunc{errredirTag, defaultTailFull, `errorredirect`,
 			`ErrorID,PageName,PageParams`}, false},
+		`Show`:    {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:    {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
+		`Refresh`: {tplFunc{refreshTag, defaultTailFull, `refresh`, `Source`}, false},
 	}}
 	tails[`div`] = forTails{map[string]tailInfo{
-		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
-		`Show`:  {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
-		`Hide`:  {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
+		`Style`:     {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:      {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:      {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
+		`Calculate`: {tplFunc{bindCalculateTag, defaultTailFull, `calculate`, `Exp,Type,Prec`}, false},
 	}}
 	tails[`form`] = forTails{map[string]tailInfo{
 		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:  {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:  {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
 	}}
 	tails[`if`] = forTails{map[string]tailInfo{
 		`Else`:   {tplFunc{elseTag, elseFull, `else`, `Body`}, true},
@@ -152,48 +164,73 @@ func init() {
 	}}
 	tails[`image`] = forTails{map[string]tailInfo{
 		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:  {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:  {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
 	}}
 	tails[`input`] = forTails{map[string]tailInfo{
-		`Validate`: {tplFunc{validateTag, validateFull, `validate`, `*`}, false},
-		`Style`:    {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Validate`:  {tplFunc{validateTag, validateFull, `validate`, `*`}, false},
+		`Style`:     {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:      {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:      {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
+		`Calculate`: {tplFunc{bindCalculateTag, defaultTailFull, `calculate`, `Exp,Type,Prec`}, false},
 	}}
 	tails[`label`] = forTails{map[string]tailInfo{
-		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Style`:     {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:      {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:      {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
+		`Calculate`: {tplFunc{bindCalculateTag, defaultTailFull, `calculate`, `Exp,Type,Prec`}, false},
 	}}
 	tails[`linkpage`] = forTails{map[string]tailInfo{
 		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:  {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:  {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
 	}}
 	tails[`data`] = forTails{map[string]tailInfo{
 		`Custom`: {tplFunc{customTag, customTagFull, `custom`, `Column,Body`}, false},
 	}}
 	tails[`dbfind`] = forTails{map[string]tailInfo{
-		`Columns`: {tplFunc{tailTag, defaultTailFull, `columns`, `Columns`}, false},
-		`Count`:   {tplFunc{tailTag, defaultTailFull, `count`, `CountVar`}, false},
-		`Where`:   {tplFunc{tailTag, defaultTailFull, `where`, `Where`}, false},
-		`WhereId`: {tplFunc{tailTag, defaultTailFull, `whereid`, `WhereId`}, false},
-		`Order`:   {tplFunc{tailTag, defaultTailFull, `order`, `Order`}, false},
-		`Limit`:   {tplFunc{tailTag, defaultTailFull, `limit`, `Limit`}, false},
-		`Offset`:  {tplFunc{tailTag, defaultTailFull, `offset`, `Offset`}, false},
-		`Custom`:  {tplFunc{customTag, customTagFull, `custom`, `Column,Body`}, false},
-		`Vars`:    {tplFunc{tailTag, defaultTailFull, `vars`, `Prefix`}, false},
-		`Cutoff`:  {tplFunc{tailTag, defaultTailFull, `cutoff`, `Cutoff`}, false},
+		`Columns`:   {tplFunc{tailTag, defaultTailFull, `columns`, `Columns`}, false},
+		`Count`:     {tplFunc{tailTag, defaultTailFull, `count`, `CountVar`}, false},
+		`Where`:     {tplFunc{tailTag, defaultTailFull, `where`, `Where`}, false},
+		`WhereId`:   {tplFunc{tailTag, defaultTailFull, `whereid`, `WhereId`}, false},
+		`Order`:     {tplFunc{tailTag, defaultTailFull, `order`, `Order`}, false},
+		`Limit`:     {tplFunc{tailTag, defaultTailFull, `limit`, `Limit`}, false},
+		`Offset`:    {tplFunc{tailTag, defaultTailFull, `offset`, `Offset`}, false},
+		`Custom`:    {tplFunc{customTag, customTagFull, `custom`, `Column,Body`}, false},
+		`Vars`:      {tplFunc{tailTag, defaultTailFull, `vars`, `Prefix`}, false},
+		`Cutoff`:    {tplFunc{tailTag, defaultTailFull, `cutoff`, `Cutoff`}, false},
+		`GroupBy`:   {tplFunc{tailTag, defaultTailFull, `groupby`, `GroupBy`}, false},
+		`Aggregate`: {tplFunc{tailTag, defaultTailFull, `aggregate`, `Aggregate`}, false},
+		`Join`:      {tplFunc{joinTag, defaultTailFull, `join`, `Table,Column,Columns`}, false},
 	}}
 	tails[`p`] = forTails{map[string]tailInfo{
-		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Style`:     {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:      {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:      {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
+		`Calculate`: {tplFunc{bindCalculateTag, defaultTailFull, `calculate`, `Exp,Type,Prec`}, false},
 	}}
 	tails[`radiogroup`] = forTails{map[string]tailInfo{
 		`Validate`: {tplFunc{validateTag, validateFull, `validate`, `*`}, false},
 		`Style`:    {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:     {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:     {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
 	}}
 	tails[`span`] = forTails{map[string]tailInfo{
-		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Style`:     {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:      {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:      {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
+		`Calculate`: {tplFunc{bindCalculateTag, defaultTailFull, `calculate`, `Exp,Type,Prec`}, false},
 	}}
 	tails[`table`] = forTails{map[string]tailInfo{
 		`Style`: {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:  {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:  {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
 	}}
 	tails[`select`] = forTails{map[string]tailInfo{
 		`Validate`: {tplFunc{validateTag, validateFull, `validate`, `*`}, false},
 		`Style`:    {tplFunc{tailTag, defaultTailFull, `style`, `Style`}, false},
+		`Show`:     {tplFunc{showTag, defaultTailFull, `show`, `Condition`}, false},
+		`Hide`:     {tplFunc{hideTag, defaultTailFull, `hide`, `Condition`}, false},
 	}}
 	tails[`inputMap`] = forTails{map[string]tailInfo{
 		`Validate`: {tplFunc{validateTag, validateFull, `validate`, `*`}, false},
@@ -345,6 +382,7 @@ func paramToSource(par parFunc, val string) string {
 	data := make([][]string, 0)
 	cols := []string{`id`, `name`}
 	types := []string{`text`, `text`}
+	par.Workspace.deps.AddTable(langTable)
 	for key, item := range strings.Split(val, `,`) {
 		item, _ = language.LangText(item,
 			converter.StrToInt(getVar(par.Workspace, `ecosystem_id`)), getVar(par.Workspace, `lang`))
@@ -365,6 +403,7 @@ func paramToSource(par parFunc, val string) string {
 func paramToIndex(par parFunc, val string) (ret string) {
 	ind := converter.StrToInt(macro((*par.Pars)[`Index`], par.Workspace.Vars))
 	if alist := strings.Split(val, `,`); ind > 0 && len(alist) >= ind {
+		par.Workspace.deps.AddTable(langTable)
 		ret, _ = language.LangText(alist[ind-1],
 			converter.StrToInt(getVar(par.Workspace, `ecosystem_id`)),
 			getVar(par.Workspace, `lang`))
@@ -383,11 +422,16 @@ func ecosysparTag(par parFunc) string {
 	sp := &model.StateParameter{}
 	sp.SetTablePrefix(ecosystem)
 	parameterName := macro((*par.Pars)[`Name`], par.Workspace.Vars)
-	_, err := sp.Get(nil, parameterName)
+	found, err := sp.Get(nil, parameterName)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting ecosystem param")
 		return err.Error()
 	}
+	if found {
+		par.Workspace.deps.AddRow(sp.TableName(), sp.ID)
+	} else {
+		par.Workspace.deps.AddTable(sp.TableName())
+	}
 	val := sp.Value
 	if len((*par.Pars)[`Source`]) > 0 {
 		return paramToSource(par, val)
@@ -408,12 +452,17 @@ func appparTag(par parFunc) string {
 	}
 	ap := &model.AppParam{}
 	ap.SetTablePrefix(ecosystem)
-	_, err := ap.Get(nil, converter.StrToInt64(macro((*par.Pars)[`App`], par.Workspace.Vars)),
+	found, err := ap.Get(nil, converter.StrToInt64(macro((*par.Pars)[`App`], par.Workspace.Vars)),
 		macro((*par.Pars)[`Name`], par.Workspace.Vars))
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting app param")
 		return err.Error()
 	}
+	if found {
+		par.Workspace.deps.AddRow(ap.TableName(), ap.ID)
+	} else {
+		par.Workspace.deps.AddTable(ap.TableName())
+	}
 	val := ap.Value
 	if len((*par.Pars)[`Source`]) > 0 {
 		return paramToSource(par, val)
@@ -429,6 +478,7 @@ func langresTag(par parFunc) string {
 	if len(lang) == 0 {
 		lang = getVar(par.Workspace, `lang`)
 	}
+	par.Workspace.deps.AddTable(langTable)
 	ret, _ := language.LangText((*par.Pars)[`Name`],
 		int(converter.StrToInt64(getVar(par.Workspace, `ecosystem_id`))), lang)
 	return ret
@@ -436,6 +486,7 @@ func langresTag(par parFunc) string {
 
 func sysparTag(par parFunc) (ret string) {
 	if len((*par.Pars)[`Name`]) > 0 {
+		par.Workspace.deps.AddTable(model.SystemParameter{}.TableName())
 		ret = syspar.SysString(macro((*par.Pars)[`Name`], par.Workspace.Vars))
 	}
 	return
@@ -477,6 +528,8 @@ func txinfoTag(par parFunc) (out string) {
 	setAllAttr(par)
 	if par.Node.Attr[`hash`] != nil {
 		var err error
+		// the status of transaction is not kept in the tables which are tracked by blocks
+		par.Workspace.deps.SetVolatile()
 		out, err = smart.TransactionInfo(par.Node.Attr[`hash`].(string))
 		if err != nil {
 			out = err.Error()
@@ -648,6 +701,8 @@ func dbfindTag(par parFunc) string {
 	sc := par.Workspace.SmartContract
 	tblname := converter.ParseTable(strings.Trim(macro((*par.Pars)[`Name`], par.Workspace.Vars), `"`), state)
 	tblname = strings.ToLower(tblname)
+	par.Workspace.deps.AddTable(tblname)
+	par.Workspace.deps.AddTable(tablesTable)
 
 	inColumns = ``
 	if par.Node.Attr[`order`] != nil {
@@ -658,11 +713,15 @@ func dbfindTag(par parFunc) string {
 			inColumns = order
 		}
 	}
-	order, err = smart.GetOrder(tblname, inColumns)
-	if err != nil {
-		return err.Error()
+	aggregate := par.Node.Attr[`groupby`] != nil || par.Node.Attr[`aggregate`] != nil
+	inOrder := inColumns
+	if !aggregate {
+		order, err = smart.GetOrder(tblname, inColumns)
+		if err != nil {
+			return err.Error()
+		}
+		order = ` order by ` + order
 	}
-	order = ` order by ` + order
 
 	rows, err := model.GetAllColumnTypes(tblname)
 	if err != nil {
@@ -678,7 +737,7 @@ func dbfindTag(par parFunc) string {
 	perm, err = sc.AccessTablePerm(tblname, `read`)
 	if err != nil || sc.AccessColumns(tblname, &columns, false) != nil {
 		log.WithFields(log.Fields{"table": tblname, "columns": columns}).Error("ACCESS DENIED")
-		return `Access denied`
+		return errAccessDenied.Error()
 	}
 
 	if utils.StringInSlice(columns, `*`) {
@@ -721,9 +780,66 @@ func dbfindTag(par parFunc) string {
 		}
 		columnNames[i] = strings.TrimSpace(columnNames[i])
 	}
+	var (
+		query string
+		joins []dbfindJoin
+	)
+	if par.Node.Attr[`joins`] != nil {
+		joins = par.Node.Attr[`joins`].([]dbfindJoin)
+	}
+	if aggregate || len(joins) > 0 {
+		dbq, err := newDBFindQuery(sc, tblname, columnTypes, joins, par.Workspace.deps)
+		if err != nil {
+			return err.Error()
+		}
+		if aggregate {
+			if perm != nil && len(perm[`filter`]) > 0 {
+				return errAccessDenied.Error()
+			}
+			var groupBy, aggregates []string
+			if par.Node.Attr[`groupby`] != nil {
+				groupBy = dbfindList(macro(par.Node.Attr[`groupby`].(string), par.Workspace.Vars))
+			}
+			if par.Node.Attr[`aggregate`] != nil {
+				aggregates = dbfindList(macro(par.Node.Attr[`aggregate`].(string), par.Workspace.Vars))
+			}
+			if len(where) > 0 {
+				where = ` where ` + where
+			}
+			columnNames, query, err = dbq.aggregate(groupBy, aggregates, where, inOrder)
+			if err != nil {
+				return err.Error()
+			}
+			extendedColumns = make(map[string]string)
+		} else {
+			exprs, names, err := dbq.joinColumns()
+			if err != nil {
+				return err.Error()
+			}
+			queryColumns = append(queryColumns, exprs...)
+			columnNames = append(columnNames, names...)
+		}
+	}
+	if !aggregate {
+		var filter string
+		if len(where) > 0 {
+			filter = ` where ` + where
+		}
+		query = `select ` + strings.Join(queryColumns, `, `) + ` from "` + tblname + `"` + filter + order
+	}
+	// the cost is checked before the count so that the expensive query isn't run at all
+	if aggregate || len(joins) > 0 {
+		if err = checkQueryCost(query + offset + fmt.Sprintf(` limit %d`, limit)); err != nil {
+			return err.Error()
+		}
+	}
 	if par.Node.Attr[`countvar`] != nil {
 		var count int64
-		err = model.GetDB(nil).Table(tblname).Where(where).Count(&count).Error
+		if aggregate {
+			count, err = model.Single(nil, `select count(*) from (`+query+`) as "c"`).Int64()
+		} else {
+			err = model.GetDB(nil).Table(tblname).Where(where).Count(&count).Error
+		}
 		if err != nil {
 			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("selecting count from table in DBFind")
 		}
@@ -732,11 +848,8 @@ func dbfindTag(par parFunc) string {
 		setVar(par.Workspace, par.Node.Attr[`countvar`].(string), countStr)
 		delete(par.Node.Attr, `countvar`)
 	}
-	if len(where) > 0 {
-		where = ` where ` + where
-	}
-	list, err := model.GetAll(`select `+strings.Join(queryColumns, `, `)+` from "`+tblname+`"`+
-		where+order+offset, limit)
+	query += offset
+	list, err := model.GetAll(query, limit)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting all from db")
 		return err.Error()
@@ -834,7 +947,7 @@ func dbfindTag(par parFunc) string {
 				`key_id`:       sc.TxSmart.KeyID, `sc`: sc,
 				`block_time`: 0, `time`: sc.TxSmart.Time})
 		if err != nil || !fltResult {
-			return `Access denied`
+			return errAccessDenied.Error()
 		}
 		for i := range data {
 			for j, col := range columnNames {
@@ -846,6 +959,7 @@ func dbfindTag(par parFunc) string {
 	delete(par.Node.Attr, `customs`)
 	delete(par.Node.Attr, `custombody`)
 	delete(par.Node.Attr, `prefix`)
+	delete(par.Node.Attr, `joins`)
 	par.Node.Attr[`columns`] = &columnNames
 	par.Node.Attr[`types`] = &types
 	par.Node.Attr[`data`] = &data
@@ -916,6 +1030,22 @@ func customTagFull(par parFunc) string {
 	return ``
 }
 
+func joinTag(par parFunc) string {
+	if len((*par.Pars)[`Table`]) == 0 || len((*par.Pars)[`Column`]) == 0 {
+		return ``
+	}
+	var joins []dbfindJoin
+	if par.Owner.Attr[`joins`] != nil {
+		joins = par.Owner.Attr[`joins`].([]dbfindJoin)
+	}
+	par.Owner.Attr[`joins`] = append(joins, dbfindJoin{
+		Table:   macro((*par.Pars)[`Table`], par.Workspace.Vars),
+		Column:  macro((*par.Pars)[`Column`], par.Workspace.Vars),
+		Columns: dbfindList(macro((*par.Pars)[`Columns`], par.Workspace.Vars)),
+	})
+	return ``
+}
+
 func tailTag(par parFunc) string {
 	setAllAttr(par)
 	for key, v := range par.Node.Attr {
@@ -978,9 +1108,11 @@ func includeTag(par parFunc) string {
 			return err.Error()
 		}
 		if !found {
+			par.Workspace.deps.AddTable(bi.TableName())
 			log.WithFields(log.Fields{"type": consts.NotFound, "name": (*par.Pars)[`Name`]}).Error("include block not found")
 			return fmt.Sprintf("Inlcude %s has not been found", (*par.Pars)[`Name`])
 		}
+		par.Workspace.deps.AddRow(bi.TableName(), bi.ID)
 		if len(bi.Value) > 0 {
 			root := node{}
 			setVar(par.Workspace, `_include`, getVar(par.Workspace, `_include`)+`1`)
@@ -1191,6 +1323,7 @@ func dateTimeTag(par parFunc) string {
 	}
 	format := (*par.Pars)[`Format`]
 	if len(format) == 0 {
+		par.Workspace.deps.AddTable(langTable)
 		format, _ = language.LangText(`timeformat`,
 			converter.StrToInt(getVar(par.Workspace, `ecosystem_id`)), getVar(par.Workspace, `lang`))
 		if format == `timeformat` {
@@ -1372,6 +1505,7 @@ func binaryTag(par parFunc) string {
 	}
 	binary := &model.Binary{}
 	binary.SetTablePrefix(ecosystemID)
+	par.Workspace.deps.AddTable(binary.TableName())
 
 	var (
 		ok  bool
@@ -1405,6 +1539,7 @@ func columntypeTag(par parFunc) string {
 		tableName := macro((*par.Pars)[`Table`], par.Workspace.Vars)
 		columnName := macro((*par.Pars)[`Column`], par.Workspace.Vars)
 		tblname := smart.GetTableName(par.Workspace.SmartContract, tableName)
+		par.Workspace.deps.AddTable(tablesTable)
 		colType, err := model.GetColumnType(tblname, columnName)
 		if err == nil {
 			return colType
@@ -1424,6 +1559,8 @@ func getHistoryTag(par parFunc) string {
 		return ``
 	}
 	table := macro((*par.Pars)["Name"], par.Workspace.Vars)
+	par.Workspace.deps.AddTable(getVar(par.Workspace, `ecosystem_id`) + "_" + table)
+	par.Workspace.deps.AddTable(tablesTable)
 	list, err := smart.GetHistoryRaw(nil, converter.StrToInt64(getVar(par.Workspace, `ecosystem_id`)),
 		table, converter.StrToInt64(macro((*par.Pars)[`Id`], par.Workspace.Vars)), rollID)
 	if err != nil {
@@ -0,0 +1,499 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/pagecache"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ClassesDefault is the name of the mapping which keeps the classes of the templates
+	ClassesDefault = `default`
+	// ClassesBootstrap is the name of the mapping to the classes of Bootstrap
+	ClassesBootstrap = `bootstrap`
+)
+
+// ClassMapper converts the tag and the classes of the template element to CSS classes of HTML
+type ClassMapper interface {
+	Class(tag, class string) string
+}
+
+// ClassMap is ClassMapper which is defined by the keys similar to CSS selectors. The value of
+// the key "tag" is added to all elements of the tag, the values of the keys ".class" and
+// "tag.class" replace the class of the template
+type ClassMap map[string]string
+
+// Class returns CSS classes of the element
+func (cm ClassMap) Class(tag, class string) string {
+	list := make([]string, 0, 4)
+	if v := cm[tag]; len(v) > 0 {
+		list = append(list, v)
+	}
+	for _, item := range strings.Fields(class) {
+		if v, ok := cm[tag+`.`+item]; ok {
+			item = v
+		} else if v, ok := cm[`.`+item]; ok {
+			item = v
+		}
+		if len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+	return strings.Join(list, ` `)
+}
+
+var (
+	classMappers = map[string]ClassMapper{
+		ClassesDefault: ClassMap{},
+		ClassesBootstrap: ClassMap{
+			`table`:      `table`,
+			`button`:     `btn btn-default`,
+			`input`:      `form-control`,
+			`select`:     `form-control`,
+			`image`:      `img-responsive`,
+			`radiogroup`: `radio`,
+			`hint`:       `text-muted`,
+		},
+	}
+	classMutex = &sync.RWMutex{}
+)
+
+// RegisterClassMapper adds the mapping of classes which can be chosen by the name
+func RegisterClassMapper(name string, mapper ClassMapper) {
+	classMutex.Lock()
+	defer classMutex.Unlock()
+	classMappers[name] = mapper
+}
+
+// GetClassMapper returns the mapping of classes by the name or nil if it isn't registered
+func GetClassMapper(name string) ClassMapper {
+	classMutex.RLock()
+	defer classMutex.RUnlock()
+	return classMappers[name]
+}
+
+// HTMLOptions are the settings of the HTML renderer
+type HTMLOptions struct {
+	// Classes maps the classes of the templates to CSS classes
+	Classes ClassMapper
+	// PageLink returns the link to the page with the parameters. By default it is the name
+	// of the page with the query of the parameters
+	PageLink func(page string, params url.Values) string
+	// Deps collects the tables and rows which have been read while rendering
+	Deps *pagecache.Deps
+}
+
+type htmlRenderer struct {
+	HTMLOptions
+	out     bytes.Buffer
+	sources map[string]*node
+}
+
+// Template2HTML converts templates to HTML. Data sources and client-side actions aren't
+// rendered, inline styles are omitted so the look is defined by the mapping of classes
+func Template2HTML(input string, timeout *bool, vars *map[string]string, options HTMLOptions) []byte {
+	children := buildTree(input, timeout, vars, options.Deps, nil)
+	if children == nil {
+		return []byte{}
+	}
+	return renderHTML(children, options)
+}
+
+func renderHTML(children []*node, options HTMLOptions) []byte {
+	if options.Classes == nil {
+		options.Classes = ClassMap{}
+	}
+	r := &htmlRenderer{HTMLOptions: options, sources: make(map[string]*node)}
+	r.collect(children)
+	r.nodes(children)
+	return r.out.Bytes()
+}
+
+// collect finds the nodes of data sources
+func (r *htmlRenderer) collect(children []*node) {
+	for _, n := range children {
+		if name, ok := n.Attr[`source`].(string); ok && n.Attr[`data`] != nil {
+			r.sources[name] = n
+		}
+		r.collect(n.Children)
+	}
+}
+
+func attrString(n *node, name string) string {
+	if v, ok := n.Attr[name].(string); ok {
+		return v
+	}
+	return ``
+}
+
+func isTrue(val string) bool {
+	return len(val) > 0 && val != `0` && val != `false`
+}
+
+// safeURL returns # for the links with the schemes which can execute scripts
+func safeURL(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return `#`
+	}
+	switch strings.ToLower(u.Scheme) {
+	case ``, `http`, `https`, `mailto`:
+		return link
+	case `data`:
+		if strings.HasPrefix(strings.ToLower(u.Opaque), `image/`) {
+			return link
+		}
+	}
+	return `#`
+}
+
+func (r *htmlRenderer) pageURL(n *node) string {
+	page := attrString(n, `page`)
+	if len(page) == 0 {
+		return `#`
+	}
+	query := url.Values{}
+	if params, ok := n.Attr[`pageparams`].(map[string]interface{}); ok {
+		for key, v := range params {
+			if par, ok := v.(map[string]interface{}); ok && par[`type`] == `text` {
+				if text, ok := par[`text`].(string); ok {
+					query.Set(key, text)
+				}
+			}
+		}
+	}
+	if r.PageLink != nil {
+		return r.PageLink(page, query)
+	}
+	link := url.PathEscape(page)
+	if len(query) > 0 {
+		link += `?` + query.Encode()
+	}
+	return link
+}
+
+func (r *htmlRenderer) text(s string) {
+	r.out.WriteString(html.EscapeString(s))
+}
+
+// open writes the start tag with the mapped class and the pairs of attributes.
+// The attributes with empty values are skipped except alt
+func (r *htmlRenderer) open(tag string, n *node, attrs ...string) {
+	r.out.WriteString(`<` + tag)
+	if class := r.Classes.Class(n.Tag, attrString(n, `class`)); len(class) > 0 {
+		r.out.WriteString(` class="` + html.EscapeString(class) + `"`)
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if len(attrs[i+1]) == 0 && attrs[i] != `alt` {
+			continue
+		}
+		r.out.WriteString(` ` + attrs[i] + `="` + html.EscapeString(attrs[i+1]) + `"`)
+	}
+	r.out.WriteString(`>`)
+}
+
+func (r *htmlRenderer) close(tag string) {
+	r.out.WriteString(`</` + tag + `>`)
+}
+
+func (r *htmlRenderer) element(tag string, n *node, attrs ...string) {
+	r.open(tag, n, attrs...)
+	r.nodes(n.Children)
+	r.close(tag)
+}
+
+func (r *htmlRenderer) nodes(children []*node) {
+	for _, n := range children {
+		r.node(n)
+	}
+}
+
+func (r *htmlRenderer) node(n *node) {
+	switch n.Tag {
+	case tagText:
+		r.text(n.Text)
+	case `div`, `p`, `span`, `strong`, `em`, `form`:
+		r.element(n.Tag, n)
+	case `if`, `elseif`, `else`:
+		r.nodes(n.Children)
+	case `label`:
+		r.element(`label`, n, `for`, attrString(n, `for`))
+	case `code`, `qrcode`:
+		r.open(`code`, n)
+		r.text(attrString(n, `text`))
+		r.close(`code`)
+	case `settitle`:
+		r.open(`h1`, n)
+		r.text(attrString(n, `title`))
+		r.close(`h1`)
+	case `hint`:
+		r.open(`span`, n, `title`, attrString(n, `text`))
+		r.text(attrString(n, `title`))
+		r.close(`span`)
+	case `linkpage`:
+		r.element(`a`, n, `href`, r.pageURL(n))
+	case `button`:
+		if len(attrString(n, `contract`)) == 0 && len(attrString(n, `page`)) > 0 {
+			r.element(`a`, n, `href`, r.pageURL(n), `role`, `button`)
+		} else {
+			r.element(`button`, n, `type`, `button`, `data-contract`, attrString(n, `contract`),
+				`data-page`, attrString(n, `page`))
+		}
+	case `image`:
+		r.open(`img`, n, `src`, safeURL(attrString(n, `src`)), `alt`, attrString(n, `alt`))
+	case `input`:
+		r.input(n)
+	case `select`:
+		r.selectList(n)
+	case `radiogroup`:
+		r.radioGroup(n)
+	case `table`:
+		r.table(n)
+	case `chart`:
+		r.chart(n)
+	case `menuitem`:
+		r.open(`a`, n, `href`, r.pageURL(n))
+		r.text(attrString(n, `title`))
+		r.close(`a`)
+	case `menugroup`:
+		title := attrString(n, `title`)
+		r.open(`div`, n, `role`, `group`, `aria-label`, title)
+		r.out.WriteString(`<span>`)
+		r.text(title)
+		r.out.WriteString(`</span>`)
+		r.nodes(n.Children)
+		r.close(`div`)
+	default:
+		if len(n.Children) > 0 {
+			r.element(`div`, n)
+		}
+	}
+}
+
+func (r *htmlRenderer) input(n *node) {
+	name := attrString(n, `name`)
+	attrs := []string{`id`, name, `name`, name, `placeholder`, attrString(n, `placeholder`)}
+	if isTrue(attrString(n, `disabled`)) {
+		attrs = append(attrs, `disabled`, `disabled`)
+	}
+	if validate, ok := n.Attr[`validate`].(map[string]interface{}); ok {
+		if v, ok := validate[`required`].(string); ok && isTrue(v) {
+			attrs = append(attrs, `required`, `required`, `aria-required`, `true`)
+		}
+		for _, key := range []string{`minlength`, `maxlength`} {
+			if v, ok := validate[key].(string); ok {
+				attrs = append(attrs, key, v)
+			}
+		}
+	}
+	itype := attrString(n, `type`)
+	if itype == `textarea` {
+		r.open(`textarea`, n, attrs...)
+		r.text(attrString(n, `value`))
+		r.close(`textarea`)
+		return
+	}
+	if len(itype) == 0 {
+		itype = `text`
+	}
+	r.open(`input`, n, append(attrs, `type`, itype, `value`, attrString(n, `value`))...)
+}
+
+// source returns the columns, the types and the rows of the data source
+func (r *htmlRenderer) source(name string) (cols, types []string, data [][]string) {
+	src := r.sources[name]
+	if src == nil {
+		return
+	}
+	if v, ok := src.Attr[`columns`].(*[]string); ok {
+		cols = *v
+	}
+	if v, ok := src.Attr[`types`].(*[]string); ok {
+		types = *v
+	}
+	if v, ok := src.Attr[`data`].(*[][]string); ok {
+		data = *v
+	}
+	return
+}
+
+func columnIndex(cols []string, name string) int {
+	for i, col := range cols {
+		if col == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// options returns the pairs of the values and the names of the items of the list
+func (r *htmlRenderer) options(n *node) [][2]string {
+	cols, _, data := r.source(attrString(n, `source`))
+	nameCol := columnIndex(cols, attrString(n, `namecolumn`))
+	valueCol := columnIndex(cols, attrString(n, `valuecolumn`))
+	if valueCol < 0 {
+		valueCol = nameCol
+	}
+	items := make([][2]string, 0, len(data))
+	if nameCol < 0 {
+		return items
+	}
+	for _, row := range data {
+		if nameCol < len(row) && valueCol < len(row) {
+			items = append(items, [2]string{row[valueCol], row[nameCol]})
+		}
+	}
+	return items
+}
+
+func (r *htmlRenderer) selectList(n *node) {
+	name := attrString(n, `name`)
+	value := attrString(n, `value`)
+	r.open(`select`, n, `id`, name, `name`, name)
+	for _, item := range r.options(n) {
+		r.out.WriteString(`<option value="` + html.EscapeString(item[0]) + `"`)
+		if item[0] == value {
+			r.out.WriteString(` selected`)
+		}
+		r.out.WriteString(`>`)
+		r.text(item[1])
+		r.out.WriteString(`</option>`)
+	}
+	r.close(`select`)
+}
+
+func (r *htmlRenderer) radioGroup(n *node) {
+	name := attrString(n, `name`)
+	value := attrString(n, `value`)
+	r.open(`fieldset`, n, `id`, name)
+	for _, item := range r.options(n) {
+		r.out.WriteString(`<label><input type="radio" name="` + html.EscapeString(name) +
+			`" value="` + html.EscapeString(item[0]) + `"`)
+		if item[0] == value {
+			r.out.WriteString(` checked`)
+		}
+		r.out.WriteString(`> `)
+		r.text(item[1])
+		r.out.WriteString(`</label>`)
+	}
+	r.close(`fieldset`)
+}
+
+func (r *htmlRenderer) cell(ctype, value string) {
+	if ctype == `tags` {
+		var children []*node
+		if err := json.Unmarshal([]byte(value), &children); err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling custom column")
+			return
+		}
+		r.nodes(children)
+		return
+	}
+	r.text(value)
+}
+
+func (r *htmlRenderer) headers(titles []string) {
+	r.out.WriteString(`<thead><tr>`)
+	for _, title := range titles {
+		r.out.WriteString(`<th scope="col">`)
+		r.text(title)
+		r.out.WriteString(`</th>`)
+	}
+	r.out.WriteString(`</tr></thead>`)
+}
+
+func (r *htmlRenderer) table(n *node) {
+	cols, types, data := r.source(attrString(n, `source`))
+	titles := make([]string, 0, len(cols))
+	indexes := make([]int, 0, len(cols))
+	if columns, ok := n.Attr[`columns`].([]map[string]string); ok {
+		for _, col := range columns {
+			titles = append(titles, col[`Title`])
+			indexes = append(indexes, columnIndex(cols, col[`Name`]))
+		}
+	} else {
+		for i, col := range cols {
+			titles = append(titles, col)
+			indexes = append(indexes, i)
+		}
+	}
+	r.open(`table`, n)
+	r.headers(titles)
+	r.out.WriteString(`<tbody>`)
+	for _, row := range data {
+		r.out.WriteString(`<tr>`)
+		for _, i := range indexes {
+			r.out.WriteString(`<td>`)
+			if i >= 0 && i < len(row) {
+				var ctype string
+				if i < len(types) {
+					ctype = types[i]
+				}
+				r.cell(ctype, row[i])
+			}
+			r.out.WriteString(`</td>`)
+		}
+		r.out.WriteString(`</tr>`)
+	}
+	r.out.WriteString(`</tbody>`)
+	r.close(`table`)
+}
+
+// chart is rendered as the table of the labels and the values
+func (r *htmlRenderer) chart(n *node) {
+	cols, _, data := r.source(attrString(n, `source`))
+	label, value := attrString(n, `fieldlabel`), attrString(n, `fieldvalue`)
+	labelCol, valueCol := columnIndex(cols, label), columnIndex(cols, value)
+	r.open(`figure`, n, `data-type`, attrString(n, `type`))
+	r.out.WriteString(`<table>`)
+	r.headers([]string{label, value})
+	r.out.WriteString(`<tbody>`)
+	for _, row := range data {
+		r.out.WriteString(`<tr>`)
+		for _, i := range []int{labelCol, valueCol} {
+			r.out.WriteString(`<td>`)
+			if i >= 0 && i < len(row) {
+				r.text(row[i])
+			}
+			r.out.WriteString(`</td>`)
+		}
+		r.out.WriteString(`</tr>`)
+	}
+	r.out.WriteString(`</tbody></table>`)
+	r.close(`figure`)
+}
@@ -0,0 +1,81 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package template
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	var timeout bool
+	vars := make(map[string]string)
+	vars[`_full`] = `0`
+	for _, item := range forHTMLTest {
+		out := Template2HTML(item.input, &timeout, &vars, HTMLOptions{
+			Classes: GetClassMapper(ClassesBootstrap),
+			PageLink: func(page string, params url.Values) string {
+				return `/page/` + url.PathEscape(page) + `?` + params.Encode()
+			},
+		})
+		if string(out) != item.want {
+			t.Errorf("wrong html \r\n%s != \r\n%s", out, item.want)
+		}
+	}
+}
+
+var forHTMLTest = tplList{
+	{`Div(myclass){Text <b>&</b> Span(inner)}`,
+		`<div class="myclass">Text &lt;b&gt;&amp;&lt;/b&gt; <span>inner</span></div>`},
+	{`Data(src, "name,value"){
+		a,1
+		b,2
+	}Table(src, "Name=name,Value=value")`,
+		`<table class="table"><thead><tr><th scope="col">Name</th><th scope="col">Value</th></tr></thead><tbody><tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr></tbody></table>`},
+	{`LinkPage(Body: Open, Page: my page, PageParams: "id=5")`,
+		`<a href="/page/my%20page?id=5">Open</a>`},
+	{`Image(Src: "javascript:alert(1)")`,
+		`<img class="img-responsive" src="#" alt="">`},
+	{`Input(Name: amount, Class: wide).Validate(required: true, maxLength: 10)`,
+		`<input class="form-control wide" id="amount" name="amount" required="required" aria-required="true" maxlength="10" type="text">`},
+	{`Button(Contract: Send){Send}`,
+		`<button class="btn btn-default" type="button" data-contract="Send">Send</button>`},
+}
+
+func TestClassMap(t *testing.T) {
+	classes := ClassMap{`button`: `btn`, `.primary`: `btn-primary`, `span.primary`: `text-primary`, `.hidden`: ``}
+	for _, item := range [][3]string{
+		{`button`, `primary hidden`, `btn btn-primary`},
+		{`span`, `primary other`, `text-primary other`},
+		{`div`, ``, ``},
+	} {
+		if class := classes.Class(item[0], item[1]); class != item[2] {
+			t.Errorf("wrong class %s != %s", class, item[2])
+		}
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/converter"
 	"github.com/AplaProject/go-apla/packages/language"
+	"github.com/AplaProject/go-apla/packages/pagecache"
 	"github.com/AplaProject/go-apla/packages/smart"
 	"github.com/AplaProject/go-apla/packages/utils/tx"
 
@@ -76,6 +77,21 @@ type Workspace struct {
 	Vars          *map[string]Var
 	SmartContract *smart.SmartContract
 	Timeout       *bool
+	components    *components
+	slots         map[string][]*node
+	deps          *pagecache.Deps
+	source        *sourceTarget
+}
+
+// sourceTarget is the source of data which is rendered without the rest of the page
+type sourceTarget struct {
+	name string
+	node *node
+}
+
+// stopped returns true if the time is out or the node of the requested source has been rendered
+func (w *Workspace) stopped() bool {
+	return *w.Timeout || (w.source != nil && w.source.node != nil)
 }
 
 // SetSource sets source to workspace
@@ -361,7 +377,7 @@ func callFunc(curFunc *tplFunc, owner *node, workspace *Workspace, params *[][]r
 	parFunc := parFunc{
 		Workspace: workspace,
 	}
-	if *workspace.Timeout {
+	if workspace.stopped() {
 		return
 	}
 	trim := func(input string, quotes bool) string {
@@ -408,6 +424,7 @@ func callFunc(curFunc *tplFunc, owner *node, workspace *Workspace, params *[][]r
 		for i, v := range pars {
 			pars[i] = language.LangMacro(v, state, getVar(workspace, `lang`))
 			if pars[i] != v {
+				workspace.deps.AddTable(langTable)
 				if parFunc.RawPars == nil {
 					rawpars := make(map[string]string)
 					parFunc.RawPars = &rawpars
@@ -428,7 +445,7 @@ func callFunc(curFunc *tplFunc, owner *node, workspace *Workspace, params *[][]r
 		parFunc.Node = &curNode
 		parFunc.Tails = tailpars
 	}
-	if *workspace.Timeout {
+	if workspace.stopped() {
 		return
 	}
 	parFunc.Pars = &pars
@@ -486,6 +503,10 @@ func callFunc(curFunc *tplFunc, owner *node, workspace *Workspace, params *[][]r
 			appendText(owner, out)
 		}
 	}
+	if workspace.source != nil && workspace.source.node == nil && (curNode.Tag == `dbfind` ||
+		curNode.Tag == `data`) && curNode.Attr[`source`] == workspace.source.name {
+		workspace.source.node = &curNode
+	}
 }
 
 func getFunc(input string, curFunc tplFunc) (*[][]rune, int, *[]*[][]rune) {
@@ -681,8 +702,11 @@ func process(input string, owner *node, workspace *Workspace) {
 			continue
 		}
 		if ch == '(' {
-			if curFunc, isFunc = funcs[string(name[nameOff:])]; isFunc {
-				if *workspace.Timeout {
+			if curFunc, isFunc = funcs[string(name[nameOff:])]; !isFunc {
+				curFunc, isFunc = workspace.getComponent(string(name[nameOff:]))
+			}
+			if isFunc {
+				if workspace.stopped() {
 					return
 				}
 				appendText(owner, macro(string(name[:nameOff]), workspace.Vars))
@@ -723,8 +747,11 @@ func parseArg(arg string, workspace *Workspace) (val string) {
 	return
 }
 
-// Template2JSON converts templates to JSON data
-func Template2JSON(input string, timeout *bool, vars *map[string]string) []byte {
+// buildTree processes templates and returns the top nodes of the tree or nil if the time is out.
+// The tables and rows which have been read are added to deps if it is not nil. If source is not nil
+// the processing stops when the node of the source has been rendered
+func buildTree(input string, timeout *bool, vars *map[string]string, deps *pagecache.Deps,
+	source *sourceTarget) []*node {
 	root := node{}
 	isobs := (*vars)[`obs`] == `true` || (*vars)[`obs`] == `1`
 	sc := smart.SmartContract{
@@ -739,16 +766,32 @@ func Template2JSON(input string, timeout *bool, vars *map[string]string) []byte
 		},
 	}
 	toVars := mapToVar(*vars)
-	process(input, &root, &Workspace{Vars: toVars, Timeout: timeout, SmartContract: &sc})
+	process(input, &root, &Workspace{Vars: toVars, Timeout: timeout, SmartContract: &sc, deps: deps,
+		source: source})
 	if root.Children == nil || *timeout {
-		return []byte(`[]`)
+		return nil
 	}
 	for i, v := range root.Children {
 		if v.Tag == `text` {
 			root.Children[i].Text = macro(v.Text, toVars)
 		}
 	}
-	out, err := json.Marshal(root.Children)
+	return root.Children
+}
+
+// Template2JSON converts templates to JSON data
+func Template2JSON(input string, timeout *bool, vars *map[string]string) []byte {
+	return Template2JSONDeps(input, timeout, vars, nil)
+}
+
+// Template2JSONDeps converts templates to JSON data and collects the tables and rows
+// which have been read into deps
+func Template2JSONDeps(input string, timeout *bool, vars *map[string]string, deps *pagecache.Deps) []byte {
+	children := buildTree(input, timeout, vars, deps, nil)
+	if children == nil {
+		return []byte(`[]`)
+	}
+	out, err := json.Marshal(children)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling template data to json")
 		return []byte(err.Error())
@@ -429,3 +429,34 @@ var forFullTest = tplList{
 			}.Else {Fourth}If(0).Else{ALL right}.What`,
 		`[{"tag":"if","attr":{"condition":"true"},"children":[{"tag":"text","text":"OK"}],"tail":[{"tag":"else","children":[{"tag":"text","text":"false"}]}]},{"tag":"if","attr":{"condition":"false"},"children":[{"tag":"text","text":"FALSE"}],"tail":[{"tag":"elseif","attr":{"condition":"1"},"children":[{"tag":"text","text":"Else OK"}]},{"tag":"else","children":[{"tag":"text","text":"Fourth"}]}]},{"tag":"if","attr":{"condition":"0"},"tail":[{"tag":"else","children":[{"tag":"text","text":"ALL right"}]}]},{"tag":"text","text":".What"}]`},
 }
+
+func TestDBFindList(t *testing.T) {
+	for _, item := range (tplList{
+		{`name, amount`, `[name amount]`},
+		{`["dept", "dept.name"]`, `[dept dept.name]`},
+		{`total=sum(amount),count=count(*)`, `[total=sum(amount) count=count(*)]`},
+		{``, `[]`},
+	}) {
+		if list := fmt.Sprint(dbfindList(item.input)); list != item.want {
+			t.Errorf(`wrong list %s != %s`, list, item.want)
+		}
+	}
+	output := map[string]bool{`dept`: true, `total`: true}
+	for _, item := range (tplList{
+		{`total`, `"total"`},
+		{`[{"total": -1}, "dept"]`, `"total" desc, "dept"`},
+		{`amount`, `Column amount has not been found`},
+	}) {
+		var inOrder interface{} = item.input
+		if strings.HasPrefix(item.input, `[`) {
+			inOrder, _ = parseObject([]rune(item.input))
+		}
+		order, err := outputOrder(inOrder, output)
+		if err != nil {
+			order = err.Error()
+		}
+		if order != item.want {
+			t.Errorf(`wrong order %s != %s`, order, item.want)
+		}
+	}
+}
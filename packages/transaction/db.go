@@ -31,9 +31,11 @@ package transaction
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/mempool"
 	"github.com/AplaProject/go-apla/packages/model"
 	"github.com/AplaProject/go-apla/packages/utils"
 
@@ -43,9 +45,17 @@ import (
 var ErrDuplicatedTx = errors.New("Duplicated transaction")
 var ErrEarlyTime = errors.New("Early transaction time")
 
+// ErrDuplicatedNonce is returned if the transaction differing from the executed one only by the fee is executed
+var ErrDuplicatedNonce = errors.New("Duplicated transaction nonce")
+
 // InsertInLogTx is inserting tx in log
 func InsertInLogTx(t *Transaction, blockID int64) error {
-	ltx := &model.LogTransaction{Hash: t.TxHash, Block: blockID}
+	ltx := &model.LogTransaction{
+		Hash:  t.TxHash,
+		Block: blockID,
+		KeyID: t.TxKeyID,
+		Nonce: append([]byte{}, mempool.Nonce(t.TxSmart)...),
+	}
 	if err := ltx.Create(t.DbTransaction); err != nil {
 		log.WithFields(log.Fields{"error": err, "type": consts.DBError}).Error("insert logged transaction")
 		return utils.ErrInfo(err)
@@ -98,6 +108,24 @@ func CheckLogTx(txHash []byte, transactions, txQueue bool) error {
 	return nil
 }
 
+// CheckLogNonce checks if the transaction with the same nonce has been executed. The pending
+// transaction can be replaced by fee, so the replaced one can still be included in a block
+func CheckLogNonce(dbTransaction *model.DbTransaction, keyID int64, nonce []byte) error {
+	if len(nonce) == 0 {
+		return nil
+	}
+	found, err := model.IsNonceUsed(dbTransaction, keyID, nonce)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting log transaction by nonce")
+		return utils.ErrInfo(err)
+	}
+	if found {
+		log.WithFields(log.Fields{"key_id": keyID, "nonce": nonce, "type": consts.DuplicateObject}).Error("double nonce in log transactions")
+		return ErrDuplicatedNonce
+	}
+	return nil
+}
+
 // DeleteQueueTx deletes a transaction from the queue
 func DeleteQueueTx(dbTransaction *model.DbTransaction, hash []byte) error {
 	delQueueTx := &model.QueueTx{Hash: hash}
@@ -115,10 +143,38 @@ func DeleteQueueTx(dbTransaction *model.DbTransaction, hash []byte) error {
 	return nil
 }
 
+// MarkTransactionBad marks the transaction as bad. If the transaction was sent as a part of a batch
+// then the rest pending transactions of the batch are marked as bad too
 func MarkTransactionBad(dbTransaction *model.DbTransaction, hash []byte, errText string) error {
 	if hash == nil {
 		return nil
 	}
+	batch, err := model.GetTransactionBatch(dbTransaction, hash)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting transaction batch")
+	}
+	if err = markTransactionBad(dbTransaction, hash, errText); err != nil || len(batch) == 0 {
+		return err
+	}
+
+	hashes, err := model.GetBatchHashes(dbTransaction, batch)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting batch transactions")
+		return utils.ErrInfo(err)
+	}
+	batchErr := fmt.Sprintf("batch transaction %x is bad: %s", hash, errText)
+	for _, item := range hashes {
+		if bytes.Equal(item, hash) {
+			continue
+		}
+		if err = markTransactionBad(dbTransaction, item, batchErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markTransactionBad(dbTransaction *model.DbTransaction, hash []byte, errText string) error {
 	model.MarkTransactionUsed(dbTransaction, hash)
 	if len(errText) > 255 {
 		errText = errText[:255]
@@ -152,7 +208,8 @@ func MarkTransactionBad(dbTransaction *model.DbTransaction, hash []byte, errText
 }
 
 // TxParser writes transactions into the queue
-func ProcessQueueTransaction(dbTransaction *model.DbTransaction, hash, binaryTx []byte, myTx bool) error {
+func ProcessQueueTransaction(dbTransaction *model.DbTransaction, qtx *model.QueueTx, myTx bool) error {
+	hash, binaryTx := qtx.Hash, qtx.Data
 	t, err := UnmarshallTransaction(bytes.NewBuffer(binaryTx), true)
 	if err != nil {
 		MarkTransactionBad(dbTransaction, hash, err.Error())
@@ -187,15 +244,33 @@ func ProcessQueueTransaction(dbTransaction *model.DbTransaction, hash, binaryTx
 		return utils.ErrInfo(err)
 	}
 
+	priority, maxSum := mempool.Fee(t.TxSmart)
 	// put with verified=1
 	newTx := &model.Transaction{
-		Hash:     hash,
-		Data:     binaryTx,
-		Type:     int8(t.TxType),
-		KeyID:    t.TxKeyID,
-		Counter:  counter,
-		Verified: 1,
-		HighRate: tx.HighRate,
+		Hash:       hash,
+		Data:       binaryTx,
+		Type:       int8(t.TxType),
+		KeyID:      t.TxKeyID,
+		Counter:    counter,
+		Verified:   1,
+		HighRate:   tx.HighRate,
+		Batch:      qtx.Batch,
+		BatchIndex: qtx.BatchIndex,
+		Time:       t.TxTime,
+		Priority:   priority,
+		MaxSum:     maxSum,
+		Nonce:      mempool.Nonce(t.TxSmart),
+	}
+	dropped, err := mempool.Admit(dbTransaction, newTx)
+	if err == mempool.ErrFull || err == mempool.ErrKeyQuota {
+		return MarkTransactionBad(dbTransaction, hash, err.Error())
+	} else if err != nil {
+		return utils.ErrInfo(err)
+	}
+	for _, item := range dropped {
+		if err = MarkTransactionBad(dbTransaction, item, fmt.Sprintf("dropped from mempool for transaction %x", hash)); err != nil {
+			return err
+		}
 	}
 	err = newTx.Create()
 	if err != nil {
@@ -221,7 +296,7 @@ func ProcessTransactionsQueue(dbTransaction *model.DbTransaction) error {
 		return err
 	}
 	for _, data := range all {
-		err := ProcessQueueTransaction(dbTransaction, data.Hash, data.Data, false)
+		err := ProcessQueueTransaction(dbTransaction, data, false)
 		if err != nil {
 			return utils.ErrInfo(err)
 		}
@@ -37,6 +37,7 @@ import (
 	"github.com/AplaProject/go-apla/packages/consts"
 	"github.com/AplaProject/go-apla/packages/converter"
 	"github.com/AplaProject/go-apla/packages/crypto"
+	"github.com/AplaProject/go-apla/packages/mempool"
 	"github.com/AplaProject/go-apla/packages/model"
 	"github.com/AplaProject/go-apla/packages/script"
 	"github.com/AplaProject/go-apla/packages/smart"
@@ -297,6 +298,9 @@ func (t *Transaction) Check(checkTime int64, checkForDupTr bool) error {
 	if err != nil {
 		return err
 	}
+	if err = CheckLogNonce(nil, t.TxKeyID, mempool.Nonce(t.TxSmart)); err != nil {
+		return err
+	}
 	logger := log.WithFields(log.Fields{"tx_time": t.TxTime})
 	// time in the transaction cannot be more than MAX_TX_FORW seconds of block time
 	if t.TxTime > checkTime {
@@ -326,6 +330,11 @@ func (t *Transaction) Check(checkTime int64, checkForDupTr bool) error {
 }
 
 func (t *Transaction) Play() (string, []smart.FlushInfo, error) {
+	// the transaction with the same nonce can be executed earlier in the same block
+	if err := CheckLogNonce(t.DbTransaction, t.TxKeyID, mempool.Nonce(t.TxSmart)); err != nil {
+		return "", nil, err
+	}
+
 	// smart-contract
 	if t.TxContract != nil {
 		// check that there are enough money in CallContract
@@ -9,6 +9,7 @@ import (
 // ClientTxPreprocessor procees tx from client
 type ClientTxPreprocessor interface {
 	ProcessClientTranstaction([]byte, int64, *log.Entry) (string, error)
+	ProcessClientTxBatch([][]byte, int64, *log.Entry) ([]string, string, error)
 }
 
 // SmartContractRunner run serialized contract
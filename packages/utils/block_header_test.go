@@ -0,0 +1,156 @@
+// Apla Software includes an integrated development
+// environment with a multi-level system for the management
+// of access rights to data, interfaces, and Smart contracts. The
+// technical characteristics of the Apla Software are indicated in
+// Apla Technical Paper.
+
+// Apla Users are granted a permission to deal in the Apla
+// Software without restrictions, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of Apla Software, and to permit persons
+// to whom Apla Software is furnished to do so, subject to the
+// following conditions:
+// * the copyright notice of GenesisKernel and EGAAS S.A.
+// and this permission notice shall be included in all copies or
+// substantial portions of the software;
+// * a result of the dealing in Apla Software cannot be
+// implemented outside of the Apla Platform environment.
+
+// THE APLA SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY
+// OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+// TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+// PARTICULAR PURPOSE, ERROR FREE AND NONINFRINGEMENT. IN
+// NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR
+// THE USE OR OTHER DEALINGS IN THE APLA SOFTWARE.
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AplaProject/go-apla/packages/consts"
+	"github.com/AplaProject/go-apla/packages/converter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func marshalHeader(b *BlockData) []byte {
+	var buf bytes.Buffer
+	buf.Write(converter.DecToBin(b.Version, 2))
+	buf.Write(converter.DecToBin(b.BlockID, 4))
+	buf.Write(converter.DecToBin(b.Time, 4))
+	buf.Write(converter.DecToBin(b.EcosystemID, 4))
+	buf.Write(converter.EncodeLenInt64InPlace(b.KeyID))
+	buf.Write(converter.DecToBin(b.NodePosition, 1))
+	if b.Version >= consts.BV_STATE_ROOT {
+		buf.Write(converter.EncodeLengthPlusData(b.StateRoot))
+	}
+	if b.Version >= consts.BV_COMMIT {
+		buf.Write(converter.EncodeLengthPlusData(b.Commit))
+	}
+	if b.Version >= consts.BV_SNAPSHOT_HASH {
+		buf.Write(converter.EncodeLengthPlusData(b.SnapshotHash))
+	}
+	buf.Write(converter.EncodeLengthPlusData([]byte("sign")))
+	return buf.Bytes()
+}
+
+func TestParseBlockHeaderStateRoot(t *testing.T) {
+	root := bytes.Repeat([]byte{7}, consts.HashSize)
+	header := &BlockData{
+		BlockID:      10,
+		Time:         1520000000,
+		KeyID:        -1234567,
+		NodePosition: 2,
+		StateRoot:    root,
+		Version:      consts.BV_STATE_ROOT,
+	}
+	parsed, err := ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.NoError(t, err)
+	require.Equal(t, root, parsed.StateRoot)
+	require.Equal(t, []byte("sign"), parsed.Sign)
+
+	header.Version = consts.BV_ROLLBACK_HASH
+	parsed, err = ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.NoError(t, err)
+	require.Empty(t, parsed.StateRoot)
+
+	header.Version = consts.BV_STATE_ROOT
+	header.StateRoot = append(root, 1)
+	_, err = ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.Error(t, err)
+
+	// the state root is signed by the node
+	prev := &BlockData{Hash: root}
+	signSource := header.ForSign(prev, []byte("0"))
+	header.StateRoot = root
+	require.NotEqual(t, signSource, header.ForSign(prev, []byte("0")))
+}
+
+func TestParseBlockHeaderCommit(t *testing.T) {
+	header := &BlockData{
+		BlockID:      11,
+		Time:         1520000000,
+		KeyID:        -1234567,
+		NodePosition: 1,
+		StateRoot:    bytes.Repeat([]byte{7}, consts.HashSize),
+		Commit:       []byte("commit of block 9"),
+		Version:      consts.BV_COMMIT,
+	}
+	parsed, err := ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.NoError(t, err)
+	require.Equal(t, header.Commit, parsed.Commit)
+	require.Equal(t, []byte("sign"), parsed.Sign)
+
+	header.Commit = nil
+	parsed, err = ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.NoError(t, err)
+	require.Empty(t, parsed.Commit)
+	require.Equal(t, []byte("sign"), parsed.Sign)
+
+	header.Commit = make([]byte, consts.MaxCommitSize+1)
+	_, err = ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.Error(t, err)
+
+	// the commit is signed by the node
+	prev := &BlockData{Hash: header.StateRoot}
+	signSource := header.ForSign(prev, []byte("0"))
+	header.Commit = []byte("other commit")
+	require.NotEqual(t, signSource, header.ForSign(prev, []byte("0")))
+}
+
+func TestParseBlockHeaderSnapshotHash(t *testing.T) {
+	hash := bytes.Repeat([]byte{5}, consts.HashSize)
+	header := &BlockData{
+		BlockID:      2 * consts.SnapshotCheckpoint,
+		Time:         1520000000,
+		KeyID:        -1234567,
+		NodePosition: 1,
+		StateRoot:    bytes.Repeat([]byte{7}, consts.HashSize),
+		SnapshotHash: hash,
+		Version:      consts.BV_SNAPSHOT_HASH,
+	}
+	parsed, err := ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.NoError(t, err)
+	require.Equal(t, hash, parsed.SnapshotHash)
+	require.Equal(t, []byte("sign"), parsed.Sign)
+
+	header.SnapshotHash = nil
+	parsed, err = ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.NoError(t, err)
+	require.Empty(t, parsed.SnapshotHash)
+
+	header.SnapshotHash = append(hash, 1)
+	_, err = ParseBlockHeader(bytes.NewBuffer(marshalHeader(header)), false)
+	require.Error(t, err)
+
+	// the snapshot hash is signed by the node
+	prev := &BlockData{Hash: header.StateRoot}
+	header.SnapshotHash = hash
+	signSource := header.ForSign(prev, []byte("0"))
+	header.SnapshotHash = nil
+	require.NotEqual(t, signSource, header.ForSign(prev, []byte("0")))
+}
@@ -68,6 +68,9 @@ type BlockData struct {
 	Sign              []byte
 	Hash              []byte
 	RollbacksHash     []byte
+	StateRoot         []byte
+	Commit            []byte
+	SnapshotHash      []byte
 	Version           int
 	PrivateBlockchain bool
 }
@@ -80,6 +83,15 @@ func blockVer(cur, prev *BlockData) (ret string) {
 	if cur.Version >= consts.BV_ROLLBACK_HASH {
 		ret = fmt.Sprintf(",%x", prev.RollbacksHash)
 	}
+	if cur.Version >= consts.BV_STATE_ROOT {
+		ret += fmt.Sprintf(",%x", cur.StateRoot)
+	}
+	if cur.Version >= consts.BV_COMMIT {
+		ret += fmt.Sprintf(",%x", cur.Commit)
+	}
+	if cur.Version >= consts.BV_SNAPSHOT_HASH {
+		ret += fmt.Sprintf(",%x", cur.SnapshotHash)
+	}
 	return
 }
 
@@ -127,6 +139,37 @@ func ParseBlockHeader(binaryBlock *bytes.Buffer, checkMaxSize bool) (BlockData,
 	}
 	block.NodePosition = converter.BinToDec(binaryBlock.Next(1))
 
+	if block.Version >= consts.BV_STATE_ROOT {
+		rootSize, err := converter.DecodeLengthBuf(binaryBlock)
+		if err != nil || rootSize > consts.HashSize || binaryBlock.Len() < rootSize {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "size": rootSize, "error": err}).Error("decoding binary state root")
+			return BlockData{}, fmt.Errorf("bad block format (state root)")
+		}
+		block.StateRoot = binaryBlock.Next(rootSize)
+	}
+
+	if block.Version >= consts.BV_COMMIT {
+		commitSize, err := converter.DecodeLengthBuf(binaryBlock)
+		if err != nil || commitSize > consts.MaxCommitSize || binaryBlock.Len() < commitSize {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "size": commitSize, "error": err}).Error("decoding binary commit")
+			return BlockData{}, fmt.Errorf("bad block format (commit)")
+		}
+		if commitSize > 0 {
+			block.Commit = binaryBlock.Next(commitSize)
+		}
+	}
+
+	if block.Version >= consts.BV_SNAPSHOT_HASH {
+		hashSize, err := converter.DecodeLengthBuf(binaryBlock)
+		if err != nil || hashSize > consts.HashSize || binaryBlock.Len() < hashSize {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "size": hashSize, "error": err}).Error("decoding binary snapshot hash")
+			return BlockData{}, fmt.Errorf("bad block format (snapshot hash)")
+		}
+		if hashSize > 0 {
+			block.SnapshotHash = binaryBlock.Next(hashSize)
+		}
+	}
+
 	if block.BlockID > 1 {
 		signSize, err := converter.DecodeLengthBuf(binaryBlock)
 		if err != nil {